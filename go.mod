@@ -9,10 +9,12 @@ require (
 	github.com/golang/mock v1.5.0
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.8.2
+	go.mongodb.org/mongo-driver v1.11.9
 	golang.org/x/net v0.10.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
 	gorm.io/driver/mysql v1.4.0
+	gorm.io/driver/postgres v1.3.10
+	gorm.io/driver/sqlite v1.3.6
 	gorm.io/gorm v1.23.8
 	vitess.io/vitess v0.11.0
-
 )
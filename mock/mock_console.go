@@ -5,11 +5,12 @@
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
 
+	gomock "github.com/golang/mock/gomock"
 	common "github.com/ylh990835774/ay-go-components/pkg/common"
 	engine "github.com/ylh990835774/ay-go-components/pkg/engine"
-	gomock "github.com/golang/mock/gomock"
 )
 
 // MockConsole is a mock of Console interface.
@@ -77,45 +78,45 @@ func (mr *MockConsoleMockRecorder) Fork(arg0, arg1 interface{}) *gomock.Call {
 }
 
 // QueryHandler mocks base method.
-func (m *MockConsole) QueryHandler(arg0, arg1, arg2 string, arg3 *common.HandlerOptions) *common.QuerySet {
+func (m *MockConsole) QueryHandler(arg0 context.Context, arg1, arg2, arg3 string, arg4 *common.HandlerOptions) *common.QuerySet {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "QueryHandler", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "QueryHandler", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(*common.QuerySet)
 	return ret0
 }
 
 // QueryHandler indicates an expected call of QueryHandler.
-func (mr *MockConsoleMockRecorder) QueryHandler(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockConsoleMockRecorder) QueryHandler(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryHandler", reflect.TypeOf((*MockConsole)(nil).QueryHandler), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryHandler", reflect.TypeOf((*MockConsole)(nil).QueryHandler), arg0, arg1, arg2, arg3, arg4)
 }
 
 // SchemaHandler mocks base method.
-func (m *MockConsole) SchemaHandler(arg0 *common.HandlerOptions) ([]string, error) {
+func (m *MockConsole) SchemaHandler(arg0 context.Context, arg1 *common.HandlerOptions) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SchemaHandler", arg0)
+	ret := m.ctrl.Call(m, "SchemaHandler", arg0, arg1)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SchemaHandler indicates an expected call of SchemaHandler.
-func (mr *MockConsoleMockRecorder) SchemaHandler(arg0 interface{}) *gomock.Call {
+func (mr *MockConsoleMockRecorder) SchemaHandler(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaHandler", reflect.TypeOf((*MockConsole)(nil).SchemaHandler), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaHandler", reflect.TypeOf((*MockConsole)(nil).SchemaHandler), arg0, arg1)
 }
 
 // TableHandler mocks base method.
-func (m *MockConsole) TableHandler(arg0 string, arg1 *common.HandlerOptions) ([]string, error) {
+func (m *MockConsole) TableHandler(arg0 context.Context, arg1 string, arg2 *common.HandlerOptions) (*common.TableSet, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TableHandler", arg0, arg1)
-	ret0, _ := ret[0].([]string)
+	ret := m.ctrl.Call(m, "TableHandler", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*common.TableSet)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // TableHandler indicates an expected call of TableHandler.
-func (mr *MockConsoleMockRecorder) TableHandler(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockConsoleMockRecorder) TableHandler(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TableHandler", reflect.TypeOf((*MockConsole)(nil).TableHandler), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TableHandler", reflect.TypeOf((*MockConsole)(nil).TableHandler), arg0, arg1, arg2)
 }
@@ -5,10 +5,11 @@
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
 
-	common "github.com/ylh990835774/ay-go-components/pkg/common"
 	gomock "github.com/golang/mock/gomock"
+	common "github.com/ylh990835774/ay-go-components/pkg/common"
 )
 
 // MockEngine is a mock of Engine interface.
@@ -49,17 +50,17 @@ func (mr *MockEngineMockRecorder) Close() *gomock.Call {
 }
 
 // Query mocks base method.
-func (m *MockEngine) Query(arg0, arg1, arg2 string, arg3 int64) *common.QuerySet {
+func (m *MockEngine) Query(arg0 context.Context, arg1, arg2, arg3 string, arg4 common.QueryOptions) *common.QuerySet {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Query", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "Query", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(*common.QuerySet)
 	return ret0
 }
 
 // Query indicates an expected call of Query.
-func (mr *MockEngineMockRecorder) Query(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockEngineMockRecorder) Query(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockEngine)(nil).Query), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockEngine)(nil).Query), arg0, arg1, arg2, arg3, arg4)
 }
 
 // RegistryQueryPost mocks base method.
@@ -87,31 +88,31 @@ func (mr *MockEngineMockRecorder) RegistryQueryPrev(arg0 interface{}) *gomock.Ca
 }
 
 // Schema mocks base method.
-func (m *MockEngine) Schema() ([]string, error) {
+func (m *MockEngine) Schema(arg0 context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Schema")
+	ret := m.ctrl.Call(m, "Schema", arg0)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Schema indicates an expected call of Schema.
-func (mr *MockEngineMockRecorder) Schema() *gomock.Call {
+func (mr *MockEngineMockRecorder) Schema(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schema", reflect.TypeOf((*MockEngine)(nil).Schema))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schema", reflect.TypeOf((*MockEngine)(nil).Schema), arg0)
 }
 
 // Table mocks base method.
-func (m *MockEngine) Table(arg0 string) ([]string, error) {
+func (m *MockEngine) Table(arg0 context.Context, arg1 string, arg2 common.QueryOptions) (*common.TableSet, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Table", arg0)
-	ret0, _ := ret[0].([]string)
+	ret := m.ctrl.Call(m, "Table", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*common.TableSet)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Table indicates an expected call of Table.
-func (mr *MockEngineMockRecorder) Table(arg0 interface{}) *gomock.Call {
+func (mr *MockEngineMockRecorder) Table(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Table", reflect.TypeOf((*MockEngine)(nil).Table), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Table", reflect.TypeOf((*MockEngine)(nil).Table), arg0, arg1, arg2)
 }
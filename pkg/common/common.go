@@ -1,18 +1,98 @@
 package common
 
-import "time"
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
 
 const MySQLConsole = "MysqlConsole"
 const MongoDBConsole = "MongoDBConsole"
 const RedisConsole = "RedisConsole"
+const PostgresConsole = "PostgresConsole"
+const SQLiteConsole = "SQLiteConsole"
 
 const MySQLEngine = "MySQLEngine"
 const MongoDBEngine = "MongoDBEngine"
 const RedisEngine = "RedisEngine"
+const PostgresEngine = "PostgresEngine"
+const SQLiteEngine = "SQLiteEngine"
 
 const ActionFetchSchema = "fetchSchema"
 const ActionFetchTable = "fetchTable"
+const ActionFetchObjects = "fetchObjects" // 表及类型、存储过程、函数、触发器、事件
 const ActionSQLQuery = "sqlQuery"
+const ActionExportQuery = "exportQuery"
+
+// actions of write statement approval
+const ActionSubmitTicket = "submitTicket"
+const ActionListTickets = "listTickets"
+const ActionApproveTicket = "approveTicket"
+const ActionRejectTicket = "rejectTicket"
+const ActionExecuteTicket = "executeTicket"
+
+// actions of interactive transaction
+const ActionBeginTx = "beginTx"
+const ActionCommitTx = "commitTx"
+const ActionRollbackTx = "rollbackTx"
+
+// action of multiple statements script
+const ActionExecuteScript = "executeScript"
+
+// actions of structured table metadata
+const ActionDescribeTable = "describeTable"
+const ActionShowCreateTable = "showCreateTable"
+
+// action of editor autocompletion
+const ActionCompleteSQL = "completeSQL"
+
+// action of SQL formatter
+const ActionFormatSQL = "formatSQL"
+
+// DefaultTxIdleTimeout 事务会话默认空闲超时(秒)
+const DefaultTxIdleTimeout = 60
+
+// DefaultCompletionCacheTTL 自动补全元数据缓存的默认过期时间(秒)
+const DefaultCompletionCacheTTL = 60
+
+// 自动补全候选项的类别
+const CompletionKeyword = "keyword"
+const CompletionSchema = "schema"
+const CompletionTable = "table"
+const CompletionColumn = "column"
+const CompletionFunction = "function"
+const CompletionCommand = "command"     // Redis命令
+const CompletionKeyPrefix = "keyPrefix" // Redis Key前缀，以:结尾
+const CompletionKey = "key"
+
+// 导出文件格式
+const ExportFormatCSV = "csv"
+const ExportFormatNDJSON = "ndjson"
+const ExportFormatXLSX = "xlsx"
+const ExportFormatSQL = "sql" // INSERT语句，仅支持MySQL、PostgreSQL、SQLite
+
+// 查询结果列的值类型，由数据库类型和驱动的Go扫描类型推导
+const ColumnKindInt = "int"
+const ColumnKindUint = "uint"
+const ColumnKindFloat = "float"
+const ColumnKindDecimal = "decimal" // 保持字符串避免精度丢失
+const ColumnKindBool = "bool"
+const ColumnKindBit = "bit" // 渲染为无符号整数
+const ColumnKindString = "string"
+const ColumnKindDate = "date"
+const ColumnKindTime = "time"
+const ColumnKindBytes = "bytes" // 非utf8内容渲染为0x开头的十六进制
+const ColumnKindJSON = "json"
+const ColumnKindUnknown = "unknown"
 
 // redis value 数据类型
 const RedisKeyTypeNone = "none" // key不存在
@@ -126,6 +206,23 @@ const (
 	// 写命令
 	StmtRedisZAdd
 	StmtRedisZRem
+
+	// mongodb支持的常用命令
+	// 读命令
+	StmtMongoFind
+	StmtMongoFindOne
+	StmtMongoAggregate
+	StmtMongoCountDocuments
+	StmtMongoEstimatedDocumentCount
+	StmtMongoDistinct
+	// 写命令
+	StmtMongoAggregateOut // 包含$out/$merge阶段的聚合
+	StmtMongoInsertOne
+	StmtMongoInsertMany
+	StmtMongoUpdateOne
+	StmtMongoUpdateMany
+	StmtMongoDeleteOne
+	StmtMongoDeleteMany
 )
 
 var DefaultRedisWhiteCMD = []SQLType{
@@ -164,6 +261,15 @@ var DefaultRedisWhiteCMD = []SQLType{
 	StmtRedisZRangeByScore,
 }
 
+var DefaultMongoDBWhiteCMD = []SQLType{
+	StmtMongoFind,
+	StmtMongoFindOne,
+	StmtMongoAggregate,
+	StmtMongoCountDocuments,
+	StmtMongoEstimatedDocumentCount,
+	StmtMongoDistinct,
+}
+
 var RedisCMDTOSQLType = map[string]SQLType{
 	"type":          StmtRedisType,
 	"exists":        StmtRedisExists,
@@ -215,26 +321,454 @@ var RedisCMDTOSQLType = map[string]SQLType{
 	"zrem":          StmtRedisZRem,
 }
 
+// MongoDBCMDTOSQLType 集合方法名(小写)与命令类型的映射
+// 含$out/$merge阶段的aggregate在解析时单独归类为StmtMongoAggregateOut
+var MongoDBCMDTOSQLType = map[string]SQLType{
+	"find":                   StmtMongoFind,
+	"findone":                StmtMongoFindOne,
+	"aggregate":              StmtMongoAggregate,
+	"countdocuments":         StmtMongoCountDocuments,
+	"estimateddocumentcount": StmtMongoEstimatedDocumentCount,
+	"distinct":               StmtMongoDistinct,
+	"insertone":              StmtMongoInsertOne,
+	"insertmany":             StmtMongoInsertMany,
+	"updateone":              StmtMongoUpdateOne,
+	"updatemany":             StmtMongoUpdateMany,
+	"deleteone":              StmtMongoDeleteOne,
+	"deletemany":             StmtMongoDeleteMany,
+}
+
 // ConnConfig connect information
 type ConnConfig struct {
 	IP       string
 	Port     int
 	UserName string
 	Password string
+
+	FilePath string // sqlite数据库文件路径
 }
 
 type SQLType int
 
+// DefaultPageSize 分页查询未指定PageSize时的默认每页条数
+const DefaultPageSize = 100
+
+// DefaultRollbackMaxRows 生成回滚语句时未指定RollbackMaxRows的默认快照最大行数
+const DefaultRollbackMaxRows = 1000
+
+// QueryOptions
+/*
+Timeout:
+查询超时(秒)
+
+Page、PageSize、Cursor:
+分页查询参数，由每次请求的QueryMeta传入，PageSize大于0或Cursor非空时开启分页
+Cursor为上一页返回的NextCursor，优先于Page使用
+
+ScanAllKeys:
+Redis fetchTable未分页时SCAN全部Key，默认只返回第一页及NextCursor，仅在Key数量可控时由HandlerOptions.QueryOpt开启
+
+PreCheck:
+执行前钩子改写语句后，改写后的语句由PreCheck再次校验，由控制台按拦截规则设置，为nil时不校验
+
+MaskRules:
+查询结果的脱敏规则，由控制台从HandlerOptions.MaskRules设置
+
+RowPolicies:
+MySQL行级权限策略，由控制台从HandlerOptions.RowPolicies设置
+
+LintRules:
+SQL检查规则，由控制台从HandlerOptions.LintRules设置
+
+GenerateRollback:
+MySQL执行UPDATE、DELETE时在事务内先以相同WHERE条件SELECT ... FOR UPDATE快照受影响的行，
+再生成反向的INSERT、UPDATE语句返回，仅支持单表语句，UPDATE需要主键且不能修改主键，不支持的语句不执行
+
+RollbackMaxRows:
+GenerateRollback快照的最大行数，受影响的行超过时不生成回滚语句且语句不执行，默认DefaultRollbackMaxRows
+
+DryRun:
+预执行，由QueryMeta.DryRun设置，仅支持MySQL、Redis
+MySQL写语句执行EXPLAIN及相同WHERE条件的COUNT(*)，Redis写命令返回涉及的Key是否存在及类型，读语句照常执行
+
+Params:
+绑定参数，由QueryMeta.Params设置，仅支持MySQL、SQLite，语句中的 ? 及 :name 以驱动占位符传递参数
+
+RowStream:
+逐行接收MySQL、PostgreSQL、SQLite查询结果，由exportQuery设置，行在扫描时即交给RowStream，不在内存中缓存
+结果的Rows为空，Total为已接收的行数，其他引擎忽略
+*/
 type QueryOptions struct {
-	Timeout int64 // 查询超时(秒)
+	Timeout int64
+
+	Page     int
+	PageSize int
+	Cursor   string
+
+	ScanAllKeys bool
+
+	PreCheck PreCheck
+
+	MaskRules []MaskRule
+
+	RowPolicies []RowPolicy
+
+	LintRules []LintRule
+
+	GenerateRollback bool
+	RollbackMaxRows  int64
+
+	DryRun bool
+
+	Params []QueryParam
+
+	RowStream RowStream
+}
+
+// DryRunReport 预执行报告，写语句未被执行
+type DryRunReport struct {
+	EstimatedRows int64            `json:"estimatedRows"`     // MySQL为COUNT(*)结果，Redis为存在的Key数量
+	Indexes       []string         `json:"indexes,omitempty"` // EXPLAIN中使用的索引
+	Keys          []RedisKeyImpact `json:"keys,omitempty"`
+}
+
+// RedisKeyImpact Redis写命令涉及的Key
+type RedisKeyImpact struct {
+	Key    string `json:"key"`
+	Exists bool   `json:"exists"`
+	Type   string `json:"type"`
+}
+
+// level of lint rule
+const (
+	LintLevelWarn  = "warn"
+	LintLevelError = "error"
+)
+
+// LintIssue violation of lint rule
+type LintIssue struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// TableStats statistics of table used by lint rules
+type TableStats struct {
+	Rows    int64
+	Columns int
+}
+
+// LintContext
+// Schema is the chosen schema, TableStats fetch statistics of table, nil if engine not support
+type LintContext struct {
+	Schema     string
+	TableStats func(schema string, table string) (*TableStats, error)
+}
+
+// LintRule
+/*
+Name:
+规则名，返回给前端用于展示
+
+Level:
+warn: 仅返回警告，语句继续执行
+error: 拒绝执行语句，未知的级别同error
+
+Check:
+检查vitess解析的语法树，返回违反规则的说明，未违反时返回空
+*/
+type LintRule struct {
+	Name  string
+	Level string
+	Check func(st vsqlparser.Statement, lc *LintContext) []string
+}
+
+// RowPolicy
+/*
+Schema、Table:
+受保护的表，通配符语法同path.Match，忽略大小写，Schema为空时匹配全部库
+未限定库名的表属于当前选择的库
+
+Predicate:
+注入SELECT、UPDATE、DELETE语句的谓词，如 tenant_id = ?，未限定表名的列自动限定为受保护的表
+
+Args:
+按位置替换Predicate中的?，支持nil、bool、整数、浮点数、字符串
+*/
+type RowPolicy struct {
+	Schema    string
+	Table     string
+	Predicate string
+	Args      []interface{}
+}
+
+// mask strategy of MaskRule
+const (
+	MaskStrategyPartial = "partial"
+	MaskStrategyHash    = "hash"
+	MaskStrategyRedact  = "redact"
+)
+
+// MaskRedacted masked value of redact strategy
+const MaskRedacted = "******"
+
+// MaskRule
+/*
+Schema、Table、Column:
+MySQL、PostgreSQL、SQLite按库名、表名、列名匹配，MongoDB按库名、集合名、顶层字段名匹配
+通配符语法同path.Match，忽略大小写，Schema、Table为空时匹配全部，Column为空的规则不作用于这些控制台
+
+Key、Field:
+Redis按Key及Hash字段匹配，通配符语法同path.Match，Field为空时脱敏Key的全部值，Key为空的规则不作用于Redis
+
+Strategy:
+partial: 保留前KeepPrefix个及后KeepSuffix个字符，其余替换为*，两者均为0时保留前3后4个字符
+hash: 替换为值的sha256，设置Salt时为以Salt为密钥的hmac-sha256
+redact: 替换为MaskRedacted，未知的策略同redact
+*/
+type MaskRule struct {
+	Schema string
+	Table  string
+	Column string
+
+	Key   string
+	Field string
+
+	Strategy   string
+	KeepPrefix int
+	KeepSuffix int
+	Salt       string
+}
+
+// PreCheck check statement and return the statement to execute, eg: limit is added to select statement
+type PreCheck func(sql string) (string, error)
+
+// RowStream receive rows of query result one by one
+// Begin is called before the first row with SQL、Columns、ColumnTypes of result, query is aborted when it returns error
+// values of row are rendered by column type and masked
+type RowStream interface {
+	Begin(qs *QuerySet) error
+	Row(row Row) error
+}
+
+// IsPaging query is paginated
+func (q QueryOptions) IsPaging() bool {
+	return q.PageSize > 0 || q.Cursor != ""
+}
+
+// PageLimit row count of one page
+func (q QueryOptions) PageLimit() int64 {
+	if q.PageSize > 0 {
+		return int64(q.PageSize)
+	}
+	return DefaultPageSize
+}
+
+// RollbackRowLimit max rows of rollback snapshot
+func (q QueryOptions) RollbackRowLimit() int64 {
+	if q.RollbackMaxRows > 0 {
+		return q.RollbackMaxRows
+	}
+	return DefaultRollbackMaxRows
+}
+
+// PageOffset offset of the first row in current page
+// cursor is preferred over page number
+func (q QueryOptions) PageOffset() (int64, error) {
+	if q.Cursor != "" {
+		return DecodeOffsetCursor(q.Cursor)
+	}
+
+	if q.Page <= 1 {
+		return 0, nil
+	}
+	return int64(q.Page-1) * q.PageLimit(), nil
+}
+
+// EncodeOffsetCursor encode row offset as opaque cursor token
+func EncodeOffsetCursor(offset int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.FormatInt(offset, 10)))
+}
+
+// DecodeOffsetCursor decode cursor token created by EncodeOffsetCursor
+func DecodeOffsetCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, inerr.ErrCursorInvalid
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimPrefix(string(raw), "offset:"), 10, 64)
+	if err != nil || offset < 0 || !strings.HasPrefix(string(raw), "offset:") {
+		return 0, inerr.ErrCursorInvalid
+	}
+	return offset, nil
 }
 
 // QueryMeta request params about query operation
 type QueryMeta struct {
-	Action string `json:"action"` // fetchSchema|fetchTable|sqlQuery
+	Action string `json:"action"` // fetchSchema|fetchTable|fetchObjects|sqlQuery|exportQuery|submitTicket|listTickets|approveTicket|rejectTicket|executeTicket|beginTx|commitTx|rollbackTx|executeScript|describeTable|showCreateTable|completeSQL|formatSQL
 	Schema string `json:"schema"`
 	Table  string `json:"table"` // 在Redis中取值为Key
 	SQL    string `json:"sql"`
+
+	// 分页参数，不传时按原有方式返回全部结果
+	Page     int    `json:"page,omitempty"`
+	PageSize int    `json:"pageSize,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+
+	// 导出格式，exportQuery时使用: csv|ndjson|xlsx|sql
+	Format string `json:"format,omitempty"`
+
+	// 工单参数，TicketID用于审批、驳回、执行，Comment为提交或审批说明，Status用于筛选工单列表
+	TicketID string `json:"ticketId,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+	Status   string `json:"status,omitempty"`
+
+	// 预执行，sqlQuery时使用，写语句不执行，仅返回预计影响的行数、使用的索引或Redis Key
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// 事务会话ID，由beginTx返回，sqlQuery、commitTx、rollbackTx时使用
+	SessionID string `json:"sessionId,omitempty"`
+
+	// executeScript时使用，语句失败后继续执行后续语句，默认遇错即止
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+
+	// 绑定参数，sqlQuery、exportQuery时使用，对应语句中的 ? 或 :name
+	Params []QueryParam `json:"params,omitempty"`
+
+	// completeSQL时使用，光标在SQL中的位置，按字符计算
+	Position int `json:"position,omitempty"`
+}
+
+// type of bind param
+const (
+	ParamTypeString   = "string"
+	ParamTypeInt      = "int"
+	ParamTypeFloat    = "float"
+	ParamTypeDecimal  = "decimal" // 字符串传递，避免精度丢失
+	ParamTypeBool     = "bool"
+	ParamTypeNull     = "null"
+	ParamTypeBytes    = "bytes"    // base64编码
+	ParamTypeDatetime = "datetime" // RFC3339或2006-01-02 15:04:05
+)
+
+// QueryParam
+// bind param of statement, Name is empty for positional param ?
+// positional params are matched in order, same as vitess bind variables :v1、:v2...
+type QueryParam struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Arg value of param passed to driver
+func (p QueryParam) Arg() (interface{}, error) {
+	if p.Type == ParamTypeNull || p.Value == nil {
+		return nil, nil
+	}
+
+	switch p.Type {
+	case ParamTypeString, ParamTypeDecimal, "":
+		return fmt.Sprint(p.Value), nil
+	case ParamTypeInt:
+		switch v := p.Value.(type) {
+		case float64:
+			if v != float64(int64(v)) {
+				return nil, errors.Wrapf(inerr.ErrParamInvalid, "%v is not int", v)
+			}
+			return int64(v), nil
+		case string:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(inerr.ErrParamInvalid, err.Error())
+			}
+			return i, nil
+		}
+	case ParamTypeFloat:
+		switch v := p.Value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, errors.Wrap(inerr.ErrParamInvalid, err.Error())
+			}
+			return f, nil
+		}
+	case ParamTypeBool:
+		switch v := p.Value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errors.Wrap(inerr.ErrParamInvalid, err.Error())
+			}
+			return b, nil
+		}
+	case ParamTypeBytes:
+		if v, ok := p.Value.(string); ok {
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, errors.Wrap(inerr.ErrParamInvalid, err.Error())
+			}
+			return b, nil
+		}
+	case ParamTypeDatetime:
+		if v, ok := p.Value.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t, nil
+			}
+			t, err := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local)
+			if err != nil {
+				return nil, errors.Wrap(inerr.ErrParamInvalid, err.Error())
+			}
+			return t, nil
+		}
+	default:
+		return nil, errors.Wrapf(inerr.ErrParamInvalid, "unknown type %s", p.Type)
+	}
+
+	return nil, errors.Wrapf(inerr.ErrParamInvalid, "%v is not %s", p.Value, p.Type)
+}
+
+// status of statement in script
+const (
+	ScriptStmtSucceed = "succeed"
+	ScriptStmtFailed  = "failed"
+	ScriptStmtSkipped = "skipped" // 前面的语句失败后未执行
+)
+
+// ScriptStatement 脚本中单条语句的执行结果
+type ScriptStatement struct {
+	Index         int       `json:"index"`
+	SQL           string    `json:"sql"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	QueryDuration int64     `json:"queryDuration"` // 毫秒
+	AffectedRows  int64     `json:"affectedRows"`
+	Result        *QuerySet `json:"result,omitempty"`
+}
+
+// ScriptResult 脚本执行结果，语句按脚本中的顺序返回
+type ScriptResult struct {
+	Statements []*ScriptStatement `json:"statements"`
+	Succeed    int                `json:"succeed"`
+	Failed     int                `json:"failed"`
+	Skipped    int                `json:"skipped"`
+}
+
+// TxSession 事务会话信息，ExpireAt为空闲超时自动回滚的时间
+type TxSession struct {
+	SessionID   string    `json:"sessionId"`
+	Schema      string    `json:"schema"`
+	IdleTimeout int64     `json:"idleTimeout"` // 秒
+	ExpireAt    time.Time `json:"expireAt"`
+}
+
+// IsPaging request is paginated
+func (q *QueryMeta) IsPaging() bool {
+	return q.PageSize > 0 || q.Cursor != ""
 }
 
 // Resp response about request
@@ -244,6 +778,62 @@ type Resp struct {
 	Result  interface{} `json:"result"`
 }
 
+// status of ticket
+const (
+	TicketPending   = "pending"
+	TicketApproved  = "approved"
+	TicketRejected  = "rejected"
+	TicketExecuting = "executing"
+	TicketExecuted  = "executed"
+	TicketFailed    = "failed"
+)
+
+// Ticket
+// write statement submitted for approval, executed after approved by another person
+// Instance is the address of database, the ticket can only be executed on the same console and instance
+type Ticket struct {
+	ID          string `json:"id"`
+	ConsoleType string `json:"consoleType"`
+	Instance    string `json:"instance"`
+	Schema      string `json:"schema"`
+	Table       string `json:"table"`
+	SQL         string `json:"sql"`
+	Comment     string `json:"comment"`
+	Status      string `json:"status"`
+
+	Submitter     string `json:"submitter"`
+	Reviewer      string `json:"reviewer,omitempty"`
+	ReviewComment string `json:"reviewComment,omitempty"`
+	Executor      string `json:"executor,omitempty"`
+
+	AffectedRows int64  `json:"affectedRows"`
+	Error        string `json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TicketFilter filter of ticket list, empty field match all
+type TicketFilter struct {
+	ConsoleType string
+	Instance    string
+	Status      string
+}
+
+// TicketStore
+// storage of tickets, see package ticket for memory and sql implementation
+// Update is a compare and set by status, ErrTicketStatus is returned if status of stored ticket is not fromStatus,
+// so a ticket can not be approved or executed twice
+type TicketStore interface {
+	Create(ctx context.Context, ticket *Ticket) error
+	Get(ctx context.Context, id string) (*Ticket, error)
+	List(ctx context.Context, filter TicketFilter) ([]*Ticket, error)
+	Update(ctx context.Context, ticket *Ticket, fromStatus string) error
+}
+
+// RespCodeLintRejected code of response when statement is rejected by lint rules, result has warnings
+const RespCodeLintRejected = 422
+
 type Row map[string]interface{}
 
 // QuerySet
@@ -261,23 +851,187 @@ type QuerySet struct {
 
 	Total int `json:"total"`
 
-	Columns []string `json:"columns"`
-	Rows    []Row    `json:"rows"`
+	Columns     []string     `json:"columns"`
+	ColumnTypes []ColumnMeta `json:"columnTypes,omitempty"` // 与Columns一一对应，Redis、MongoDB不返回
+	Rows        []Row        `json:"rows"`
+
+	// 分页查询时下一页的游标，为空表示没有更多数据
+	NextCursor string `json:"nextCursor,omitempty"`
+
+	// 违反的SQL检查规则，error级别的规则被违反时语句不执行
+	Warnings []LintIssue `json:"warnings,omitempty"`
+
+	// 开启GenerateRollback时UPDATE、DELETE语句的回滚语句，命中MaskRules的列值已脱敏
+	RollbackSQL []string `json:"rollbackSQL,omitempty"`
+
+	// 预执行报告，MySQL的执行计划以Columns、Rows返回
+	DryRun *DryRunReport `json:"dryRun,omitempty"`
 
 	AffectedRows int64 `json:"-"`
 }
 
+// ColumnMeta metadata of query result column from sql.ColumnType
+// Nullable、Length、Precision、Scale are omitted when driver not support
+type ColumnMeta struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"databaseType"`
+	Kind         string `json:"kind"`
+
+	Nullable  *bool  `json:"nullable,omitempty"`
+	Length    *int64 `json:"length,omitempty"`
+	Precision *int64 `json:"precision,omitempty"`
+	Scale     *int64 `json:"scale,omitempty"`
+
+	// 列值被脱敏规则处理过
+	Masked bool `json:"masked,omitempty"`
+}
+
+// TableSet
+type TableSet struct {
+	Tables []string `json:"tables"`
+
+	// 下一页的游标，为空表示没有更多数据
+	NextCursor string `json:"nextCursor,omitempty"`
+
+	// 表的类型，key为Tables中的表名，取值BASE TABLE|VIEW|SYSTEM VIEW，仅MySQL返回
+	TableTypes map[string]string `json:"tableTypes,omitempty"`
+
+	// 存储过程、函数、触发器、事件，仅MySQL在第一页返回
+	Procedures []string `json:"procedures,omitempty"`
+	Functions  []string `json:"functions,omitempty"`
+	Triggers   []string `json:"triggers,omitempty"`
+	Events     []string `json:"events,omitempty"`
+}
+
+// TableDetail 表结构，describeTable返回
+// Rows、DataLength、IndexLength为information_schema中的估算值，SQLite的Rows为实际行数
+type TableDetail struct {
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Type    string `json:"type,omitempty"` // BASE TABLE|VIEW
+	Engine  string `json:"engine,omitempty"`
+	Comment string `json:"comment,omitempty"`
+
+	Rows        int64 `json:"rows"`
+	DataLength  int64 `json:"dataLength"`
+	IndexLength int64 `json:"indexLength"`
+
+	Columns     []TableColumn     `json:"columns"`
+	Indexes     []TableIndex      `json:"indexes"`
+	ForeignKeys []TableForeignKey `json:"foreignKeys"`
+}
+
+// TableColumn 表的列，Default为nil表示没有默认值
+type TableColumn struct {
+	Name         string  `json:"name"`
+	DatabaseType string  `json:"databaseType"`
+	Nullable     bool    `json:"nullable"`
+	Default      *string `json:"default"`
+	PrimaryKey   bool    `json:"primaryKey"`
+	Extra        string  `json:"extra,omitempty"` // 如auto_increment
+	Comment      string  `json:"comment,omitempty"`
+}
+
+// TableIndex 表的索引，Columns按索引中的顺序
+type TableIndex struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+	Type    string   `json:"type,omitempty"` // BTREE|HASH|FULLTEXT...
+	Comment string   `json:"comment,omitempty"`
+}
+
+// TableForeignKey 表的外键，Columns与RefColumns按顺序一一对应
+type TableForeignKey struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefSchema  string   `json:"refSchema"`
+	RefTable   string   `json:"refTable"`
+	RefColumns []string `json:"refColumns"`
+	OnUpdate   string   `json:"onUpdate,omitempty"`
+	OnDelete   string   `json:"onDelete,omitempty"`
+}
+
+// Completion 自动补全候选项，Detail为列所属的表、表的类型等说明
+type Completion struct {
+	Label  string `json:"label"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CompletionSet
+// 自动补全结果，completeSQL返回
+// Start为待替换文本的起始位置(按字符计算)，前端以候选项替换[Start, Position)之间的Prefix
+type CompletionSet struct {
+	Prefix     string       `json:"prefix"`
+	Start      int          `json:"start"`
+	Candidates []Completion `json:"candidates"`
+}
+
+// FormattedStatement 格式化后的单条语句及其指纹
+type FormattedStatement struct {
+	SQL         string `json:"sql"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// FormattedSQL
+// SQL格式化结果，formatSQL返回
+// 多语句脚本逐条格式化，SQL为以分号及空行连接的全部语句
+type FormattedSQL struct {
+	SQL        string               `json:"sql"`
+	Statements []FormattedStatement `json:"statements"`
+}
+
+// TableDDL 建表语句，showCreateTable返回
+type TableDDL struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	DDL    string `json:"ddl"`
+}
+
+// Caller identity and request scoped metadata of who run the statement
+// Principal is set by HandlerOptions.CallerExtractor
+// RemoteIP、RequestID、Headers are filled from http request if extractor not set them
+type Caller struct {
+	Principal string
+	RemoteIP  string
+	RequestID string
+	Headers   http.Header
+	Metadata  map[string]interface{}
+}
+
+type callerCtxKey struct{}
+
+// WithCaller return a copy of ctx which carries caller
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerCtxKey{}, caller)
+}
+
+// CallerFromContext return caller carried by ctx, nil if not set
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerCtxKey{}).(*Caller)
+	return caller
+}
+
 type PrevHookArgs struct {
 	EngineType string
 	Action     string
+	Caller     *Caller
 
 	Schema string
-	SQL    string
+	// describeTable、showCreateTable动作的表名
+	Table string
+	// 执行前钩子可以修改SQL以改写执行的语句，后续钩子看到改写后的语句
+	SQL string
+	// 钩子改写前的语句
+	OriginalSQL string
 }
 
 type PostHookArgs struct {
 	EngineType string
 	Action     string
+	Caller     *Caller
 
 	IsExecute     bool
 	ExecuteAt     time.Time
@@ -285,9 +1039,21 @@ type PostHookArgs struct {
 	Err           error
 
 	Schema string
-	SQL    string
+	// describeTable、showCreateTable动作的表名
+	Table string
+	// 实际执行的语句，执行前钩子改写后的语句
+	SQL string
+	// 钩子改写前的语句
+	OriginalSQL string
+	// MySQL、PostgreSQL、SQLite实际执行语句的指纹，字面量替换为 ? ，同一结构的语句指纹相同，用于审计时聚合，无法解析的语句为空
+	Fingerprint string
 
 	AffectedRows int64
+	// 开启GenerateRollback时UPDATE、DELETE语句的回滚语句，用于归档，列值未脱敏
+	RollbackSQL []string
+
+	// fetchSchema、fetchTable动作返回的schema或table列表，钩子可以修改该列表以过滤返回结果
+	Names []string
 }
 
 // hooks unImplement
@@ -298,16 +1064,70 @@ type PostHook func(*PostHookArgs)
 // EngineBase base struct of egine
 type EngineBase struct {
 	ConnConfig
-	QueryPrev PreHook
-	QueryPost PostHook
+	PrevHooks []PreHook
+	PostHooks []PostHook
 }
 
+// BindPrevHook append hook to pre hook chain, hooks run by bind order
 func (e *EngineBase) BindPrevHook(hook PreHook) {
-	e.QueryPrev = hook
+	if hook == nil {
+		return
+	}
+	e.PrevHooks = append(e.PrevHooks, hook)
 }
 
+// BindPostHook append hook to post hook chain, hooks run by bind order
 func (e *EngineBase) BindPostHook(hook PostHook) {
-	e.QueryPost = hook
+	if hook == nil {
+		return
+	}
+	e.PostHooks = append(e.PostHooks, hook)
+}
+
+// UnbindHook clear hooks bound by request, engine can be reused by other request
+func (e *EngineBase) UnbindHook() {
+	e.PrevHooks = nil
+	e.PostHooks = nil
+}
+
+// RunPrevHooks
+// run pre hooks by order and stop at the first error
+// panic of hook is recovered and returned as error, so query is aborted too
+func (e *EngineBase) RunPrevHooks(args *PrevHookArgs) error {
+	for _, hook := range e.PrevHooks {
+		if err := runPrevHook(hook, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunPostHooks
+// run all post hooks by order, panic of hook is recovered and not stop the following hooks
+func (e *EngineBase) RunPostHooks(args *PostHookArgs) {
+	for _, hook := range e.PostHooks {
+		runPostHook(hook, args)
+	}
+}
+
+func runPrevHook(hook PreHook, args *PrevHookArgs) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(inerr.ErrHookPanic, "%v", r)
+		}
+	}()
+
+	return hook(args)
+}
+
+func runPostHook(hook PostHook, args *PostHookArgs) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("query post hook panic: %v\n%s\n", r, debug.Stack())
+		}
+	}()
+
+	hook(args)
 }
 
 func NewEngineBase(conn ConnConfig) *EngineBase {
@@ -328,6 +1148,36 @@ false: 开启系统内置的拦截器，若用户设置了QueryBeforeHook钩子
 
 QueryBeforeHook:
 执行命令前的钩子函数，用户可以利用该钩子函数进行业务逻辑扩展比如记录，内置拦截器无法满足业务场景等
+
+CallerExtractor:
+从http请求中提取调用者身份及请求元数据，结果通过PrevHookArgs、PostHookArgs的Caller字段传递给钩子函数
+返回错误时请求被拒绝，未设置时Caller仅包含请求的RemoteIP、RequestID、Headers
+
+QueryBeforeHooks、QueryAfterHooks:
+有序的钩子函数列表，在QueryBeforeHook、QueryAfterHook之后按顺序执行，用于组合审计、限流、鉴权等多个钩子
+执行前钩子遇到第一个错误即终止并中止查询，钩子panic视为返回错误
+执行后钩子总是全部执行，某个钩子panic不影响后续钩子
+
+MaskRules:
+查询结果的脱敏规则，在结果返回及导出前生效，匹配多条规则时使用第一条
+
+RowPolicies:
+MySQL控制台的行级权限策略，语句经钩子改写后注入谓词，无法安全注入的语句被拒绝，不受IsIgnoreSystemIntercept影响
+
+LintRules:
+MySQL、SQLite控制台的SQL检查规则，在语句经钩子改写后执行，内置规则见engine.DefaultLintRules
+
+TicketStore:
+写语句审批工单的存储，设置后支持submitTicket、listTickets、approveTicket、rejectTicket、executeTicket动作
+提交及审批需要CallerExtractor提供调用者身份，提交人不能审批自己的工单
+审批通过的工单执行时不受AllowSQLType限制，钩子、行级权限及检查规则照常生效
+
+CompletionCacheTTL:
+completeSQL使用的schema、表、列及Redis Key缓存的过期时间(秒)，按连接及调用者缓存，默认DefaultCompletionCacheTTL
+
+TxIdleTimeout:
+MySQL、PostgreSQL、SQLite控制台事务会话的空闲超时(秒)，超时未执行语句、提交或回滚的事务自动回滚，默认DefaultTxIdleTimeout
+会话内的语句同样受AllowSQLType限制，写语句需要加入AllowSQLType
 */
 type HandlerOptions struct {
 	Conn                    ConnConfig
@@ -336,6 +1186,15 @@ type HandlerOptions struct {
 	IsIgnoreSystemIntercept bool
 	QueryBeforeHook         PreHook
 	QueryAfterHook          PostHook
+	QueryBeforeHooks        []PreHook
+	QueryAfterHooks         []PostHook
+	CallerExtractor         func(req *http.Request) (*Caller, error)
+	MaskRules               []MaskRule
+	RowPolicies             []RowPolicy
+	LintRules               []LintRule
+	TicketStore             TicketStore
+	TxIdleTimeout           int64
+	CompletionCacheTTL      int64
 }
 
 // ConsoleBase  base struct of console
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestEngineBaseHooks(t *testing.T) {
+	t.Run("pre hooks short circuit", func(t *testing.T) {
+		calls := make([]string, 0)
+		denied := errors.New("denied")
+
+		eb := &EngineBase{}
+		eb.BindPrevHook(nil)
+		eb.BindPrevHook(func(*PrevHookArgs) error { calls = append(calls, "audit"); return nil })
+		eb.BindPrevHook(func(*PrevHookArgs) error { calls = append(calls, "auth"); return denied })
+		eb.BindPrevHook(func(*PrevHookArgs) error { calls = append(calls, "rate"); return nil })
+
+		require.Equal(t, denied, eb.RunPrevHooks(&PrevHookArgs{}))
+		require.Equal(t, []string{"audit", "auth"}, calls)
+	})
+
+	t.Run("pre hook panic", func(t *testing.T) {
+		eb := &EngineBase{}
+		eb.BindPrevHook(func(*PrevHookArgs) error { panic("boom") })
+
+		err := eb.RunPrevHooks(&PrevHookArgs{})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, inerr.ErrHookPanic), err.Error())
+	})
+
+	t.Run("post hooks always run", func(t *testing.T) {
+		calls := make([]string, 0)
+
+		eb := &EngineBase{}
+		eb.BindPostHook(func(*PostHookArgs) { calls = append(calls, "first") })
+		eb.BindPostHook(func(*PostHookArgs) { panic("boom") })
+		eb.BindPostHook(func(*PostHookArgs) { calls = append(calls, "last") })
+
+		require.NotPanics(t, func() { eb.RunPostHooks(&PostHookArgs{}) })
+		require.Equal(t, []string{"first", "last"}, calls)
+
+		eb.UnbindHook()
+		require.Empty(t, eb.PostHooks)
+	})
+}
+
+func TestQueryParamArg(t *testing.T) {
+	cases := []struct {
+		param QueryParam
+		arg   interface{}
+	}{
+		{QueryParam{Type: ParamTypeString, Value: "li"}, "li"},
+		{QueryParam{Type: ParamTypeInt, Value: float64(3)}, int64(3)},
+		{QueryParam{Type: ParamTypeInt, Value: "42"}, int64(42)},
+		{QueryParam{Type: ParamTypeFloat, Value: 1.5}, 1.5},
+		{QueryParam{Type: ParamTypeDecimal, Value: "12.3400"}, "12.3400"},
+		{QueryParam{Type: ParamTypeBool, Value: "true"}, true},
+		{QueryParam{Type: ParamTypeNull, Value: "x"}, nil},
+		{QueryParam{Type: ParamTypeBytes, Value: "aGk="}, []byte("hi")},
+	}
+
+	for _, c := range cases {
+		arg, err := c.param.Arg()
+		require.NoError(t, err, c.param)
+		require.Equal(t, c.arg, arg, c.param)
+	}
+
+	for _, param := range []QueryParam{
+		{Type: ParamTypeInt, Value: 1.5},
+		{Type: ParamTypeBool, Value: "yes?"},
+		{Type: ParamTypeDatetime, Value: "yesterday"},
+		{Type: "uuid", Value: "x"},
+	} {
+		_, err := param.Arg()
+		require.ErrorIs(t, err, inerr.ErrParamInvalid, param)
+	}
+}
@@ -0,0 +1,364 @@
+package console
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/utils"
+)
+
+// completionLimit max candidates returned by one completeSQL request
+const completionLimit = 200
+
+// completionConsole console supports autocompletion
+type completionConsole interface {
+	Console
+
+	consoleMetadata() *metadataCache
+}
+
+// metadataCache
+// schemas、tables、columns and redis keys for completion, keyed by connection、caller and object,
+// lists are fetched with hooks, so lists filtered for one caller are not shared with others
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]*metadataEntry
+}
+
+type metadataEntry struct {
+	candidates []common.Completion
+	expireAt   time.Time
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{
+		entries: map[string]*metadataEntry{},
+	}
+}
+
+func (c *metadataCache) consoleMetadata() *metadataCache {
+	return c
+}
+
+// load
+// cached candidates if not expired, otherwise load and cache them, failed load is not cached
+// expired entries are dropped when new entry is cached
+func (c *metadataCache) load(key string, ttl time.Duration, load func() ([]common.Completion, error)) ([]common.Completion, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expireAt) {
+		return entry.candidates, nil
+	}
+
+	candidates, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expireAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = &metadataEntry{
+		candidates: candidates,
+		expireAt:   now.Add(ttl),
+	}
+
+	return candidates, nil
+}
+
+// completionSource
+// metadata of completion request, engine is forked when metadata is not cached
+type completionSource struct {
+	ctx    context.Context
+	cle    Console
+	cache  *metadataCache
+	opt    *common.HandlerOptions
+	schema string
+	ttl    time.Duration
+	caller string
+
+	eg engine.Engine
+}
+
+func (s *completionSource) engine() (engine.Engine, error) {
+	if s.eg != nil {
+		return s.eg, nil
+	}
+
+	eg, err := s.cle.Fork(s.opt.Conn, s.schema)
+	if err != nil {
+		return nil, err
+	}
+	bindHooks(eg, s.opt)
+
+	s.eg = eg
+	return eg, nil
+}
+
+func (s *completionSource) close() {
+	if s.eg != nil {
+		s.cle.Destory(s.eg)
+	}
+}
+
+func (s *completionSource) load(kind string, name string, load func(eg engine.Engine) ([]common.Completion, error)) ([]common.Completion, error) {
+	key := strings.Join([]string{ticketInstance(s.opt.Conn), s.opt.Conn.UserName, s.caller, kind, name}, "\x00")
+	return s.cache.load(key, s.ttl, func() ([]common.Completion, error) {
+		eg, err := s.engine()
+		if err != nil {
+			return nil, err
+		}
+		return load(eg)
+	})
+}
+
+func (s *completionSource) schemas() ([]common.Completion, error) {
+	return s.load(common.CompletionSchema, "", func(eg engine.Engine) ([]common.Completion, error) {
+		schemas, err := eg.Schema(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]common.Completion, 0, len(schemas))
+		for _, schema := range schemas {
+			candidates = append(candidates, common.Completion{Label: schema, Kind: common.CompletionSchema})
+		}
+		return candidates, nil
+	})
+}
+
+// tables tables of schema, type of table is the detail, key of redis is returned as table
+func (s *completionSource) tables(schema string) ([]common.Completion, error) {
+	return s.load(common.CompletionTable, schema, func(eg engine.Engine) ([]common.Completion, error) {
+		// keys of redis are the first page of SCAN
+		tableSet, err := eg.Table(s.ctx, schema, common.QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]common.Completion, 0, len(tableSet.Tables))
+		for _, table := range tableSet.Tables {
+			candidates = append(candidates, common.Completion{
+				Label:  table,
+				Kind:   common.CompletionTable,
+				Detail: tableSet.TableTypes[table],
+			})
+		}
+		return candidates, nil
+	})
+}
+
+// columns columns of table, table is the detail, empty if engine not support engine.Describer
+func (s *completionSource) columns(schema string, table string) ([]common.Completion, error) {
+	return s.load(common.CompletionColumn, schema+"."+table, func(eg engine.Engine) ([]common.Completion, error) {
+		describer, ok := eg.(engine.Describer)
+		if !ok {
+			return []common.Completion{}, nil
+		}
+
+		detail, err := describer.DescribeTable(s.ctx, schema, table)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]common.Completion, 0, len(detail.Columns))
+		for _, column := range detail.Columns {
+			candidates = append(candidates, common.Completion{
+				Label:  column.Name,
+				Kind:   common.CompletionColumn,
+				Detail: table,
+			})
+		}
+		return candidates, nil
+	})
+}
+
+// completeHandler candidates of word before cursor in SQL or redis command
+func completeHandler(w http.ResponseWriter, req *http.Request, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) {
+	result, err := completeSQL(req.Context(), cle, queryMeta, opt)
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, "complete SQL failed"))
+		return
+	}
+
+	utils.RenderData(w, "complete SQL succeed", result)
+}
+
+// completeSQL
+// metadata is best effort, candidates of metadata which fails to load such as denied by hooks are skipped
+func completeSQL(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) (*common.CompletionSet, error) {
+	cc, ok := cle.(completionConsole)
+	if !ok {
+		return nil, inerr.ErrCompletionUnsupported
+	}
+
+	// decode SQL
+	// SQL is encode by base64
+	decodeSQLByte, err := base64.StdEncoding.DecodeString(queryMeta.SQL)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := opt.CompletionCacheTTL
+	if ttl <= 0 {
+		ttl = common.DefaultCompletionCacheTTL
+	}
+
+	src := &completionSource{
+		ctx:    ctx,
+		cle:    cle,
+		cache:  cc.consoleMetadata(),
+		opt:    opt,
+		schema: queryMeta.Schema,
+		ttl:    time.Duration(ttl) * time.Second,
+	}
+	if caller := common.CallerFromContext(ctx); caller != nil {
+		src.caller = caller.Principal
+	}
+	defer src.close()
+
+	if cle.ConsoleType() == common.RedisConsole {
+		return completeRedis(src, string(decodeSQLByte), queryMeta.Position), nil
+	}
+
+	c := engine.ParseSQLCompletion(string(decodeSQLByte), queryMeta.Position)
+	result := &common.CompletionSet{
+		Prefix:     c.Prefix,
+		Start:      c.Start,
+		Candidates: []common.Completion{},
+	}
+
+	candidates := make([]common.Completion, 0)
+	add := func(cs []common.Completion, err error) {
+		if err == nil {
+			candidates = append(candidates, cs...)
+		}
+	}
+
+	switch {
+	case c.Expect == engine.SQLExpectNone:
+		return result, nil
+	case c.Expect == engine.SQLExpectTable:
+		// table of schema qualifier, eg: from shop.
+		if c.Qualifier != "" {
+			add(src.tables(c.Qualifier))
+			break
+		}
+		if queryMeta.Schema != "" {
+			add(src.tables(queryMeta.Schema))
+		}
+		add(src.schemas())
+	case c.Expect == engine.SQLExpectSchema:
+		add(src.schemas())
+	case c.Qualifier != "":
+		// column of table or alias, otherwise table of schema
+		if ref := c.QualifiedTable(); ref != nil {
+			add(src.columns(completionTable(cle, ref, queryMeta.Schema)))
+		} else {
+			add(src.tables(c.Qualifier))
+		}
+	default:
+		for i := range c.Tables {
+			add(src.columns(completionTable(cle, &c.Tables[i], queryMeta.Schema)))
+		}
+		if queryMeta.Schema != "" {
+			add(src.tables(queryMeta.Schema))
+		}
+		for _, fn := range engine.SQLFunctions {
+			candidates = append(candidates, common.Completion{Label: fn, Kind: common.CompletionFunction})
+		}
+		for _, keyword := range engine.SQLKeywords {
+			candidates = append(candidates, common.Completion{Label: keyword, Kind: common.CompletionKeyword})
+		}
+	}
+
+	result.Candidates = engine.FilterCompletions(candidates, c.Prefix, completionLimit)
+	return result, nil
+}
+
+func completionSchema(schema string, defaultSchema string) string {
+	if schema != "" {
+		return schema
+	}
+	return defaultSchema
+}
+
+// completionTable
+// schema and table to describe, qualifier of PostgreSQL table is the namespace in the connected database
+func completionTable(cle Console, ref *engine.SQLTableRef, defaultSchema string) (string, string) {
+	if cle.ConsoleType() != common.PostgresConsole {
+		return completionSchema(ref.Schema, defaultSchema), ref.Table
+	}
+
+	if ref.Schema != "" {
+		return defaultSchema, ref.Schema + "." + ref.Table
+	}
+	return defaultSchema, ref.Table
+}
+
+// completeRedis
+// command names allowed by AllowSQLType at the first word, otherwise keys and key prefixes,
+// keys are the first page of fetchTable
+func completeRedis(src *completionSource, cmd string, pos int) *common.CompletionSet {
+	prefix, start, isCommand := engine.RedisCompletion(cmd, pos)
+	result := &common.CompletionSet{
+		Prefix:     prefix,
+		Start:      start,
+		Candidates: []common.Completion{},
+	}
+
+	if !isCommand {
+		keys, err := src.tables(src.schema)
+		if err != nil {
+			return result
+		}
+
+		names := make([]string, 0, len(keys))
+		for _, key := range keys {
+			names = append(names, key.Label)
+		}
+		result.Candidates = engine.RedisKeyCompletions(names, prefix)
+		if len(result.Candidates) > completionLimit {
+			result.Candidates = result.Candidates[:completionLimit]
+		}
+		return result
+	}
+
+	allowed := map[common.SQLType]bool{}
+	allowSQLType := common.DefaultRedisWhiteCMD
+	if src.opt.AllowSQLType != nil {
+		allowSQLType = src.opt.AllowSQLType
+	}
+	for _, t := range allowSQLType {
+		allowed[t] = true
+	}
+
+	commands := make([]common.Completion, 0)
+	for name, t := range common.RedisCMDTOSQLType {
+		if allowed[t] || src.opt.IsIgnoreSystemIntercept {
+			commands = append(commands, common.Completion{Label: name, Kind: common.CompletionCommand})
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Label < commands[j].Label
+	})
+
+	result.Candidates = engine.FilterCompletions(commands, prefix, completionLimit)
+	return result
+}
@@ -1,9 +1,11 @@
 package console
 
 import (
+	"context"
 	"embed"
 	"encoding/base64"
 	"io/fs"
+	"net"
 	"net/http"
 	"strings"
 
@@ -26,9 +28,10 @@ type Console interface {
 	Fork(common.ConnConfig, string) (engine.Engine, error)
 	Destory(engine.Engine)
 
-	SchemaHandler(opt *common.HandlerOptions) ([]string, error)
-	TableHandler(schema string, opt *common.HandlerOptions) ([]string, error)
-	QueryHandler(schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet
+	// ctx is the context of http request, handler is aborted when request is canceled
+	SchemaHandler(ctx context.Context, opt *common.HandlerOptions) ([]string, error)
+	TableHandler(ctx context.Context, schema string, opt *common.HandlerOptions) (*common.TableSet, error)
+	QueryHandler(ctx context.Context, schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet
 }
 
 // route entrypoint
@@ -70,9 +73,20 @@ func handlerGateway(w http.ResponseWriter, req *http.Request, cle Console, opt *
 		return
 	}
 
+	// page params of current request
+	opt = requestOptions(opt, queryMeta)
+
+	// caller of current request, passed to hooks by request context
+	caller, err := requestCaller(req, opt)
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, "extract caller failed"))
+		return
+	}
+	req = req.WithContext(common.WithCaller(req.Context(), caller))
+
 	switch queryMeta.Action {
 	case common.ActionFetchSchema:
-		result, err := cle.SchemaHandler(opt)
+		result, err := cle.SchemaHandler(req.Context(), opt)
 		if err != nil {
 			utils.RenderErr(w, errors.Wrap(err, "fetch schema failed"))
 			return
@@ -80,13 +94,29 @@ func handlerGateway(w http.ResponseWriter, req *http.Request, cle Console, opt *
 
 		utils.RenderData(w, "fetch schema succeed", result)
 	case common.ActionFetchTable:
-		result, err := cle.TableHandler(queryMeta.Schema, opt)
+		result, err := cle.TableHandler(req.Context(), queryMeta.Schema, opt)
 		if err != nil {
 			utils.RenderErr(w, errors.Wrap(err, "fetch table failed"))
 			return
 		}
 
+		// render table list as before if request is not paginated,
+		// keys of redis are the first page of SCAN, the cursor of next page is rendered too
+		if !queryMeta.IsPaging() && result.NextCursor == "" {
+			utils.RenderData(w, "fetch table succeed", result.Tables)
+			return
+		}
+
 		utils.RenderData(w, "fetch table succeed", result)
+	case common.ActionFetchObjects:
+		// table list with types and other objects of schema, hooks run as fetchTable
+		result, err := cle.TableHandler(req.Context(), queryMeta.Schema, opt)
+		if err != nil {
+			utils.RenderErr(w, errors.Wrap(err, "fetch objects failed"))
+			return
+		}
+
+		utils.RenderData(w, "fetch objects succeed", result)
 	case common.ActionSQLQuery:
 		// decode SQL
 		// SQL is encode by base64
@@ -96,18 +126,174 @@ func handlerGateway(w http.ResponseWriter, req *http.Request, cle Console, opt *
 			return
 		}
 
-		result := cle.QueryHandler(queryMeta.Schema, queryMeta.Table, string(decodeSQLByte), opt)
+		var result *common.QuerySet
+		if queryMeta.SessionID != "" {
+			// statement run in transaction of session
+			result = txQuery(req.Context(), cle, queryMeta, string(decodeSQLByte), opt)
+		} else {
+			result = cle.QueryHandler(req.Context(), queryMeta.Schema, queryMeta.Table, string(decodeSQLByte), opt)
+		}
 		if result.Err != nil {
-			utils.RenderErr(w, errors.Wrap(result.Err, "query failed"))
+			renderQueryErr(w, result, "query failed")
 			return
 		}
 
 		utils.RenderData(w, "query succeed", result)
+	case common.ActionExportQuery:
+		exportHandler(w, req, cle, queryMeta, opt)
+	case common.ActionSubmitTicket, common.ActionListTickets, common.ActionApproveTicket,
+		common.ActionRejectTicket, common.ActionExecuteTicket:
+		ticketHandler(w, req, cle, queryMeta, opt)
+	case common.ActionBeginTx, common.ActionCommitTx, common.ActionRollbackTx:
+		txHandler(w, req, cle, queryMeta, opt)
+	case common.ActionExecuteScript:
+		scriptHandler(w, req, cle, queryMeta, opt)
+	case common.ActionDescribeTable, common.ActionShowCreateTable:
+		describeHandler(w, req, cle, queryMeta, opt)
+	case common.ActionCompleteSQL:
+		completeHandler(w, req, cle, queryMeta, opt)
+	case common.ActionFormatSQL:
+		formatHandler(w, req, cle, queryMeta, opt)
 	default:
 		utils.RenderErr(w, errors.Wrap(inerr.ErrUnsupportedOperation, queryMeta.Action))
 	}
 }
 
+// renderQueryErr
+// statement rejected by lint rules is rendered with warnings in result, so the page can show each violation
+func renderQueryErr(w http.ResponseWriter, result *common.QuerySet, msg string) {
+	err := errors.Wrap(result.Err, msg)
+	if errors.Is(result.Err, inerr.ErrSQLLintRejected) {
+		utils.RenderErrWithData(w, common.RespCodeLintRejected, err, result)
+		return
+	}
+
+	utils.RenderErr(w, err)
+}
+
+// requestOptions
+// copy handler options with page params、dry run flag and bind params of request, options shared by requests are not modified
+// dry run only works for sqlQuery action, bind params work for sqlQuery and exportQuery action
+func requestOptions(opt *common.HandlerOptions, queryMeta *common.QueryMeta) *common.HandlerOptions {
+	dryRun := queryMeta.DryRun && queryMeta.Action == common.ActionSQLQuery
+	var params []common.QueryParam
+	if queryMeta.Action == common.ActionSQLQuery || queryMeta.Action == common.ActionExportQuery {
+		params = queryMeta.Params
+	}
+	if !queryMeta.IsPaging() && !dryRun && len(params) == 0 {
+		return opt
+	}
+
+	reqOpt := &common.HandlerOptions{}
+	if opt != nil {
+		*reqOpt = *opt
+	}
+	reqOpt.QueryOpt.Page = queryMeta.Page
+	reqOpt.QueryOpt.PageSize = queryMeta.PageSize
+	reqOpt.QueryOpt.Cursor = queryMeta.Cursor
+	reqOpt.QueryOpt.DryRun = dryRun
+	reqOpt.QueryOpt.Params = params
+
+	return reqOpt
+}
+
+// unpagedOptions copy handler options without page params, used by statements not shown page by page
+func unpagedOptions(opt *common.HandlerOptions) *common.HandlerOptions {
+	reqOpt := *opt
+	reqOpt.QueryOpt.Page = 0
+	reqOpt.QueryOpt.PageSize = 0
+	reqOpt.QueryOpt.Cursor = ""
+
+	return &reqOpt
+}
+
+// requestCaller
+// extract caller by CallerExtractor of handler options,
+// RemoteIP、RequestID、Headers not set by extractor are filled from request
+// X-Forwarded-For is not trusted here, extractor should set RemoteIP behind proxy
+func requestCaller(req *http.Request, opt *common.HandlerOptions) (*common.Caller, error) {
+	caller := &common.Caller{}
+	if opt != nil && opt.CallerExtractor != nil {
+		extracted, err := opt.CallerExtractor(req)
+		if err != nil {
+			return nil, err
+		}
+		if extracted != nil {
+			caller = extracted
+		}
+	}
+
+	if caller.RemoteIP == "" {
+		caller.RemoteIP = req.RemoteAddr
+		if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+			caller.RemoteIP = host
+		}
+	}
+	if caller.RequestID == "" {
+		caller.RequestID = req.Header.Get("X-Request-Id")
+	}
+	if caller.Headers == nil {
+		caller.Headers = req.Header.Clone()
+	}
+
+	return caller, nil
+}
+
+// queryOptions
+// return query options with default timeout、mask rules、row policies and lint rules, and limit added to select statement
+// paginated query is limited to the end of current page, one more row to know whether next page exists
+func queryOptions(opt *common.HandlerOptions) (common.QueryOptions, int64, error) {
+	queryOpt := opt.QueryOpt
+	if queryOpt.Timeout <= 0 {
+		queryOpt.Timeout = 15
+	}
+	if len(opt.MaskRules) > 0 {
+		queryOpt.MaskRules = opt.MaskRules
+	}
+	if len(opt.RowPolicies) > 0 {
+		queryOpt.RowPolicies = opt.RowPolicies
+	}
+	if len(opt.LintRules) > 0 {
+		queryOpt.LintRules = opt.LintRules
+	}
+
+	if !queryOpt.IsPaging() {
+		return queryOpt, 100, nil
+	}
+
+	offset, err := queryOpt.PageOffset()
+	if err != nil {
+		return queryOpt, 0, err
+	}
+
+	return queryOpt, offset + queryOpt.PageLimit() + 1, nil
+}
+
+// bindHooks
+// bind hooks of handler options to engine by order,
+// QueryBeforeHook/QueryAfterHook run before hooks of list
+func bindHooks(eg engine.Engine, opt *common.HandlerOptions) {
+	eg.RegistryQueryPrev(opt.QueryBeforeHook)
+	for _, hook := range opt.QueryBeforeHooks {
+		eg.RegistryQueryPrev(hook)
+	}
+
+	eg.RegistryQueryPost(opt.QueryAfterHook)
+	for _, hook := range opt.QueryAfterHooks {
+		eg.RegistryQueryPost(hook)
+	}
+}
+
+// newEngineCache create engine cache by user provided options, default options if not set
+func newEngineCache(newEngine func() engine.CacheableEngine, cacheOpt []engine.CacheOptions) *engine.EngineCache {
+	opt := engine.DefaultCacheOptions
+	if len(cacheOpt) > 0 {
+		opt = cacheOpt[0]
+	}
+
+	return engine.NewEngineCache(newEngine, opt)
+}
+
 func staticFileHandler(w http.ResponseWriter, req *http.Request, consolePath string, consoleType string) {
 	// handler console  static files about component fronentend pages
 	if strings.Contains(consolePath, ":") || strings.Contains(consolePath, "*") {
@@ -24,7 +24,7 @@ func TestHandlerRoute(t *testing.T) {
 
 	fakeHandlerOpt := &common.HandlerOptions{}
 	fakeSchemaList := []string{"database01", "database02"}
-	fakeTableList := []string{"table01", "table02"}
+	fakeTableList := &common.TableSet{Tables: []string{"table01", "table02"}}
 	fakeQuerySet := &common.QuerySet{
 		EngineType: common.MySQLEngine,
 		Action:     common.ActionSQLQuery,
@@ -50,9 +50,9 @@ func TestHandlerRoute(t *testing.T) {
 		AffectedRows: 100,
 	}
 
-	fakeconsole.EXPECT().SchemaHandler(fakeHandlerOpt).Return(fakeSchemaList, nil).AnyTimes()
-	fakeconsole.EXPECT().TableHandler("database01", fakeHandlerOpt).Return(fakeTableList, nil).AnyTimes()
-	fakeconsole.EXPECT().QueryHandler("database01", "table01", "select * from table01", fakeHandlerOpt).Return(fakeQuerySet).AnyTimes()
+	fakeconsole.EXPECT().SchemaHandler(gomock.Any(), fakeHandlerOpt).Return(fakeSchemaList, nil).AnyTimes()
+	fakeconsole.EXPECT().TableHandler(gomock.Any(), "database01", fakeHandlerOpt).Return(fakeTableList, nil).AnyTimes()
+	fakeconsole.EXPECT().QueryHandler(gomock.Any(), "database01", "table01", "select * from table01", fakeHandlerOpt).Return(fakeQuerySet).AnyTimes()
 	fakeconsole.EXPECT().ConsoleType().Return("fakeConsole").AnyTimes()
 
 	t.Run("fetch schema", func(t *testing.T) {
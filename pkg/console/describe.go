@@ -0,0 +1,53 @@
+package console
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/utils"
+)
+
+// describeHandler
+// structured metadata or create statement of table, engine must implement engine.Describer
+func describeHandler(w http.ResponseWriter, req *http.Request, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) {
+	data, err := describeTable(req.Context(), cle, queryMeta, opt)
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, queryMeta.Action+" failed"))
+		return
+	}
+
+	utils.RenderData(w, queryMeta.Action+" succeed", data)
+}
+
+func describeTable(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) (interface{}, error) {
+	if queryMeta.Schema == "" {
+		return nil, inerr.ErrSchemaEmpty
+	}
+	if queryMeta.Table == "" {
+		return nil, inerr.ErrTableEmpty
+	}
+
+	// fork engine instance
+	eg, err := cle.Fork(opt.Conn, queryMeta.Schema)
+	if err != nil {
+		return nil, err
+	}
+	defer cle.Destory(eg) // destory engine instance
+
+	describer, ok := eg.(engine.Describer)
+	if !ok {
+		return nil, inerr.ErrDescribeUnsupported
+	}
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	if queryMeta.Action == common.ActionShowCreateTable {
+		return describer.ShowCreateTable(ctx, queryMeta.Schema, queryMeta.Table)
+	}
+	return describer.DescribeTable(ctx, queryMeta.Schema, queryMeta.Table)
+}
@@ -0,0 +1,129 @@
+package console
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/export"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/utils"
+)
+
+// exportHandler
+// re-run query by QueryHandler, so system intercept、hooks and row limit are same as sqlQuery
+// result is written as downloadable file, rows of sql engine are written as they are scanned
+func exportHandler(w http.ResponseWriter, req *http.Request, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) {
+	// decode SQL
+	// SQL is encode by base64
+	decodeSQLByte, err := base64.StdEncoding.DecodeString(queryMeta.SQL)
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, "export failed"))
+		return
+	}
+	sql := string(decodeSQLByte)
+
+	if err = export.Valid(queryMeta.Format); err != nil {
+		utils.RenderErr(w, errors.Wrap(err, "export failed"))
+		return
+	}
+
+	stream := &exportStream{w: w, format: queryMeta.Format, table: queryMeta.Table}
+	reqOpt := *opt
+	reqOpt.QueryOpt.RowStream = stream
+
+	result := cle.QueryHandler(req.Context(), queryMeta.Schema, queryMeta.Table, sql, &reqOpt)
+	if !stream.started {
+		if result.Err != nil {
+			renderQueryErr(w, result, "export failed")
+			return
+		}
+
+		// result of redis and mongodb is not streamed
+		err = stream.Begin(result)
+		if err != nil && !stream.started {
+			utils.RenderErr(w, errors.Wrap(err, "export failed"))
+			return
+		}
+		for i := 0; err == nil && i < len(result.Rows); i++ {
+			err = stream.Row(result.Rows[i])
+		}
+	}
+
+	// header is sent, error can not be rendered
+	if err == nil {
+		err = result.Err
+	}
+	if err == nil {
+		err = stream.writer.Close()
+	}
+	if err != nil {
+		fmt.Printf("export query result failed: %s\n", err)
+	}
+}
+
+// exportStream write rows of query result to response
+// attachment header is sent by Begin, so query error before it is still rendered as response
+type exportStream struct {
+	w      http.ResponseWriter
+	format string
+	table  string
+
+	started bool
+	writer  export.RowWriter
+}
+
+func (s *exportStream) Begin(qs *common.QuerySet) error {
+	// INSERT statement is only supported by sql engine
+	if err := export.ValidEngine(s.format, qs.EngineType); err != nil {
+		return err
+	}
+
+	// columns of INSERT statement should exist in table, so statement should be a select over a single table
+	table := s.table
+	if s.format == common.ExportFormatSQL {
+		tb, err := exportTable(qs)
+		if err != nil {
+			return err
+		}
+		table = tb
+	}
+
+	utils.RenderAttachment(s.w, export.FileName(table, s.format), export.ContentType(s.format))
+	s.started = true
+
+	writer, err := export.NewRowWriter(s.w, s.format, table, qs)
+	if err != nil {
+		return err
+	}
+	s.writer = writer
+	return nil
+}
+
+func (s *exportStream) Row(row common.Row) error {
+	return s.writer.WriteRow(row)
+}
+
+// exportTable table of executed select statement, error if it is not over a single table
+func exportTable(qs *common.QuerySet) (string, error) {
+	var table string
+	var err error
+	switch qs.EngineType {
+	case common.PostgresEngine:
+		table, err = engine.PostgresTableFromSelectSQL(qs.SQL)
+	default:
+		table, err = engine.MySQLTableFromSelectSQL(qs.SQL)
+		if err == nil && strings.EqualFold(table, "dual") {
+			table = ""
+		}
+	}
+
+	if err != nil || table == "" {
+		return "", errors.Wrap(inerr.ErrExportNotSingleTable, qs.SQL)
+	}
+	return table, nil
+}
@@ -0,0 +1,43 @@
+package console
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/utils"
+)
+
+// formatHandler
+// pretty-print SQL and fingerprint each statement, SQL is not executed so engine is not forked and hooks are not run
+func formatHandler(w http.ResponseWriter, req *http.Request, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) {
+	result, err := formatSQL(cle, queryMeta)
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, "format SQL failed"))
+		return
+	}
+
+	utils.RenderData(w, "format SQL succeed", result)
+}
+
+// formatSQL
+// vitess prints identifiers quoted by backtick, so only MySQL and SQLite console are supported
+func formatSQL(cle Console, queryMeta *common.QueryMeta) (*common.FormattedSQL, error) {
+	switch cle.ConsoleType() {
+	case common.MySQLConsole, common.SQLiteConsole:
+	default:
+		return nil, inerr.ErrFormatUnsupported
+	}
+
+	// decode SQL
+	// SQL is encode by base64
+	decodeSQLByte, err := base64.StdEncoding.DecodeString(queryMeta.SQL)
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.FormatSQL(string(decodeSQLByte))
+}
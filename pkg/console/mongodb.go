@@ -0,0 +1,155 @@
+package console
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+type mongoDBConsole struct {
+	*engine.EngineCache
+	*common.ConsoleBase
+}
+
+func (m *mongoDBConsole) ConsoleType() string {
+	return common.MongoDBConsole
+}
+
+func (m *mongoDBConsole) Fork(conn common.ConnConfig, schema string) (engine.Engine, error) {
+	// lease a warm engine from cache
+	eg, err := m.Acquire(conn, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return eg, nil
+}
+
+func (m *mongoDBConsole) Destory(e engine.Engine) {
+	// return engine to cache instead of closing driver
+	m.Release(e.(*engine.MongoEngine))
+}
+
+func (m *mongoDBConsole) SchemaHandler(ctx context.Context, opt *common.HandlerOptions) ([]string, error) {
+	// fork engine instance
+	eg, err := m.Fork(opt.Conn, "")
+	if err != nil {
+		return nil, err
+	}
+	defer m.Destory(eg) // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// fetch schema
+	schemas, err := eg.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+func (m *mongoDBConsole) TableHandler(ctx context.Context, schema string, opt *common.HandlerOptions) (*common.TableSet, error) {
+	// fork engine instance
+	eg, err := m.Fork(opt.Conn, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Destory(eg) // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// fetch collections
+	tables, err := eg.Table(ctx, schema, opt.QueryOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+func (m *mongoDBConsole) QueryHandler(ctx context.Context, schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet {
+	// fork engine instance
+	eg, err := m.Fork(opt.Conn, schema)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "mongodb engine fork failed"),
+		}
+	}
+	defer m.Destory(eg) // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// query options of current request
+	queryOpt, queryLimit, err := queryOptions(opt)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "query options invalid"),
+		}
+	}
+
+	var preProcessSQL string
+	defaultSafeCMD := common.DefaultMongoDBWhiteCMD
+
+	// if sql is empty
+	// find documents of collection as default command
+	if sql == "" {
+		sql = fmt.Sprintf(`db.getCollection("%s").find({})`, table)
+	}
+
+	// system intercept
+	// check mongodb command is valid by user provided white list
+	// if user not set, valid by default white list
+	// otherwise valid by user provided
+	if opt.AllowSQLType != nil {
+		defaultSafeCMD = opt.AllowSQLType
+	}
+
+	// preCheck is also used to check command rewritten by hooks
+	preCheck := func(sql string) (string, error) {
+		preProcessSQL, isSafe, err := engine.IsMongoCMDSafeWithLimit(sql, defaultSafeCMD, queryLimit)
+		if err != nil {
+			return "", errors.Wrap(err, "mongodb command preCheck failed")
+		}
+
+		if !isSafe {
+			return "", errors.Wrap(inerr.ErrMongoCMDForbidden, sql)
+		}
+		return preProcessSQL, nil
+	}
+
+	// valid is turn off system intercpet
+	if opt.IsIgnoreSystemIntercept {
+		preProcessSQL = sql
+		goto queryMain
+	}
+
+	preProcessSQL, err = preCheck(sql)
+	if err != nil {
+		return &common.QuerySet{
+			Err: err,
+		}
+	}
+	queryOpt.PreCheck = preCheck
+
+queryMain:
+	// query execute
+	return eg.Query(ctx, schema, table, preProcessSQL, queryOpt)
+}
+
+// NewMongoDBConsole
+func NewMongoDBConsole(cacheOpt ...engine.CacheOptions) *mongoDBConsole {
+	return &mongoDBConsole{
+		newEngineCache(func() engine.CacheableEngine {
+			return engine.NewMongoEngine()
+		}, cacheOpt),
+		common.NewConsoleBase(),
+	}
+}
@@ -1,8 +1,8 @@
 package console
 
 import (
+	"context"
 	"fmt"
-	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/ylh990835774/ay-go-components/pkg/common"
@@ -11,8 +11,10 @@ import (
 )
 
 type mySQLConsole struct {
-	sync.Pool
+	*engine.EngineCache
 	*common.ConsoleBase
+	*txSessions
+	*metadataCache
 }
 
 func (m *mySQLConsole) ConsoleType() string {
@@ -20,9 +22,8 @@ func (m *mySQLConsole) ConsoleType() string {
 }
 
 func (m *mySQLConsole) Fork(conn common.ConnConfig, schema string) (engine.Engine, error) {
-	eg := m.Get().(*engine.MySQLEngine)
-
-	err := eg.InitialDriver(conn, schema)
+	// lease a warm engine from cache
+	eg, err := m.Acquire(conn, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -31,13 +32,11 @@ func (m *mySQLConsole) Fork(conn common.ConnConfig, schema string) (engine.Engin
 }
 
 func (m *mySQLConsole) Destory(e engine.Engine) {
-	eg := e.(*engine.MySQLEngine)
-	eg.Reset()
-
-	m.Put(eg)
+	// return engine to cache instead of closing driver
+	m.Release(e.(*engine.MySQLEngine))
 }
 
-func (m *mySQLConsole) SchemaHandler(opt *common.HandlerOptions) ([]string, error) {
+func (m *mySQLConsole) SchemaHandler(ctx context.Context, opt *common.HandlerOptions) ([]string, error) {
 	// fork engine instance
 	eg, err := m.Fork(opt.Conn, "")
 	if err != nil {
@@ -46,11 +45,10 @@ func (m *mySQLConsole) SchemaHandler(opt *common.HandlerOptions) ([]string, erro
 	defer m.Destory(eg) // destory engine instance
 
 	// bind hooks
-	eg.RegistryQueryPrev(opt.QueryBeforeHook)
-	eg.RegistryQueryPost(opt.QueryAfterHook)
+	bindHooks(eg, opt)
 
 	// fetch schema
-	schemas, err := eg.Schema()
+	schemas, err := eg.Schema(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +56,7 @@ func (m *mySQLConsole) SchemaHandler(opt *common.HandlerOptions) ([]string, erro
 	return schemas, nil
 }
 
-func (m *mySQLConsole) TableHandler(schema string, opt *common.HandlerOptions) ([]string, error) {
+func (m *mySQLConsole) TableHandler(ctx context.Context, schema string, opt *common.HandlerOptions) (*common.TableSet, error) {
 	// fork engine instance
 	eg, err := m.Fork(opt.Conn, schema)
 	if err != nil {
@@ -67,11 +65,10 @@ func (m *mySQLConsole) TableHandler(schema string, opt *common.HandlerOptions) (
 	defer m.Destory(eg) // destory engine instance
 
 	// bind hooks
-	eg.RegistryQueryPrev(opt.QueryBeforeHook)
-	eg.RegistryQueryPost(opt.QueryAfterHook)
+	bindHooks(eg, opt)
 
 	// fetch tables
-	tables, err := eg.Table(schema)
+	tables, err := eg.Table(ctx, schema, opt.QueryOpt)
 	if err != nil {
 		return nil, err
 	}
@@ -79,32 +76,47 @@ func (m *mySQLConsole) TableHandler(schema string, opt *common.HandlerOptions) (
 	return tables, nil
 }
 
-func (m *mySQLConsole) QueryHandler(schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet {
-	// fork engine instance
-	eg, err := m.Fork(opt.Conn, schema)
+// allowSQLType
+// set default SQL Allow Rule
+// select、show、desc、explain statement
+func (m *mySQLConsole) allowSQLType(opt *common.HandlerOptions) []common.SQLType {
+	if opt.AllowSQLType != nil {
+		return opt.AllowSQLType
+	}
+
+	return []common.SQLType{
+		common.StmtSelect,
+		common.StmtShow,
+		common.StmtExplain,
+	}
+}
+
+// splitScript split script into statements by the grammar of engine
+func (m *mySQLConsole) splitScript(script string) ([]string, error) {
+	return engine.SplitSQLScript(script)
+}
+
+// preCheck check statement by the grammar of engine, limit is not added
+func (m *mySQLConsole) preCheck(sql string, allowSQLType []common.SQLType) (string, bool, error) {
+	return engine.MySQLPreCheck(sql, allowSQLType)
+}
+
+func (m *mySQLConsole) QueryHandler(ctx context.Context, schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet {
+	// fork engine instance, or use engine of transaction session
+	eg, release, err := forkEngine(ctx, m, opt.Conn, schema)
 	if err != nil {
 		return &common.QuerySet{
 			Err: errors.Wrap(err, "mysql engine fork failed"),
 		}
 	}
-	defer m.Destory(eg) // destory engine instance
+	defer release() // destory engine instance
 
 	// bind hooks
-	eg.RegistryQueryPrev(opt.QueryBeforeHook)
-	eg.RegistryQueryPost(opt.QueryAfterHook)
+	bindHooks(eg, opt)
 
 	// query
 	// sql preCheck inner system
-	// set default SQL Allow Rule
-	// select、show、desc、explain statement
-	defaultAllowSQLType := []common.SQLType{
-		common.StmtSelect,
-		common.StmtShow,
-		common.StmtExplain,
-	}
-	if opt.AllowSQLType != nil {
-		defaultAllowSQLType = opt.AllowSQLType
-	}
+	defaultAllowSQLType := m.allowSQLType(opt)
 
 	// if sql is empty
 	// assign desc table as default sql
@@ -112,8 +124,28 @@ func (m *mySQLConsole) QueryHandler(schema string, table string, sql string, opt
 		sql = fmt.Sprintf("desc %s", table)
 	}
 
+	// query options of current request
+	queryOpt, queryLimit, err := queryOptions(opt)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "query options invalid"),
+		}
+	}
+
 	var preProcessSQL string
-	var isPass bool
+
+	// preCheck is also used to check statement rewritten by hooks
+	preCheck := func(sql string) (string, error) {
+		preProcessSQL, isPass, err := engine.MySQLPreCheckWithLimit(sql, defaultAllowSQLType, queryLimit)
+		if err != nil {
+			return "", errors.Wrap(err, "sql preCheck failed")
+		}
+
+		if !isPass {
+			return "", errors.Wrap(inerr.ErrSQLForbidden, sql)
+		}
+		return preProcessSQL, nil
+	}
 
 	// valid systemIncepter state
 	if opt.IsIgnoreSystemIntercept {
@@ -121,37 +153,27 @@ func (m *mySQLConsole) QueryHandler(schema string, table string, sql string, opt
 		goto queryMain
 	}
 
-	preProcessSQL, isPass, err = engine.MySQLPreCheck(sql, defaultAllowSQLType)
+	preProcessSQL, err = preCheck(sql)
 	if err != nil {
 		return &common.QuerySet{
-			Err: errors.Wrap(err, "sql preCheck failed"),
-		}
-	}
-
-	if !isPass {
-		return &common.QuerySet{
-			Err: errors.Wrap(inerr.ErrSQLForbidden, sql),
+			Err: err,
 		}
 	}
+	queryOpt.PreCheck = preCheck
 
 queryMain:
 	// query execute
-	var defaultQueryTimeout int64 = 15
-	if opt.QueryOpt.Timeout > 0 {
-		defaultQueryTimeout = opt.QueryOpt.Timeout
-	}
-
-	return eg.Query(schema, table, preProcessSQL, defaultQueryTimeout)
+	return eg.Query(ctx, schema, table, preProcessSQL, queryOpt)
 }
 
 // NewMySQLConsole
-func NewMySQLConsole() *mySQLConsole {
+func NewMySQLConsole(cacheOpt ...engine.CacheOptions) *mySQLConsole {
 	return &mySQLConsole{
-		sync.Pool{
-			New: func() interface{} {
-				return engine.NewMySQLEngine()
-			},
-		},
+		newEngineCache(func() engine.CacheableEngine {
+			return engine.NewMySQLEngine()
+		}, cacheOpt),
 		common.NewConsoleBase(),
+		newTxSessions(),
+		newMetadataCache(),
 	}
 }
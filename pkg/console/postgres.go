@@ -0,0 +1,196 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+type postgresConsole struct {
+	*engine.EngineCache
+	*common.ConsoleBase
+	*txSessions
+	*metadataCache
+}
+
+func (p *postgresConsole) ConsoleType() string {
+	return common.PostgresConsole
+}
+
+func (p *postgresConsole) Fork(conn common.ConnConfig, schema string) (engine.Engine, error) {
+	// lease a warm engine from cache
+	eg, err := p.Acquire(conn, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return eg, nil
+}
+
+func (p *postgresConsole) Destory(e engine.Engine) {
+	// return engine to cache instead of closing driver
+	p.Release(e.(*engine.PostgresEngine))
+}
+
+func (p *postgresConsole) SchemaHandler(ctx context.Context, opt *common.HandlerOptions) ([]string, error) {
+	// fork engine instance
+	eg, err := p.Fork(opt.Conn, "")
+	if err != nil {
+		return nil, err
+	}
+	defer p.Destory(eg) // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// fetch schema
+	schemas, err := eg.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+func (p *postgresConsole) TableHandler(ctx context.Context, schema string, opt *common.HandlerOptions) (*common.TableSet, error) {
+	// fork engine instance
+	eg, err := p.Fork(opt.Conn, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Destory(eg) // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// fetch tables
+	tables, err := eg.Table(ctx, schema, opt.QueryOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// allowSQLType
+// set default SQL Allow Rule
+// select、show、explain statement
+func (p *postgresConsole) allowSQLType(opt *common.HandlerOptions) []common.SQLType {
+	if opt.AllowSQLType != nil {
+		return opt.AllowSQLType
+	}
+
+	return []common.SQLType{
+		common.StmtSelect,
+		common.StmtShow,
+		common.StmtExplain,
+	}
+}
+
+// splitScript split script into statements by the grammar of engine
+func (p *postgresConsole) splitScript(script string) ([]string, error) {
+	return engine.SplitPostgresScript(script)
+}
+
+// preCheck check statement by the grammar of engine, limit is not added
+func (p *postgresConsole) preCheck(sql string, allowSQLType []common.SQLType) (string, bool, error) {
+	return engine.PostgresPreCheck(sql, allowSQLType)
+}
+
+func (p *postgresConsole) QueryHandler(ctx context.Context, schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet {
+	// fork engine instance, or use engine of transaction session
+	eg, release, err := forkEngine(ctx, p, opt.Conn, schema)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "postgres engine fork failed"),
+		}
+	}
+	defer release() // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// query
+	// sql preCheck inner system
+	defaultAllowSQLType := p.allowSQLType(opt)
+
+	// if sql is empty
+	// postgres not support desc statement
+	// assign columns of table as default sql
+	if sql == "" {
+		sql = postgresDescSQL(table)
+	}
+
+	// query options of current request
+	queryOpt, queryLimit, err := queryOptions(opt)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "query options invalid"),
+		}
+	}
+
+	var preProcessSQL string
+
+	// preCheck is also used to check statement rewritten by hooks
+	preCheck := func(sql string) (string, error) {
+		preProcessSQL, isPass, err := engine.PostgresPreCheckWithLimit(sql, defaultAllowSQLType, queryLimit)
+		if err != nil {
+			return "", errors.Wrap(err, "sql preCheck failed")
+		}
+
+		if !isPass {
+			return "", errors.Wrap(inerr.ErrSQLForbidden, sql)
+		}
+		return preProcessSQL, nil
+	}
+
+	// valid systemIncepter state
+	if opt.IsIgnoreSystemIntercept {
+		preProcessSQL = sql
+		goto queryMain
+	}
+
+	preProcessSQL, err = preCheck(sql)
+	if err != nil {
+		return &common.QuerySet{
+			Err: err,
+		}
+	}
+	queryOpt.PreCheck = preCheck
+
+queryMain:
+	// query execute
+	return eg.Query(ctx, schema, table, preProcessSQL, queryOpt)
+}
+
+// NewPostgresConsole
+func NewPostgresConsole(cacheOpt ...engine.CacheOptions) *postgresConsole {
+	return &postgresConsole{
+		newEngineCache(func() engine.CacheableEngine {
+			return engine.NewPostgresEngine()
+		}, cacheOpt),
+		common.NewConsoleBase(),
+		newTxSessions(),
+		newMetadataCache(),
+	}
+}
+
+// postgresDescSQL query columns of schema-qualified table, eg: public.user
+func postgresDescSQL(table string) string {
+	tableSchema := "public"
+	if idx := strings.Index(table, "."); idx >= 0 {
+		tableSchema, table = table[:idx], table[idx+1:]
+	}
+
+	quote := func(v string) string {
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+
+	return fmt.Sprintf(`SELECT column_name, data_type, is_nullable, column_default FROM information_schema.columns
+		WHERE table_schema = %s AND table_name = %s ORDER BY ordinal_position`, quote(tableSchema), quote(table))
+}
@@ -1,8 +1,8 @@
 package console
 
 import (
+	"context"
 	"fmt"
-	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/ylh990835774/ay-go-components/pkg/common"
@@ -11,8 +11,9 @@ import (
 )
 
 type redisConsole struct {
-	sync.Pool
+	*engine.EngineCache
 	*common.ConsoleBase
+	*metadataCache
 }
 
 func (r *redisConsole) ConsoleType() string {
@@ -20,9 +21,8 @@ func (r *redisConsole) ConsoleType() string {
 }
 
 func (r *redisConsole) Fork(conn common.ConnConfig, schema string) (engine.Engine, error) {
-	eg := r.Get().(*engine.RedisEngine)
-
-	err := eg.InitialDriver(conn, schema)
+	// lease a warm engine from cache
+	eg, err := r.Acquire(conn, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -31,13 +31,11 @@ func (r *redisConsole) Fork(conn common.ConnConfig, schema string) (engine.Engin
 }
 
 func (r *redisConsole) Destory(e engine.Engine) {
-	eg := e.(*engine.RedisEngine)
-	eg.Reset()
-
-	r.Put(eg)
+	// return engine to cache instead of closing driver
+	r.Release(e.(*engine.RedisEngine))
 }
 
-func (r *redisConsole) SchemaHandler(opt *common.HandlerOptions) ([]string, error) {
+func (r *redisConsole) SchemaHandler(ctx context.Context, opt *common.HandlerOptions) ([]string, error) {
 	// fork engine instance
 	eg, err := r.Fork(opt.Conn, "")
 	if err != nil {
@@ -46,11 +44,10 @@ func (r *redisConsole) SchemaHandler(opt *common.HandlerOptions) ([]string, erro
 	defer r.Destory(eg) // destory engine instance
 
 	// bind hooks
-	eg.RegistryQueryPrev(opt.QueryBeforeHook)
-	eg.RegistryQueryPost(opt.QueryAfterHook)
+	bindHooks(eg, opt)
 
 	// fetch schema
-	schemas, err := eg.Schema()
+	schemas, err := eg.Schema(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +55,7 @@ func (r *redisConsole) SchemaHandler(opt *common.HandlerOptions) ([]string, erro
 	return schemas, nil
 }
 
-func (r *redisConsole) TableHandler(schema string, opt *common.HandlerOptions) ([]string, error) {
+func (r *redisConsole) TableHandler(ctx context.Context, schema string, opt *common.HandlerOptions) (*common.TableSet, error) {
 	// fork engine instance
 	eg, err := r.Fork(opt.Conn, schema)
 	if err != nil {
@@ -67,11 +64,10 @@ func (r *redisConsole) TableHandler(schema string, opt *common.HandlerOptions) (
 	defer r.Destory(eg) // destory engine instance
 
 	// bind hooks
-	eg.RegistryQueryPrev(opt.QueryBeforeHook)
-	eg.RegistryQueryPost(opt.QueryAfterHook)
+	bindHooks(eg, opt)
 
 	// fetch tables
-	tables, err := eg.Table(schema)
+	tables, err := eg.Table(ctx, schema, opt.QueryOpt)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +75,7 @@ func (r *redisConsole) TableHandler(schema string, opt *common.HandlerOptions) (
 	return tables, nil
 }
 
-func (r *redisConsole) QueryHandler(schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet {
+func (r *redisConsole) QueryHandler(ctx context.Context, schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet {
 	// fork engine instance
 	eg, err := r.Fork(opt.Conn, schema)
 	if err != nil {
@@ -90,10 +86,16 @@ func (r *redisConsole) QueryHandler(schema string, table string, sql string, opt
 	defer r.Destory(eg) // destory engine instance
 
 	// bind hooks
-	eg.RegistryQueryPrev(opt.QueryBeforeHook)
-	eg.RegistryQueryPost(opt.QueryAfterHook)
+	bindHooks(eg, opt)
+
+	// query options of current request
+	queryOpt, _, err := queryOptions(opt)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "query options invalid"),
+		}
+	}
 
-	var isSafe bool
 	defaultSafeCMD := common.DefaultRedisWhiteCMD
 
 	// if sql is empty
@@ -102,11 +104,6 @@ func (r *redisConsole) QueryHandler(schema string, table string, sql string, opt
 		sql = fmt.Sprintf("type %s", table)
 	}
 
-	// valid is turn off system intercpet
-	if opt.IsIgnoreSystemIntercept {
-		goto queryMain
-	}
-
 	// system intercept
 	// check redis command is valid by user provided white list
 	// if user not set, valid by default white list
@@ -116,26 +113,33 @@ func (r *redisConsole) QueryHandler(schema string, table string, sql string, opt
 		defaultSafeCMD = opt.AllowSQLType
 	}
 
-	_, isSafe, err = engine.IsRedisCMDSafe(sql, defaultSafeCMD)
-	if err != nil {
-		return &common.QuerySet{
-			Err: errors.Wrap(err, "redis command preCheck failed"),
+	// preCheck is also used to check command rewritten by hooks
+	preCheck := func(sql string) (string, error) {
+		_, isSafe, err := engine.IsRedisCMDSafe(sql, defaultSafeCMD)
+		if err != nil {
+			return "", errors.Wrap(err, "redis command preCheck failed")
+		}
+
+		if !isSafe {
+			return "", errors.Wrap(inerr.ErrRedisCMDForbidden, sql)
 		}
+		return sql, nil
 	}
 
-	if !isSafe {
+	// valid is turn off system intercpet
+	if opt.IsIgnoreSystemIntercept {
+		goto queryMain
+	}
+
+	_, err = preCheck(sql)
+	if err != nil {
 		return &common.QuerySet{
-			Err: errors.Wrap(inerr.ErrRedisCMDForbidden, sql),
+			Err: err,
 		}
 	}
+	queryOpt.PreCheck = preCheck
 
 queryMain:
-	// query execute
-	var defaultQueryTimeout int64 = 15
-	if opt.QueryOpt.Timeout > 0 {
-		defaultQueryTimeout = opt.QueryOpt.Timeout
-	}
-
 	// try to parse key from redis command
 	// if success chosen key from redis command or chosen key from user provided
 	keyFromCMD, err := engine.ParseRedisKeyFromRedisCMD(sql)
@@ -143,17 +147,17 @@ queryMain:
 		table = keyFromCMD
 	}
 
-	return eg.Query(schema, table, sql, defaultQueryTimeout)
+	// query execute
+	return eg.Query(ctx, schema, table, sql, queryOpt)
 }
 
 // NewRedisConsole
-func NewRedisConsole() *redisConsole {
+func NewRedisConsole(cacheOpt ...engine.CacheOptions) *redisConsole {
 	return &redisConsole{
-		sync.Pool{
-			New: func() interface{} {
-				return engine.NewRedisEngine()
-			},
-		},
+		newEngineCache(func() engine.CacheableEngine {
+			return engine.NewRedisEngine()
+		}, cacheOpt),
 		common.NewConsoleBase(),
+		newMetadataCache(),
 	}
 }
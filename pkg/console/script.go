@@ -0,0 +1,122 @@
+package console
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/utils"
+)
+
+// sqlConsole console of sql engine, statements of script are split and checked by grammar and allow rule of engine
+type sqlConsole interface {
+	Console
+
+	allowSQLType(opt *common.HandlerOptions) []common.SQLType
+	splitScript(script string) ([]string, error)
+	preCheck(sql string, allowSQLType []common.SQLType) (string, bool, error)
+}
+
+// scriptHandler
+// script is split into statements, every statement is checked by AllowSQLType before any statement executed,
+// then statements are executed by QueryHandler in order, in transaction of session if sessionId is provided
+func scriptHandler(w http.ResponseWriter, req *http.Request, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) {
+	// decode SQL
+	// SQL is encode by base64
+	decodeSQLByte, err := base64.StdEncoding.DecodeString(queryMeta.SQL)
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, "execute script failed"))
+		return
+	}
+
+	statements, err := scriptStatements(cle, string(decodeSQLByte), opt)
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, "execute script failed"))
+		return
+	}
+
+	result := executeScript(req.Context(), cle, queryMeta, statements, unpagedOptions(opt))
+	utils.RenderData(w, "execute script succeed", result)
+}
+
+// scriptStatements split script and check each statement, script is rejected if any statement is forbidden
+func scriptStatements(cle Console, script string, opt *common.HandlerOptions) ([]string, error) {
+	sc, ok := cle.(sqlConsole)
+	if !ok {
+		return nil, inerr.ErrScriptUnsupported
+	}
+
+	statements, err := sc.splitScript(script)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.IsIgnoreSystemIntercept {
+		return statements, nil
+	}
+
+	allowSQLType := sc.allowSQLType(opt)
+	for i, sql := range statements {
+		_, isPass, err := sc.preCheck(sql, allowSQLType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "statement %d preCheck failed", i+1)
+		}
+
+		if !isPass {
+			return nil, errors.Wrapf(inerr.ErrSQLForbidden, "statement %d: %s", i+1, sql)
+		}
+	}
+	return statements, nil
+}
+
+// executeScript
+// statement after failed one is skipped unless ContinueOnError is set
+func executeScript(ctx context.Context, cle Console, queryMeta *common.QueryMeta, statements []string, opt *common.HandlerOptions) *common.ScriptResult {
+	result := &common.ScriptResult{
+		Statements: make([]*common.ScriptStatement, 0, len(statements)),
+	}
+
+	stopped := false
+	for i, sql := range statements {
+		stmt := &common.ScriptStatement{
+			Index: i + 1,
+			SQL:   sql,
+		}
+		result.Statements = append(result.Statements, stmt)
+
+		if stopped {
+			stmt.Status = common.ScriptStmtSkipped
+			result.Skipped++
+			continue
+		}
+
+		var queryRes *common.QuerySet
+		if queryMeta.SessionID != "" {
+			queryRes = txQuery(ctx, cle, queryMeta, sql, opt)
+		} else {
+			queryRes = cle.QueryHandler(ctx, queryMeta.Schema, queryMeta.Table, sql, opt)
+		}
+
+		stmt.QueryDuration = queryRes.QueryDuration
+		stmt.AffectedRows = queryRes.AffectedRows
+		if queryRes.Err != nil {
+			stmt.Status = common.ScriptStmtFailed
+			stmt.Error = queryRes.Err.Error()
+			if len(queryRes.Warnings) > 0 {
+				stmt.Result = queryRes
+			}
+			result.Failed++
+			stopped = !queryMeta.ContinueOnError
+			continue
+		}
+
+		stmt.Status = common.ScriptStmtSucceed
+		stmt.Result = queryRes
+		result.Succeed++
+	}
+
+	return result
+}
@@ -0,0 +1,181 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+type sqliteConsole struct {
+	*engine.EngineCache
+	*common.ConsoleBase
+	*txSessions
+	*metadataCache
+}
+
+func (s *sqliteConsole) ConsoleType() string {
+	return common.SQLiteConsole
+}
+
+func (s *sqliteConsole) Fork(conn common.ConnConfig, schema string) (engine.Engine, error) {
+	// lease a warm engine from cache
+	eg, err := s.Acquire(conn, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return eg, nil
+}
+
+func (s *sqliteConsole) Destory(e engine.Engine) {
+	// return engine to cache instead of closing driver
+	s.Release(e.(*engine.SQLiteEngine))
+}
+
+func (s *sqliteConsole) SchemaHandler(ctx context.Context, opt *common.HandlerOptions) ([]string, error) {
+	// fork engine instance
+	eg, err := s.Fork(opt.Conn, "")
+	if err != nil {
+		return nil, err
+	}
+	defer s.Destory(eg) // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// fetch schema
+	schemas, err := eg.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+func (s *sqliteConsole) TableHandler(ctx context.Context, schema string, opt *common.HandlerOptions) (*common.TableSet, error) {
+	// fork engine instance
+	eg, err := s.Fork(opt.Conn, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Destory(eg) // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// fetch tables
+	tables, err := eg.Table(ctx, schema, opt.QueryOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// allowSQLType
+// set default SQL Allow Rule
+// select、explain statement
+func (s *sqliteConsole) allowSQLType(opt *common.HandlerOptions) []common.SQLType {
+	if opt.AllowSQLType != nil {
+		return opt.AllowSQLType
+	}
+
+	return []common.SQLType{
+		common.StmtSelect,
+		common.StmtExplain,
+	}
+}
+
+// splitScript split script into statements by the grammar of engine
+func (s *sqliteConsole) splitScript(script string) ([]string, error) {
+	return engine.SplitSQLScript(script)
+}
+
+// preCheck check statement by the grammar of engine, limit is not added
+func (s *sqliteConsole) preCheck(sql string, allowSQLType []common.SQLType) (string, bool, error) {
+	return engine.MySQLPreCheck(sql, allowSQLType)
+}
+
+func (s *sqliteConsole) QueryHandler(ctx context.Context, schema string, table string, sql string, opt *common.HandlerOptions) *common.QuerySet {
+	// fork engine instance, or use engine of transaction session
+	eg, release, err := forkEngine(ctx, s, opt.Conn, schema)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "sqlite engine fork failed"),
+		}
+	}
+	defer release() // destory engine instance
+
+	// bind hooks
+	bindHooks(eg, opt)
+
+	// query
+	// sql preCheck inner system
+	defaultAllowSQLType := s.allowSQLType(opt)
+
+	// if sql is empty
+	// sqlite not support desc statement
+	// assign create statement of table as default sql
+	if sql == "" {
+		sql = fmt.Sprintf("select type, name, tbl_name, sql from %s.sqlite_master where name = '%s'",
+			engine.SQLiteQuoteIdent(schema), strings.ReplaceAll(table, "'", "''"))
+	}
+
+	// query options of current request
+	queryOpt, queryLimit, err := queryOptions(opt)
+	if err != nil {
+		return &common.QuerySet{
+			Err: errors.Wrap(err, "query options invalid"),
+		}
+	}
+
+	var preProcessSQL string
+
+	// preCheck is also used to check statement rewritten by hooks
+	preCheck := func(sql string) (string, error) {
+		preProcessSQL, isPass, err := engine.MySQLPreCheckWithLimit(sql, defaultAllowSQLType, queryLimit)
+		if err != nil {
+			return "", errors.Wrap(err, "sql preCheck failed")
+		}
+
+		if !isPass {
+			return "", errors.Wrap(inerr.ErrSQLForbidden, sql)
+		}
+		return preProcessSQL, nil
+	}
+
+	// valid systemIncepter state
+	if opt.IsIgnoreSystemIntercept {
+		preProcessSQL = sql
+		goto queryMain
+	}
+
+	preProcessSQL, err = preCheck(sql)
+	if err != nil {
+		return &common.QuerySet{
+			Err: err,
+		}
+	}
+	queryOpt.PreCheck = preCheck
+
+queryMain:
+	// query execute
+	return eg.Query(ctx, schema, table, preProcessSQL, queryOpt)
+}
+
+// NewSQLiteConsole
+func NewSQLiteConsole(cacheOpt ...engine.CacheOptions) *sqliteConsole {
+	return &sqliteConsole{
+		newEngineCache(func() engine.CacheableEngine {
+			return engine.NewSQLiteEngine()
+		}, cacheOpt),
+		common.NewConsoleBase(),
+		newTxSessions(),
+		newMetadataCache(),
+	}
+}
@@ -0,0 +1,215 @@
+package console
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/utils"
+)
+
+// ticketExecution result of executeTicket
+type ticketExecution struct {
+	Ticket *common.Ticket   `json:"ticket"`
+	Result *common.QuerySet `json:"result"`
+}
+
+// ticketHandler
+// write statement outside AllowSQLType is submitted as ticket, executed after approved by another person
+// tickets are isolated by console type and instance of connection
+func ticketHandler(w http.ResponseWriter, req *http.Request, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) {
+	if opt == nil || opt.TicketStore == nil {
+		utils.RenderErr(w, errors.Wrap(inerr.ErrTicketStoreNotSet, queryMeta.Action))
+		return
+	}
+
+	var err error
+	var data interface{}
+
+	switch queryMeta.Action {
+	case common.ActionSubmitTicket:
+		data, err = submitTicket(req.Context(), cle, queryMeta, opt)
+	case common.ActionListTickets:
+		data, err = opt.TicketStore.List(req.Context(), common.TicketFilter{
+			ConsoleType: cle.ConsoleType(),
+			Instance:    ticketInstance(opt.Conn),
+			Status:      queryMeta.Status,
+		})
+	case common.ActionApproveTicket:
+		data, err = reviewTicket(req.Context(), cle, queryMeta, opt, common.TicketApproved)
+	case common.ActionRejectTicket:
+		data, err = reviewTicket(req.Context(), cle, queryMeta, opt, common.TicketRejected)
+	case common.ActionExecuteTicket:
+		var execution *ticketExecution
+		execution, err = executeTicket(req.Context(), cle, queryMeta, opt)
+		if err == nil && execution.Result.Err != nil {
+			renderQueryErr(w, execution.Result, "execute ticket failed")
+			return
+		}
+		data = execution
+	}
+
+	if err != nil {
+		utils.RenderErr(w, errors.Wrap(err, queryMeta.Action+" failed"))
+		return
+	}
+
+	utils.RenderData(w, queryMeta.Action+" succeed", data)
+}
+
+func submitTicket(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) (*common.Ticket, error) {
+	principal, err := ticketPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQL is encode by base64
+	decodeSQLByte, err := base64.StdEncoding.DecodeString(queryMeta.SQL)
+	if err != nil {
+		return nil, err
+	}
+	if len(decodeSQLByte) == 0 {
+		return nil, inerr.ErrSQLEmpty
+	}
+	if err = ticketPreCheck(cle, string(decodeSQLByte), opt); err != nil {
+		return nil, err
+	}
+
+	ticket := &common.Ticket{
+		ConsoleType: cle.ConsoleType(),
+		Instance:    ticketInstance(opt.Conn),
+		Schema:      queryMeta.Schema,
+		Table:       queryMeta.Table,
+		SQL:         string(decodeSQLByte),
+		Comment:     queryMeta.Comment,
+		Status:      common.TicketPending,
+		Submitter:   principal,
+	}
+	if err = opt.TicketStore.Create(ctx, ticket); err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// ticketPreCheck
+// statement of ticket is checked by the pre-check of console, it must be a single statement which can be parsed,
+// statement allowed by AllowSQLType should be executed by sqlQuery directly
+func ticketPreCheck(cle Console, sql string, opt *common.HandlerOptions) error {
+	sc, ok := cle.(sqlConsole)
+	if !ok {
+		return inerr.ErrTicketUnsupported
+	}
+
+	_, isPass, err := sc.preCheck(sql, sc.allowSQLType(opt))
+	if err != nil {
+		return errors.Wrap(err, "ticket preCheck failed")
+	}
+	if isPass {
+		return inerr.ErrTicketNotRequired
+	}
+	return nil
+}
+
+// reviewTicket approve or reject pending ticket, submitter can not review own ticket
+func reviewTicket(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions, status string) (*common.Ticket, error) {
+	principal, err := ticketPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := getTicket(ctx, cle, queryMeta, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if ticket.Submitter == principal {
+		return nil, inerr.ErrTicketSelfApprove
+	}
+
+	ticket.Status = status
+	ticket.Reviewer = principal
+	ticket.ReviewComment = queryMeta.Comment
+	if err = opt.TicketStore.Update(ctx, ticket, common.TicketPending); err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// executeTicket
+// approved ticket is executed by QueryHandler without AllowSQLType check,
+// hooks、row policies and lint rules still work in engine
+// ticket is marked as executing first, so it can not be executed twice
+func executeTicket(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) (*ticketExecution, error) {
+	principal, err := ticketPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := getTicket(ctx, cle, queryMeta, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket.Status = common.TicketExecuting
+	ticket.Executor = principal
+	if err = opt.TicketStore.Update(ctx, ticket, common.TicketApproved); err != nil {
+		return nil, err
+	}
+
+	execOpt := unpagedOptions(opt)
+	execOpt.IsIgnoreSystemIntercept = true
+
+	result := cle.QueryHandler(ctx, ticket.Schema, ticket.Table, ticket.SQL, execOpt)
+
+	ticket.Status = common.TicketExecuted
+	ticket.AffectedRows = result.AffectedRows
+	if result.Err != nil {
+		ticket.Status = common.TicketFailed
+		ticket.Error = result.Err.Error()
+	}
+
+	// request may be canceled after statement executed, result of ticket is always saved
+	if err = opt.TicketStore.Update(context.Background(), ticket, common.TicketExecuting); err != nil {
+		return nil, errors.Wrap(err, "save ticket result failed")
+	}
+
+	return &ticketExecution{
+		Ticket: ticket,
+		Result: result,
+	}, nil
+}
+
+// getTicket ticket of current console and instance
+func getTicket(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) (*common.Ticket, error) {
+	ticket, err := opt.TicketStore.Get(ctx, queryMeta.TicketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ticket.ConsoleType != cle.ConsoleType() || ticket.Instance != ticketInstance(opt.Conn) {
+		return nil, inerr.ErrTicketMismatch
+	}
+	return ticket, nil
+}
+
+// ticketPrincipal principal of caller extracted by CallerExtractor
+func ticketPrincipal(ctx context.Context) (string, error) {
+	caller := common.CallerFromContext(ctx)
+	if caller == nil || caller.Principal == "" {
+		return "", inerr.ErrTicketPrincipalEmpty
+	}
+	return caller.Principal, nil
+}
+
+// ticketInstance address of database, password is not included
+func ticketInstance(conn common.ConnConfig) string {
+	if conn.FilePath != "" {
+		return conn.FilePath
+	}
+	return net.JoinHostPort(conn.IP, strconv.Itoa(conn.Port))
+}
@@ -0,0 +1,280 @@
+package console
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/utils"
+)
+
+// txConsole console supports interactive transaction
+type txConsole interface {
+	Console
+
+	consoleSessions() *txSessions
+}
+
+// txSessions transaction sessions of console keyed by session id
+type txSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*txSession
+}
+
+func newTxSessions() *txSessions {
+	return &txSessions{
+		sessions: map[string]*txSession{},
+	}
+}
+
+func (s *txSessions) consoleSessions() *txSessions {
+	return s
+}
+
+// txSession
+// transaction on a dedicated connection, engine is leased from console until transaction finished
+// requests of session are serialized by mu, transaction is rolled back after idle timeout
+type txSession struct {
+	mu sync.Mutex
+
+	info        common.TxSession
+	consoleType string
+	instance    string
+	principal   string
+	idleTimeout time.Duration
+
+	sessions *txSessions
+	cle      Console
+	eg       engine.Engine
+	tx       engine.Tx
+	timer    *time.Timer
+	finished bool
+}
+
+type txCtxKey struct{}
+
+// withTx engine of transaction used by QueryHandler of current request
+func withTx(ctx context.Context, tx engine.Tx) context.Context {
+	return context.WithValue(ctx, txCtxKey{}, tx)
+}
+
+func txFromContext(ctx context.Context) engine.Tx {
+	tx, _ := ctx.Value(txCtxKey{}).(engine.Tx)
+	return tx
+}
+
+// forkEngine
+// engine of transaction is used if request is in session, otherwise engine is forked from console
+func forkEngine(ctx context.Context, cle Console, conn common.ConnConfig, schema string) (engine.Engine, func(), error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx, tx.UnbindHook, nil
+	}
+
+	eg, err := cle.Fork(conn, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return eg, func() { cle.Destory(eg) }, nil
+}
+
+// txHandler begin、commit、rollback transaction session
+func txHandler(w http.ResponseWriter, req *http.Request, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) {
+	switch queryMeta.Action {
+	case common.ActionBeginTx:
+		info, err := beginTx(req.Context(), cle, queryMeta, opt)
+		if err != nil {
+			utils.RenderErr(w, errors.Wrap(err, "begin transaction failed"))
+			return
+		}
+
+		utils.RenderData(w, "begin transaction succeed", info)
+	case common.ActionCommitTx, common.ActionRollbackTx:
+		session, err := acquireTxSession(req.Context(), cle, queryMeta, opt)
+		if err != nil {
+			utils.RenderErr(w, errors.Wrap(err, queryMeta.Action+" failed"))
+			return
+		}
+
+		err = session.finish(queryMeta.Action == common.ActionCommitTx)
+		session.mu.Unlock()
+		if err != nil {
+			utils.RenderErr(w, errors.Wrap(err, queryMeta.Action+" failed"))
+			return
+		}
+
+		utils.RenderData(w, queryMeta.Action+" succeed", session.info)
+	}
+}
+
+func beginTx(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) (*common.TxSession, error) {
+	tc, ok := cle.(txConsole)
+	if !ok {
+		return nil, inerr.ErrTxUnsupported
+	}
+
+	if queryMeta.Schema == "" {
+		return nil, inerr.ErrSchemaEmpty
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, errors.Wrap(err, "generate session id failed")
+	}
+
+	eg, err := cle.Fork(opt.Conn, queryMeta.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	txEg, ok := eg.(engine.TxEngine)
+	if !ok {
+		cle.Destory(eg)
+		return nil, inerr.ErrTxUnsupported
+	}
+
+	tx, err := txEg.BeginTx(ctx)
+	if err != nil {
+		cle.Destory(eg)
+		return nil, err
+	}
+
+	idleTimeout := opt.TxIdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = common.DefaultTxIdleTimeout
+	}
+
+	session := &txSession{
+		info: common.TxSession{
+			SessionID:   hex.EncodeToString(buf),
+			Schema:      queryMeta.Schema,
+			IdleTimeout: idleTimeout,
+		},
+		consoleType: cle.ConsoleType(),
+		instance:    ticketInstance(opt.Conn),
+		idleTimeout: time.Duration(idleTimeout) * time.Second,
+		sessions:    tc.consoleSessions(),
+		cle:         cle,
+		eg:          eg,
+		tx:          tx,
+	}
+	if caller := common.CallerFromContext(ctx); caller != nil {
+		session.principal = caller.Principal
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.timer = time.AfterFunc(session.idleTimeout, session.expire)
+	session.touch()
+
+	session.sessions.mu.Lock()
+	session.sessions.sessions[session.info.SessionID] = session
+	session.sessions.mu.Unlock()
+
+	info := session.info
+	return &info, nil
+}
+
+// txQuery run statement in transaction of session
+func txQuery(ctx context.Context, cle Console, queryMeta *common.QueryMeta, sql string, opt *common.HandlerOptions) *common.QuerySet {
+	session, err := acquireTxSession(ctx, cle, queryMeta, opt)
+	if err != nil {
+		return &common.QuerySet{
+			Err: err,
+		}
+	}
+	defer func() {
+		session.touch()
+		session.mu.Unlock()
+	}()
+
+	return cle.QueryHandler(withTx(ctx, session.tx), queryMeta.Schema, queryMeta.Table, sql, opt)
+}
+
+// acquireTxSession
+// session is returned locked, caller, console、instance and schema of request should be the same as begin
+func acquireTxSession(ctx context.Context, cle Console, queryMeta *common.QueryMeta, opt *common.HandlerOptions) (*txSession, error) {
+	tc, ok := cle.(txConsole)
+	if !ok {
+		return nil, inerr.ErrTxUnsupported
+	}
+
+	sessions := tc.consoleSessions()
+	sessions.mu.Lock()
+	session, ok := sessions.sessions[queryMeta.SessionID]
+	sessions.mu.Unlock()
+	if !ok {
+		return nil, inerr.ErrTxSessionNotFound
+	}
+
+	var principal string
+	if caller := common.CallerFromContext(ctx); caller != nil {
+		principal = caller.Principal
+	}
+
+	if session.consoleType != cle.ConsoleType() || session.instance != ticketInstance(opt.Conn) || session.principal != principal ||
+		(queryMeta.Schema != "" && queryMeta.Schema != session.info.Schema) {
+		return nil, inerr.ErrTxSessionMismatch
+	}
+
+	session.mu.Lock()
+	if session.finished {
+		session.mu.Unlock()
+		return nil, inerr.ErrTxSessionNotFound
+	}
+	queryMeta.Schema = session.info.Schema
+	return session, nil
+}
+
+// touch delay idle timeout, mu should be held
+func (s *txSession) touch() {
+	if s.finished {
+		return
+	}
+
+	s.info.ExpireAt = time.Now().Add(s.idleTimeout)
+	s.timer.Reset(s.idleTimeout)
+}
+
+// expire rollback transaction which is idle for idleTimeout
+func (s *txSession) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// session is used while timer fired
+	if s.finished || time.Now().Before(s.info.ExpireAt) {
+		return
+	}
+
+	_ = s.finish(false)
+}
+
+// finish
+// commit or rollback transaction, session is removed and engine is returned to console, mu should be held
+// transaction can not be used after commit failed, session is also finished
+func (s *txSession) finish(commit bool) error {
+	s.finished = true
+	s.timer.Stop()
+
+	s.sessions.mu.Lock()
+	delete(s.sessions.sessions, s.info.SessionID)
+	s.sessions.mu.Unlock()
+
+	var err error
+	if commit {
+		err = s.tx.Commit()
+	} else {
+		err = s.tx.Rollback()
+	}
+
+	s.tx.UnbindHook()
+	s.cle.Destory(s.eg)
+	return err
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"gorm.io/gorm"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// MySQLBindParams
+// replace bind variables ? and :name of statement with driver placeholder ?, args are returned in order of placeholders
+// positional params are named v1、v2... same as vitess, so statement printed by vitess after rewritten keeps its params
+// statement is returned as it is if params is empty
+func MySQLBindParams(sql string, params []common.QueryParam) (string, []interface{}, error) {
+	if len(params) == 0 {
+		return sql, nil, nil
+	}
+
+	values := map[string]interface{}{}
+	position := 0
+	for _, param := range params {
+		name := strings.TrimPrefix(param.Name, ":")
+		if name == "" {
+			position++
+			name = fmt.Sprintf("v%d", position)
+		}
+
+		arg, err := param.Arg()
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "param %s", name)
+		}
+		values[name] = arg
+	}
+
+	var builder strings.Builder
+	args := make([]interface{}, 0, len(params))
+	last := 0
+
+	tokenizer := vsqlparser.NewStringTokenizer(sql)
+	for {
+		typ, val := tokenizer.Scan()
+		if typ == 0 {
+			break
+		}
+		if typ != vsqlparser.VALUE_ARG {
+			continue
+		}
+
+		// Pos is the end of token, ? is scanned as :vN
+		end := tokenizer.Pos
+		start := end - len(val)
+		if end > 0 && sql[end-1] == '?' {
+			start = end - 1
+		}
+		if start < last || sql[start:end] != val && sql[start:end] != "?" {
+			return "", nil, errors.Wrapf(inerr.ErrParamInvalid, "bind variable %s can not be located", val)
+		}
+
+		name := strings.TrimPrefix(val, ":")
+		arg, ok := values[name]
+		if !ok {
+			return "", nil, errors.Wrap(inerr.ErrParamMissing, name)
+		}
+
+		builder.WriteString(sql[last:start])
+		builder.WriteString("?")
+		args = append(args, arg)
+		last = end
+	}
+
+	if tokenizer.LastError != nil {
+		return "", nil, errors.Wrap(tokenizer.LastError, "scan bind variables failed")
+	}
+
+	builder.WriteString(sql[last:])
+	return builder.String(), args, nil
+}
+
+// queryWithParams
+// statement with args is run by connection pool of gorm directly,
+// so ? in string literal is not treated as placeholder as gorm does
+func queryWithParams(ctx context.Context, db *gorm.DB, statement string, params []common.QueryParam) (*sql.Rows, error) {
+	bound, args, err := MySQLBindParams(statement, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return db.WithContext(ctx).Raw(bound).Rows()
+	}
+	return db.WithContext(ctx).Statement.ConnPool.QueryContext(ctx, bound, args...)
+}
+
+// execWithParams same as queryWithParams, affected rows are returned
+func execWithParams(ctx context.Context, db *gorm.DB, statement string, params []common.QueryParam) (int64, error) {
+	bound, args, err := MySQLBindParams(statement, params)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(args) == 0 {
+		d := db.WithContext(ctx).Exec(bound)
+		return d.RowsAffected, d.Error
+	}
+
+	res, err := db.WithContext(ctx).Statement.ConnPool.ExecContext(ctx, bound, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
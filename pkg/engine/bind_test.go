@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestMySQLBindParams(t *testing.T) {
+	params := []common.QueryParam{
+		{Type: common.ParamTypeInt, Value: float64(1)},
+		{Name: "name", Type: common.ParamTypeString, Value: "li"},
+		{Type: common.ParamTypeBool, Value: true},
+	}
+
+	cases := []struct {
+		sql   string
+		bound string
+		args  []interface{}
+	}{
+		{
+			"select * from users where id = ? and name = :name and enabled = ?",
+			"select * from users where id = ? and name = ? and enabled = ?",
+			[]interface{}{int64(1), "li", true},
+		},
+		{
+			// statement rewritten by MySQLPreCheck
+			"select * from users where id = :v1 and name = :name and enabled = :v2 limit 100",
+			"select * from users where id = ? and name = ? and enabled = ? limit 100",
+			[]interface{}{int64(1), "li", true},
+		},
+		{
+			"select * from users where note = '?:name' and id = ? and enabled = ?",
+			"select * from users where note = '?:name' and id = ? and enabled = ?",
+			[]interface{}{int64(1), true},
+		},
+		{
+			"update users set name = :name where id = ?",
+			"update users set name = ? where id = ?",
+			[]interface{}{"li", int64(1)},
+		},
+	}
+
+	for _, c := range cases {
+		bound, args, err := MySQLBindParams(c.sql, params)
+		require.NoError(t, err, c.sql)
+		require.Equal(t, c.bound, bound, c.sql)
+		require.Equal(t, c.args, args, c.sql)
+	}
+
+	bound, args, err := MySQLBindParams("select * from users where id = ?", nil)
+	require.NoError(t, err)
+	require.Equal(t, "select * from users where id = ?", bound)
+	require.Nil(t, args)
+
+	_, _, err = MySQLBindParams("select * from users where id = :id", params)
+	require.ErrorIs(t, err, inerr.ErrParamMissing)
+
+	_, _, err = MySQLBindParams("select * from users where id = ?",
+		[]common.QueryParam{{Type: common.ParamTypeInt, Value: "abc"}})
+	require.ErrorIs(t, err, inerr.ErrParamInvalid)
+}
+
+func TestMySQLPreCheckBindVariables(t *testing.T) {
+	sql, isPass, err := MySQLPreCheck("select * from users where id = ? and name = :name",
+		[]common.SQLType{common.StmtSelect})
+	require.NoError(t, err)
+	require.True(t, isPass)
+	require.Equal(t, "select * from users where id = :v1 and `name` = :name limit 100", sql)
+
+	sqlType, err := MySQLSQLType("delete from users where id = ?")
+	require.NoError(t, err)
+	require.Equal(t, common.StmtDelete, sqlType)
+}
+
+func TestSQLiteQueryParams(t *testing.T) {
+	eg, err := ForkSQLiteEngine(common.ConnConfig{FilePath: filepath.Join(t.TempDir(), "params.db")})
+	require.NoError(t, err)
+	defer eg.Close()
+
+	ctx := context.Background()
+	opt := common.QueryOptions{Timeout: 5}
+
+	res := eg.Query(ctx, "main", "", "create table users (id integer primary key, name text)", opt)
+	require.NoError(t, res.Err)
+
+	opt.Params = []common.QueryParam{
+		{Type: common.ParamTypeInt, Value: float64(1)},
+		{Name: "name", Type: common.ParamTypeString, Value: "it's ?"},
+	}
+	res = eg.Query(ctx, "main", "users", "insert into users(id, name) values (?, :name)", opt)
+	require.NoError(t, res.Err)
+	require.Equal(t, int64(1), res.AffectedRows)
+
+	opt.Params = []common.QueryParam{{Name: "name", Type: common.ParamTypeString, Value: "it's ?"}}
+	res = eg.Query(ctx, "main", "users", "select id from users where name = :name", opt)
+	require.NoError(t, res.Err)
+	require.Len(t, res.Rows, 1)
+}
@@ -0,0 +1,325 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+// CacheableEngine engine which keep its driver after request and can be cached by EngineCache
+type CacheableEngine interface {
+	Engine
+
+	InitialDriver(conn common.ConnConfig, schema string) error
+	Ping() error
+	UnbindHook()
+}
+
+// CacheOptions
+/*
+IdleTTL:
+空闲引擎的最大存活时间，超过后引擎会被关闭，默认5分钟
+
+MaxOpen:
+缓存中最多打开的引擎数量(包括空闲和使用中)，默认64，达到上限时优先关闭最久未使用的空闲引擎，
+若没有空闲引擎可以关闭则返回ErrEngineCacheFull
+
+HealthCheckInterval:
+空闲超过该时间的引擎在复用前会先执行Ping检查连接是否可用，默认30秒
+*/
+type CacheOptions struct {
+	IdleTTL             time.Duration
+	MaxOpen             int
+	HealthCheckInterval time.Duration
+}
+
+var DefaultCacheOptions = CacheOptions{
+	IdleTTL:             5 * time.Minute,
+	MaxOpen:             64,
+	HealthCheckInterval: 30 * time.Second,
+}
+
+// EngineCache long-lived engine cache keyed by connect config and schema
+// engine is leased to one request at a time, and returned to the cache after request finished
+type EngineCache struct {
+	mu sync.Mutex
+
+	opt       CacheOptions
+	newEngine func() CacheableEngine
+
+	idle  map[engineCacheKey][]*engineCacheEntry
+	inUse map[CacheableEngine]engineCacheKey
+	open  int
+
+	closed bool
+	stop   chan struct{}
+}
+
+type engineCacheKey struct {
+	conn   common.ConnConfig
+	schema string
+}
+
+type engineCacheEntry struct {
+	key      engineCacheKey
+	eg       CacheableEngine
+	lastUsed time.Time
+}
+
+// NewEngineCache
+// newEngine create an engine without driver, driver is initialed by InitialDriver
+func NewEngineCache(newEngine func() CacheableEngine, opt CacheOptions) *EngineCache {
+	if opt.IdleTTL <= 0 {
+		opt.IdleTTL = DefaultCacheOptions.IdleTTL
+	}
+	if opt.MaxOpen == 0 {
+		opt.MaxOpen = DefaultCacheOptions.MaxOpen
+	}
+	if opt.HealthCheckInterval <= 0 {
+		opt.HealthCheckInterval = DefaultCacheOptions.HealthCheckInterval
+	}
+
+	c := &EngineCache{
+		opt:       opt,
+		newEngine: newEngine,
+		idle:      make(map[engineCacheKey][]*engineCacheEntry),
+		inUse:     make(map[CacheableEngine]engineCacheKey),
+		stop:      make(chan struct{}),
+	}
+
+	// close expired idle engine even though no request
+	go c.janitor()
+
+	return c
+}
+
+// Acquire lease an engine by connect config and schema
+// reuse warm idle engine if exists, otherwise initial a new one
+func (c *EngineCache) Acquire(conn common.ConnConfig, schema string) (CacheableEngine, error) {
+	key := engineCacheKey{conn: conn, schema: schema}
+
+	// engines to be closed after unlock
+	var closing []CacheableEngine
+
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			closeEngines(closing)
+			return nil, inerr.ErrEngineCacheClosed
+		}
+
+		closing = append(closing, c.evictExpiredLocked(time.Now())...)
+
+		entries := c.idle[key]
+		if len(entries) == 0 {
+			break
+		}
+
+		// most recently used engine first
+		entry := entries[len(entries)-1]
+		c.idle[key] = entries[:len(entries)-1]
+		if len(c.idle[key]) == 0 {
+			delete(c.idle, key)
+		}
+		c.inUse[entry.eg] = key
+		c.mu.Unlock()
+
+		closeEngines(closing)
+		closing = nil
+
+		// health check before reuse
+		if time.Since(entry.lastUsed) < c.opt.HealthCheckInterval || entry.eg.Ping() == nil {
+			return entry.eg, nil
+		}
+
+		c.Discard(entry.eg)
+	}
+
+	// reach max open limit, close the oldest idle engine
+	if c.opt.MaxOpen > 0 && c.open >= c.opt.MaxOpen {
+		oldest := c.oldestIdleLocked()
+		if oldest == nil {
+			c.mu.Unlock()
+			closeEngines(closing)
+			return nil, inerr.ErrEngineCacheFull
+		}
+
+		c.removeIdleLocked(oldest)
+		closing = append(closing, oldest.eg)
+	}
+	c.open++
+	c.mu.Unlock()
+
+	closeEngines(closing)
+
+	eg := c.newEngine()
+	if err := eg.InitialDriver(conn, schema); err != nil {
+		c.mu.Lock()
+		c.open--
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.inUse[eg] = key
+	c.mu.Unlock()
+
+	return eg, nil
+}
+
+// Release return engine to cache after request finished
+// hooks bound by request are cleared
+func (c *EngineCache) Release(eg CacheableEngine) {
+	eg.UnbindHook()
+
+	c.mu.Lock()
+	key, has := c.inUse[eg]
+	if !has {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.inUse, eg)
+
+	if c.closed {
+		c.open--
+		c.mu.Unlock()
+		closeEngines([]CacheableEngine{eg})
+		return
+	}
+
+	c.idle[key] = append(c.idle[key], &engineCacheEntry{
+		key:      key,
+		eg:       eg,
+		lastUsed: time.Now(),
+	})
+	c.mu.Unlock()
+}
+
+// Discard close a leased engine which is broken instead of returning it to cache
+func (c *EngineCache) Discard(eg CacheableEngine) {
+	c.mu.Lock()
+	if _, has := c.inUse[eg]; has {
+		delete(c.inUse, eg)
+		c.open--
+	}
+	c.mu.Unlock()
+
+	closeEngines([]CacheableEngine{eg})
+}
+
+// Len return count of opened engines, include idle and in use
+func (c *EngineCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.open
+}
+
+// Close close all idle engines, engines in use will be closed when released
+func (c *EngineCache) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.stop)
+
+	idle := make([]CacheableEngine, 0)
+	for key, entries := range c.idle {
+		for _, entry := range entries {
+			idle = append(idle, entry.eg)
+		}
+		c.open -= len(entries)
+		delete(c.idle, key)
+	}
+	c.mu.Unlock()
+
+	closeEngines(idle)
+	return nil
+}
+
+func (c *EngineCache) janitor() {
+	ticker := time.NewTicker(c.opt.IdleTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			expired := c.evictExpiredLocked(now)
+			c.mu.Unlock()
+
+			closeEngines(expired)
+		}
+	}
+}
+
+// evictExpiredLocked remove idle engines which exceed idle ttl, caller should close them
+func (c *EngineCache) evictExpiredLocked(now time.Time) []CacheableEngine {
+	expired := make([]CacheableEngine, 0)
+	for key, entries := range c.idle {
+		alive := entries[:0]
+		for _, entry := range entries {
+			if now.Sub(entry.lastUsed) > c.opt.IdleTTL {
+				expired = append(expired, entry.eg)
+				continue
+			}
+			alive = append(alive, entry)
+		}
+
+		if len(alive) == 0 {
+			delete(c.idle, key)
+		} else {
+			c.idle[key] = alive
+		}
+	}
+	c.open -= len(expired)
+
+	return expired
+}
+
+func (c *EngineCache) oldestIdleLocked() *engineCacheEntry {
+	var oldest *engineCacheEntry
+	for _, entries := range c.idle {
+		for _, entry := range entries {
+			if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+				oldest = entry
+			}
+		}
+	}
+	return oldest
+}
+
+func (c *EngineCache) removeIdleLocked(target *engineCacheEntry) {
+	entries := c.idle[target.key]
+	for i, entry := range entries {
+		if entry != target {
+			continue
+		}
+
+		entries = append(entries[:i], entries[i+1:]...)
+		break
+	}
+
+	if len(entries) == 0 {
+		delete(c.idle, target.key)
+	} else {
+		c.idle[target.key] = entries
+	}
+	c.open--
+}
+
+func closeEngines(engines []CacheableEngine) {
+	for _, eg := range engines {
+		if err := eg.Close(); err != nil {
+			fmt.Printf("engine close failed: %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+type fakeCacheEngine struct {
+	*common.EngineBase
+
+	initialed int
+	closed    int32 // closed by janitor goroutine
+	pingErr   error
+}
+
+func (f *fakeCacheEngine) Schema(ctx context.Context) ([]string, error) { return nil, nil }
+func (f *fakeCacheEngine) Table(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	return nil, nil
+}
+func (f *fakeCacheEngine) Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet {
+	return &common.QuerySet{}
+}
+func (f *fakeCacheEngine) RegistryQueryPrev(hook common.PreHook)  { f.BindPrevHook(hook) }
+func (f *fakeCacheEngine) RegistryQueryPost(hook common.PostHook) { f.BindPostHook(hook) }
+func (f *fakeCacheEngine) Close() error                           { atomic.AddInt32(&f.closed, 1); return nil }
+func (f *fakeCacheEngine) Ping() error                            { return f.pingErr }
+func (f *fakeCacheEngine) InitialDriver(conn common.ConnConfig, schema string) error {
+	f.initialed++
+	f.ConnConfig = conn
+	return nil
+}
+
+func newFakeCacheEngine() CacheableEngine {
+	return &fakeCacheEngine{EngineBase: &common.EngineBase{}}
+}
+
+func TestEngineCache(t *testing.T) {
+	conn := common.ConnConfig{IP: "127.0.0.1", Port: 3306}
+
+	t.Run("reuse warm engine", func(t *testing.T) {
+		cache := NewEngineCache(newFakeCacheEngine, CacheOptions{})
+		defer cache.Close()
+
+		eg, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		eg.RegistryQueryPrev(func(*common.PrevHookArgs) error { return nil })
+		cache.Release(eg)
+
+		reused, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		require.Same(t, eg, reused)
+		require.Empty(t, reused.(*fakeCacheEngine).PrevHooks, "hooks should be unbind after release")
+		require.Equal(t, 1, reused.(*fakeCacheEngine).initialed)
+
+		// engine is leased exclusively
+		other, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		require.NotSame(t, eg, other)
+
+		// different schema different engine
+		another, err := cache.Acquire(conn, "db02")
+		require.NoError(t, err)
+		require.NotSame(t, eg, another)
+		require.Equal(t, 3, cache.Len())
+	})
+
+	t.Run("max open limit", func(t *testing.T) {
+		cache := NewEngineCache(newFakeCacheEngine, CacheOptions{MaxOpen: 1})
+		defer cache.Close()
+
+		eg, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+
+		_, err = cache.Acquire(conn, "db02")
+		require.Equal(t, inerr.ErrEngineCacheFull, err)
+
+		// idle engine of other key is evicted
+		cache.Release(eg)
+		other, err := cache.Acquire(conn, "db02")
+		require.NoError(t, err)
+		require.NotSame(t, eg, other)
+		require.EqualValues(t, 1, atomic.LoadInt32(&eg.(*fakeCacheEngine).closed))
+		require.Equal(t, 1, cache.Len())
+	})
+
+	t.Run("idle ttl", func(t *testing.T) {
+		cache := NewEngineCache(newFakeCacheEngine, CacheOptions{IdleTTL: 20 * time.Millisecond})
+		defer cache.Close()
+
+		eg, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		cache.Release(eg)
+
+		require.Eventually(t, func() bool {
+			return cache.Len() == 0
+		}, time.Second, 5*time.Millisecond)
+		require.EqualValues(t, 1, atomic.LoadInt32(&eg.(*fakeCacheEngine).closed))
+	})
+
+	t.Run("health check before reuse", func(t *testing.T) {
+		cache := NewEngineCache(newFakeCacheEngine, CacheOptions{HealthCheckInterval: time.Nanosecond})
+		defer cache.Close()
+
+		eg, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		eg.(*fakeCacheEngine).pingErr = errors.New("broken pipe")
+		cache.Release(eg)
+
+		time.Sleep(time.Millisecond)
+		other, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		require.NotSame(t, eg, other)
+		require.EqualValues(t, 1, atomic.LoadInt32(&eg.(*fakeCacheEngine).closed))
+		require.Equal(t, 1, cache.Len())
+	})
+
+	t.Run("close cache", func(t *testing.T) {
+		cache := NewEngineCache(newFakeCacheEngine, CacheOptions{})
+
+		idle, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		inUse, err := cache.Acquire(conn, "db01")
+		require.NoError(t, err)
+		cache.Release(idle)
+
+		require.NoError(t, cache.Close())
+		require.EqualValues(t, 1, atomic.LoadInt32(&idle.(*fakeCacheEngine).closed))
+
+		cache.Release(inUse)
+		require.EqualValues(t, 1, atomic.LoadInt32(&inUse.(*fakeCacheEngine).closed))
+		require.Equal(t, 0, cache.Len())
+
+		_, err = cache.Acquire(conn, "db01")
+		require.Equal(t, inerr.ErrEngineCacheClosed, err)
+	})
+}
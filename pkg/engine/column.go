@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+)
+
+// databaseTypeKind value kind of common database type name of mysql、postgres and sqlite
+var databaseTypeKind = map[string]string{
+	"TINYINT":     common.ColumnKindInt,
+	"SMALLINT":    common.ColumnKindInt,
+	"MEDIUMINT":   common.ColumnKindInt,
+	"INT":         common.ColumnKindInt,
+	"INTEGER":     common.ColumnKindInt,
+	"BIGINT":      common.ColumnKindInt,
+	"INT2":        common.ColumnKindInt,
+	"INT4":        common.ColumnKindInt,
+	"INT8":        common.ColumnKindInt,
+	"SMALLSERIAL": common.ColumnKindInt,
+	"SERIAL":      common.ColumnKindInt,
+	"BIGSERIAL":   common.ColumnKindInt,
+	"YEAR":        common.ColumnKindInt,
+
+	"FLOAT":            common.ColumnKindFloat,
+	"DOUBLE":           common.ColumnKindFloat,
+	"DOUBLE PRECISION": common.ColumnKindFloat,
+	"REAL":             common.ColumnKindFloat,
+	"FLOAT4":           common.ColumnKindFloat,
+	"FLOAT8":           common.ColumnKindFloat,
+
+	"DECIMAL": common.ColumnKindDecimal,
+	"NUMERIC": common.ColumnKindDecimal,
+	"MONEY":   common.ColumnKindDecimal,
+
+	"BOOL":    common.ColumnKindBool,
+	"BOOLEAN": common.ColumnKindBool,
+
+	"BIT": common.ColumnKindBit,
+
+	"DATE":        common.ColumnKindDate,
+	"DATETIME":    common.ColumnKindTime,
+	"TIMESTAMP":   common.ColumnKindTime,
+	"TIMESTAMPTZ": common.ColumnKindTime,
+
+	"BLOB":       common.ColumnKindBytes,
+	"TINYBLOB":   common.ColumnKindBytes,
+	"MEDIUMBLOB": common.ColumnKindBytes,
+	"LONGBLOB":   common.ColumnKindBytes,
+	"BINARY":     common.ColumnKindBytes,
+	"VARBINARY":  common.ColumnKindBytes,
+	"BYTEA":      common.ColumnKindBytes,
+	"GEOMETRY":   common.ColumnKindBytes,
+
+	"JSON":  common.ColumnKindJSON,
+	"JSONB": common.ColumnKindJSON,
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// columnMetas build metadata of columns, names are the renamed column names
+func columnMetas(names []string, columnTypes []*sql.ColumnType) []common.ColumnMeta {
+	metas := make([]common.ColumnMeta, 0, len(columnTypes))
+	for i, ct := range columnTypes {
+		meta := common.ColumnMeta{
+			Name:         names[i],
+			DatabaseType: ct.DatabaseTypeName(),
+			Kind:         columnKind(ct),
+		}
+
+		if nullable, ok := ct.Nullable(); ok {
+			meta.Nullable = &nullable
+		}
+		if length, ok := ct.Length(); ok {
+			meta.Length = &length
+		}
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			meta.Precision = &precision
+			meta.Scale = &scale
+		}
+
+		metas = append(metas, meta)
+	}
+
+	return metas
+}
+
+// columnKind
+// kind is decided by database type name first, eg: VARCHAR(32)、UNSIGNED INT
+// go scan type of driver is used when database type is unknown
+func columnKind(ct *sql.ColumnType) string {
+	typeName := strings.ToUpper(strings.TrimSpace(ct.DatabaseTypeName()))
+	if idx := strings.Index(typeName, "("); idx >= 0 {
+		typeName = strings.TrimSpace(typeName[:idx])
+	}
+
+	if strings.HasPrefix(typeName, "UNSIGNED ") {
+		return common.ColumnKindUint
+	}
+	if strings.HasSuffix(typeName, " UNSIGNED") {
+		typeName = strings.TrimSuffix(typeName, " UNSIGNED")
+		if databaseTypeKind[typeName] == common.ColumnKindInt {
+			return common.ColumnKindUint
+		}
+	}
+
+	if kind, ok := databaseTypeKind[typeName]; ok {
+		return kind
+	}
+
+	// time with time zone、time without date
+	if strings.HasPrefix(typeName, "TIMESTAMP") || strings.HasPrefix(typeName, "DATETIME") {
+		return common.ColumnKindTime
+	}
+
+	return scanTypeKind(ct.ScanType())
+}
+
+func scanTypeKind(t reflect.Type) string {
+	if t == nil {
+		return common.ColumnKindUnknown
+	}
+
+	// sql.NullInt64、sql.NullTime and so on
+	if t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), "Null") {
+		if f, ok := t.FieldByName(strings.TrimPrefix(t.Name(), "Null")); ok {
+			t = f.Type
+		}
+	}
+
+	if t == timeType {
+		return common.ColumnKindTime
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return common.ColumnKindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return common.ColumnKindUint
+	case reflect.Float32, reflect.Float64:
+		return common.ColumnKindFloat
+	case reflect.Bool:
+		return common.ColumnKindBool
+	case reflect.String:
+		return common.ColumnKindString
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// sql.RawBytes of text column
+			return common.ColumnKindString
+		}
+	}
+
+	return common.ColumnKindUnknown
+}
+
+// renderColumnValue
+// convert value scanned by driver to display value by column kind
+// NULL is kept as nil, so it can be distinguished from string "NULL"
+func renderColumnValue(v interface{}, kind string) interface{} {
+	switch r := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if len(r) > BUF {
+			return BLOB_FIELD_NOT_DISPLA
+		}
+		return renderBytesValue(r, kind)
+	case time.Time:
+		if kind == common.ColumnKindDate {
+			return r.Format("2006-01-02")
+		}
+		return r.Format("2006-01-02 15:04:05")
+	case int64:
+		// sqlite store boolean as integer
+		if kind == common.ColumnKindBool {
+			return r != 0
+		}
+		return r
+	}
+
+	return v
+}
+
+// renderBytesValue
+// text protocol of mysql return all values as bytes
+func renderBytesValue(b []byte, kind string) interface{} {
+	switch kind {
+	case common.ColumnKindInt:
+		if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return i
+		}
+	case common.ColumnKindUint:
+		if i, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return i
+		}
+	case common.ColumnKindFloat:
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	case common.ColumnKindBool:
+		if v, err := strconv.ParseBool(string(b)); err == nil {
+			return v
+		}
+	case common.ColumnKindBit:
+		// bit value is big endian bytes
+		if len(b) <= 8 {
+			var i uint64
+			for _, c := range b {
+				i = i<<8 | uint64(c)
+			}
+			return i
+		}
+		return "0x" + hex.EncodeToString(b)
+	case common.ColumnKindBytes:
+		if !utf8.Valid(b) {
+			return "0x" + hex.EncodeToString(b)
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+)
+
+func TestRenderColumnValue(t *testing.T) {
+	testCases := []struct {
+		value  interface{}
+		kind   string
+		expect interface{}
+	}{
+		{nil, common.ColumnKindString, nil},
+		{[]byte("NULL"), common.ColumnKindString, "NULL"},
+		// single byte string is not boolean
+		{[]byte{0x01}, common.ColumnKindString, "\x01"},
+		{[]byte("-12"), common.ColumnKindInt, int64(-12)},
+		{[]byte("18446744073709551615"), common.ColumnKindUint, uint64(18446744073709551615)},
+		{[]byte("1.5"), common.ColumnKindFloat, 1.5},
+		{[]byte("12345678901234567890.12"), common.ColumnKindDecimal, "12345678901234567890.12"},
+		{[]byte{0x01}, common.ColumnKindBit, uint64(1)},
+		{[]byte{0x01, 0x00}, common.ColumnKindBit, uint64(256)},
+		{[]byte{0xff, 0xfe}, common.ColumnKindBytes, "0xfffe"},
+		{[]byte("text"), common.ColumnKindBytes, "text"},
+		{int64(1), common.ColumnKindBool, true},
+		{int64(1), common.ColumnKindInt, int64(1)},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expect, renderColumnValue(tc.value, tc.kind), "%v %s", tc.value, tc.kind)
+	}
+}
+
+func TestSQLiteColumnTypes(t *testing.T) {
+	eg, err := ForkSQLiteEngine(common.ConnConfig{FilePath: filepath.Join(t.TempDir(), "column.db")})
+	require.NoError(t, err)
+	defer eg.Close()
+
+	opt := common.QueryOptions{Timeout: 5}
+	ctx := context.Background()
+
+	for _, sql := range []string{
+		"create table column_test (id integer not null, name varchar(32), price decimal(10, 2), enabled boolean, data blob, created_at datetime)",
+		"insert into column_test values (1, 'NULL', 1.5, 1, x'fffe', '2023-12-15 10:00:00'), (2, null, null, 0, null, null)",
+	} {
+		res := eg.Query(ctx, SQLiteDefaultSchema, "column_test", sql, opt)
+		require.NoError(t, res.Err, sql)
+	}
+
+	res := eg.Query(ctx, SQLiteDefaultSchema, "column_test", "select * from column_test order by id", opt)
+	require.NoError(t, res.Err)
+	require.Len(t, res.ColumnTypes, len(res.Columns))
+
+	kinds := make(map[string]string)
+	for _, meta := range res.ColumnTypes {
+		kinds[meta.Name] = meta.Kind
+	}
+	require.Equal(t, map[string]string{
+		"id":         common.ColumnKindInt,
+		"name":       common.ColumnKindString,
+		"price":      common.ColumnKindDecimal,
+		"enabled":    common.ColumnKindBool,
+		"data":       common.ColumnKindBytes,
+		"created_at": common.ColumnKindTime,
+	}, kinds)
+	require.Equal(t, "varchar(32)", res.ColumnTypes[1].DatabaseType)
+
+	require.Equal(t, common.Row{
+		"id":         int64(1),
+		"name":       "NULL",
+		"price":      1.5,
+		"enabled":    true,
+		"data":       "0xfffe",
+		"created_at": "2023-12-15 10:00:00",
+	}, res.Rows[0])
+	require.Equal(t, common.Row{
+		"id":         int64(2),
+		"name":       nil,
+		"price":      nil,
+		"enabled":    false,
+		"data":       nil,
+		"created_at": nil,
+	}, res.Rows[1])
+}
+
+type mockRowStream struct {
+	columns []string
+	rows    []common.Row
+	err     error
+}
+
+func (m *mockRowStream) Begin(qs *common.QuerySet) error {
+	m.columns = qs.Columns
+	return m.err
+}
+
+func (m *mockRowStream) Row(row common.Row) error {
+	m.rows = append(m.rows, row)
+	return nil
+}
+
+func TestSQLiteRowStream(t *testing.T) {
+	eg, err := ForkSQLiteEngine(common.ConnConfig{FilePath: filepath.Join(t.TempDir(), "stream.db")})
+	require.NoError(t, err)
+	defer eg.Close()
+
+	ctx := context.Background()
+	for _, sql := range []string{
+		"create table stream_test (id integer, phone varchar(32))",
+		"insert into stream_test values (1, '13812345678'), (2, '13987654321')",
+	} {
+		require.NoError(t, eg.Query(ctx, SQLiteDefaultSchema, "", sql, common.QueryOptions{Timeout: 5}).Err, sql)
+	}
+
+	// rows are passed to stream masked and not kept in result
+	stream := &mockRowStream{}
+	opt := common.QueryOptions{
+		Timeout:   5,
+		MaskRules: []common.MaskRule{{Column: "phone", Strategy: common.MaskStrategyRedact}},
+		RowStream: stream,
+	}
+	res := eg.Query(ctx, SQLiteDefaultSchema, "", "select id, phone from stream_test order by id", opt)
+	require.NoError(t, res.Err)
+	require.Empty(t, res.Rows)
+	require.Equal(t, 2, res.Total)
+	require.True(t, res.ColumnTypes[1].Masked)
+	require.Equal(t, []string{"id", "phone"}, stream.columns)
+	require.Equal(t, []common.Row{
+		{"id": int64(1), "phone": common.MaskRedacted},
+		{"id": int64(2), "phone": common.MaskRedacted},
+	}, stream.rows)
+
+	// error of Begin aborts query before rows
+	stream = &mockRowStream{err: errors.New("rejected")}
+	opt.RowStream = stream
+	res = eg.Query(ctx, SQLiteDefaultSchema, "", "select id, phone from stream_test order by id", opt)
+	require.EqualError(t, res.Err, "rejected")
+	require.Empty(t, stream.rows)
+}
@@ -0,0 +1,324 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// what is expected at cursor of SQL
+const (
+	SQLExpectAny    = ""
+	SQLExpectTable  = "table"
+	SQLExpectSchema = "schema"
+	SQLExpectNone   = "none" // cursor is in string literal or comment
+)
+
+// keywords before table name
+var sqlTableKeywords = map[string]bool{
+	"from":     true,
+	"join":     true,
+	"into":     true,
+	"update":   true,
+	"table":    true,
+	"desc":     true,
+	"describe": true,
+	"truncate": true,
+}
+
+// SQLKeywords keywords offered by completion
+var SQLKeywords = []string{
+	"SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "IN", "IS", "NULL", "LIKE", "BETWEEN", "EXISTS",
+	"AS", "DISTINCT", "JOIN", "LEFT", "RIGHT", "INNER", "OUTER", "CROSS", "ON", "USING",
+	"GROUP BY", "HAVING", "ORDER BY", "ASC", "DESC", "LIMIT", "OFFSET", "UNION", "ALL",
+	"INSERT INTO", "VALUES", "UPDATE", "SET", "DELETE FROM", "REPLACE INTO",
+	"CREATE TABLE", "ALTER TABLE", "DROP TABLE", "TRUNCATE TABLE", "ADD COLUMN", "INDEX",
+	"SHOW", "EXPLAIN", "DESCRIBE", "USE", "CASE", "WHEN", "THEN", "ELSE", "END", "WITH",
+}
+
+// SQLFunctions functions offered by completion
+var SQLFunctions = []string{
+	"COUNT", "SUM", "AVG", "MIN", "MAX", "GROUP_CONCAT",
+	"CONCAT", "CONCAT_WS", "SUBSTRING", "LENGTH", "CHAR_LENGTH", "LOWER", "UPPER", "TRIM", "REPLACE",
+	"IFNULL", "COALESCE", "NULLIF", "IF", "CAST", "CONVERT",
+	"NOW", "CURDATE", "CURTIME", "DATE", "DATE_FORMAT", "DATE_ADD", "DATE_SUB", "DATEDIFF",
+	"UNIX_TIMESTAMP", "FROM_UNIXTIME", "YEAR", "MONTH", "DAY",
+	"ABS", "ROUND", "FLOOR", "CEIL", "MOD", "RAND",
+	"JSON_EXTRACT", "JSON_UNQUOTE", "JSON_OBJECT", "JSON_ARRAY",
+}
+
+// SQLTableRef table referenced by FROM、JOIN、UPDATE、INTO of statement
+type SQLTableRef struct {
+	Schema string
+	Table  string
+	Alias  string
+}
+
+// SQLCompletion
+// context of cursor in SQL, Prefix is the word before cursor and Start is the character offset of Prefix,
+// Qualifier is the identifier before dot, eg: u of u.na
+type SQLCompletion struct {
+	Prefix    string
+	Start     int
+	Qualifier string
+	Expect    string
+	Tables    []SQLTableRef
+}
+
+// QualifiedTable table referenced by alias or name of qualifier, nil if qualifier is not a table of statement
+func (c *SQLCompletion) QualifiedTable() *SQLTableRef {
+	if c.Qualifier == "" {
+		return nil
+	}
+
+	for i := range c.Tables {
+		if strings.EqualFold(c.Tables[i].Alias, c.Qualifier) {
+			return &c.Tables[i]
+		}
+	}
+	for i := range c.Tables {
+		if c.Tables[i].Alias == "" && strings.EqualFold(c.Tables[i].Table, c.Qualifier) {
+			return &c.Tables[i]
+		}
+	}
+	return nil
+}
+
+// ParseSQLCompletion
+// parse context of cursor by vitess tokenizer, pos is the character offset of cursor,
+// only the statement of cursor is parsed when SQL contains multiple statements
+func ParseSQLCompletion(sql string, pos int) *SQLCompletion {
+	before := sql[:completionByteOffset(sql, pos)]
+
+	// word before cursor, backtick of quoted identifier is dropped
+	start := len(before)
+	for start > 0 && isCompletionIdentByte(before[start-1]) {
+		start--
+	}
+	word := before[start:]
+
+	completion := &SQLCompletion{}
+	if dot := strings.LastIndex(word, "."); dot >= 0 {
+		completion.Qualifier = strings.Trim(word[:dot], "`")
+		if i := strings.LastIndex(completion.Qualifier, "."); i >= 0 {
+			completion.Qualifier = strings.Trim(completion.Qualifier[i+1:], "`")
+		}
+		word = word[dot+1:]
+	}
+	completion.Prefix = strings.TrimLeft(word, "`")
+	completion.Start = len([]rune(before)) - len([]rune(completion.Prefix))
+
+	// tokens before the word decide what is expected
+	tokens, stmtStart, err := completionTokens(before[:start])
+	if err {
+		completion.Expect = SQLExpectNone
+		return completion
+	}
+	if len(tokens) > 0 {
+		last := tokens[len(tokens)-1]
+		if last.typ == vsqlparser.COMMENT {
+			completion.Expect = SQLExpectNone
+			return completion
+		}
+
+		switch strings.ToLower(last.val) {
+		case "use":
+			completion.Expect = SQLExpectSchema
+		default:
+			if sqlTableKeywords[strings.ToLower(last.val)] && last.typ != vsqlparser.ID {
+				completion.Expect = SQLExpectTable
+			}
+		}
+	}
+
+	// tables of the whole statement, include the part after cursor
+	stmtEnd := len(sql)
+	tokenizer := vsqlparser.NewStringTokenizer(sql[len(before):])
+	for {
+		typ, _ := tokenizer.Scan()
+		if typ == 0 || typ == vsqlparser.LEX_ERROR {
+			break
+		}
+		if typ == ';' {
+			stmtEnd = len(before) + tokenizer.Pos - 1
+			break
+		}
+	}
+	stmtTokens, _, _ := completionTokens(sql[stmtStart:stmtEnd])
+	completion.Tables = completionTableRefs(stmtTokens)
+
+	return completion
+}
+
+type completionToken struct {
+	typ int
+	val string
+}
+
+// completionByteOffset byte offset of character pos, end of sql if pos is out of range
+func completionByteOffset(sql string, pos int) int {
+	if pos < 0 {
+		return len(sql)
+	}
+
+	n := 0
+	for i := range sql {
+		if n == pos {
+			return i
+		}
+		n++
+	}
+	return len(sql)
+}
+
+// completionTokens
+// tokens of the last statement in sql, offset of the last statement is returned,
+// err is true if sql ends in unterminated string or quoted identifier
+func completionTokens(sql string) ([]completionToken, int, bool) {
+	tokens := make([]completionToken, 0)
+	stmtStart := 0
+
+	tokenizer := vsqlparser.NewStringTokenizer(sql)
+	for {
+		typ, val := tokenizer.Scan()
+		if typ == 0 {
+			return tokens, stmtStart, false
+		}
+		if typ == vsqlparser.LEX_ERROR {
+			return tokens, stmtStart, true
+		}
+
+		if typ == ';' {
+			tokens = tokens[:0]
+			stmtStart = tokenizer.Pos
+			continue
+		}
+		tokens = append(tokens, completionToken{typ: typ, val: val})
+	}
+}
+
+// completionTableRefs tables after FROM、JOIN、UPDATE、INTO, tables separated by comma and their aliases
+func completionTableRefs(tokens []completionToken) []SQLTableRef {
+	refs := make([]SQLTableRef, 0)
+
+	isKeyword := func(i int, keyword string) bool {
+		return i < len(tokens) && tokens[i].typ != vsqlparser.ID && strings.EqualFold(tokens[i].val, keyword)
+	}
+	isIdent := func(i int) bool {
+		return i < len(tokens) && tokens[i].typ == vsqlparser.ID
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		if !isKeyword(i, "from") && !isKeyword(i, "join") && !isKeyword(i, "update") && !isKeyword(i, "into") {
+			continue
+		}
+
+		j := i + 1
+		for isIdent(j) {
+			ref := SQLTableRef{Table: tokens[j].val}
+			j++
+			if j+1 < len(tokens) && tokens[j].typ == '.' && isIdent(j+1) {
+				ref.Schema = ref.Table
+				ref.Table = tokens[j+1].val
+				j += 2
+			}
+
+			if isKeyword(j, "as") && isIdent(j+1) {
+				ref.Alias = tokens[j+1].val
+				j += 2
+			} else if isIdent(j) {
+				ref.Alias = tokens[j].val
+				j++
+			}
+			refs = append(refs, ref)
+
+			// comma separated tables of FROM
+			if j < len(tokens) && tokens[j].typ == ',' && !isKeyword(i, "into") {
+				j++
+				continue
+			}
+			break
+		}
+		i = j - 1
+	}
+	return refs
+}
+
+func isCompletionIdentByte(b byte) bool {
+	return b == '_' || b == '$' || b == '.' || b == '`' || b >= 0x80 ||
+		(b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// RedisCompletion
+// context of cursor in redis command, IsCommand is true if cursor is at the command name
+func RedisCompletion(cmd string, pos int) (prefix string, start int, isCommand bool) {
+	runes := []rune(cmd)
+	if pos < 0 || pos > len(runes) {
+		pos = len(runes)
+	}
+
+	start = pos
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	prefix = string(runes[start:pos])
+	isCommand = strings.TrimSpace(string(runes[:start])) == ""
+
+	return prefix, start, isCommand
+}
+
+// RedisKeyCompletions
+// keys start with prefix, keys of the same segment separated by colon are folded into one prefix, eg: user:1:name and user:2:name
+// are offered as user: for prefix us
+func RedisKeyCompletions(keys []string, prefix string) []common.Completion {
+	seen := map[string]bool{}
+	completions := make([]common.Completion, 0)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		completion := common.Completion{Label: key, Kind: common.CompletionKey}
+		if i := strings.Index(key[len(prefix):], ":"); i >= 0 && len(prefix)+i+1 < len(key) {
+			completion = common.Completion{Label: key[:len(prefix)+i+1], Kind: common.CompletionKeyPrefix}
+		}
+
+		if !seen[completion.Label] {
+			seen[completion.Label] = true
+			completions = append(completions, completion)
+		}
+	}
+
+	sort.Slice(completions, func(i, j int) bool {
+		return completions[i].Label < completions[j].Label
+	})
+	return completions
+}
+
+// FilterCompletions candidates start with prefix ignore case, duplicate candidates of the same kind are removed
+func FilterCompletions(candidates []common.Completion, prefix string, limit int) []common.Completion {
+	prefix = strings.ToLower(prefix)
+	seen := map[string]bool{}
+
+	result := make([]common.Completion, 0)
+	for _, c := range candidates {
+		if !strings.HasPrefix(strings.ToLower(c.Label), prefix) {
+			continue
+		}
+
+		key := c.Kind + "\x00" + c.Label + "\x00" + c.Detail
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		result = append(result, c)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+)
+
+func TestParseSQLCompletion(t *testing.T) {
+	cases := []struct {
+		sql       string
+		pos       int
+		prefix    string
+		start     int
+		qualifier string
+		expect    string
+		tables    []SQLTableRef
+	}{
+		{
+			sql:    "select na from users",
+			pos:    9,
+			prefix: "na",
+			start:  7,
+			tables: []SQLTableRef{{Table: "users"}},
+		},
+		{
+			sql:       "select u.na, o.id from shop.users as u join `orders` o on u.id = o.uid",
+			pos:       11,
+			prefix:    "na",
+			start:     9,
+			qualifier: "u",
+			tables:    []SQLTableRef{{Schema: "shop", Table: "users", Alias: "u"}, {Table: "orders", Alias: "o"}},
+		},
+		{
+			sql:    "select * from us",
+			pos:    16,
+			prefix: "us",
+			start:  14,
+			expect: SQLExpectTable,
+			tables: []SQLTableRef{{Table: "us"}},
+		},
+		{
+			sql:       "select * from shop.",
+			pos:       19,
+			qualifier: "shop",
+			start:     19,
+			expect:    SQLExpectTable,
+			tables:    []SQLTableRef{{Table: "shop"}},
+		},
+		{
+			sql:    "select 1 from t1; select * from a, b where ; select * from c",
+			pos:    43,
+			start:  43,
+			tables: []SQLTableRef{{Table: "a"}, {Table: "b"}},
+		},
+		{
+			sql:    "select * from users where name = 'us",
+			pos:    36,
+			prefix: "us",
+			start:  34,
+			expect: SQLExpectNone,
+		},
+		{
+			sql:    "use sh",
+			pos:    6,
+			prefix: "sh",
+			start:  4,
+			expect: SQLExpectSchema,
+			tables: []SQLTableRef{},
+		},
+		{
+			sql:    "update `订单` set 名",
+			pos:    17,
+			prefix: "名",
+			start:  16,
+			tables: []SQLTableRef{{Table: "订单"}},
+		},
+	}
+
+	for _, c := range cases {
+		completion := ParseSQLCompletion(c.sql, c.pos)
+		require.Equal(t, c.prefix, completion.Prefix, c.sql)
+		require.Equal(t, c.start, completion.Start, c.sql)
+		require.Equal(t, c.qualifier, completion.Qualifier, c.sql)
+		require.Equal(t, c.expect, completion.Expect, c.sql)
+		if c.expect != SQLExpectNone {
+			require.Equal(t, c.tables, completion.Tables, c.sql)
+		}
+	}
+
+	completion := ParseSQLCompletion("select o. from shop.users u join orders o", 9)
+	require.Equal(t, &SQLTableRef{Table: "orders", Alias: "o"}, completion.QualifiedTable())
+	completion = ParseSQLCompletion("select users. from shop.users", 13)
+	require.Equal(t, &SQLTableRef{Schema: "shop", Table: "users"}, completion.QualifiedTable())
+}
+
+func TestRedisCompletion(t *testing.T) {
+	prefix, start, isCommand := RedisCompletion("hg", 2)
+	require.Equal(t, "hg", prefix)
+	require.Equal(t, 0, start)
+	require.True(t, isCommand)
+
+	prefix, start, isCommand = RedisCompletion("hget user:1 name", 9)
+	require.Equal(t, "user", prefix)
+	require.Equal(t, 5, start)
+	require.False(t, isCommand)
+
+	keys := []string{"user:1:name", "user:2:name", "user:count", "order:1", "user"}
+	require.Equal(t, []common.Completion{
+		{Label: "user", Kind: common.CompletionKey},
+		{Label: "user:", Kind: common.CompletionKeyPrefix},
+	}, RedisKeyCompletions(keys, "us"))
+	require.Equal(t, []common.Completion{
+		{Label: "user:1:", Kind: common.CompletionKeyPrefix},
+		{Label: "user:2:", Kind: common.CompletionKeyPrefix},
+		{Label: "user:count", Kind: common.CompletionKey},
+	}, RedisKeyCompletions(keys, "user:"))
+}
+
+func TestFilterCompletions(t *testing.T) {
+	candidates := []common.Completion{
+		{Label: "name", Kind: common.CompletionColumn, Detail: "users"},
+		{Label: "name", Kind: common.CompletionColumn, Detail: "users"},
+		{Label: "NOW", Kind: common.CompletionFunction},
+		{Label: "NOT", Kind: common.CompletionKeyword},
+		{Label: "id", Kind: common.CompletionColumn},
+	}
+
+	require.Equal(t, candidates[:1], FilterCompletions(candidates, "Na", 0))
+	require.Equal(t, []common.Completion{candidates[0], candidates[2]}, FilterCompletions(candidates, "n", 2))
+}
@@ -0,0 +1,831 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+// Describer engine supports structured metadata of table
+type Describer interface {
+	Engine
+
+	DescribeTable(ctx context.Context, schema string, table string) (*common.TableDetail, error)
+	ShowCreateTable(ctx context.Context, schema string, table string) (*common.TableDDL, error)
+}
+
+// describeWithHooks
+// run hooks around fetching metadata of table, prev hook failed stop fetching
+func describeWithHooks(ctx context.Context, base *common.EngineBase, engineType string, action string, schema string, table string, fetch func() error) error {
+	err := base.RunPrevHooks(&common.PrevHookArgs{
+		EngineType: engineType,
+		Action:     action,
+		Caller:     common.CallerFromContext(ctx),
+		Schema:     schema,
+		Table:      table,
+	})
+	if err != nil {
+		return err
+	}
+
+	postArgs := &common.PostHookArgs{
+		EngineType: engineType,
+		Action:     action,
+		Caller:     common.CallerFromContext(ctx),
+		ExecuteAt:  time.Now(),
+		Schema:     schema,
+		Table:      table,
+	}
+
+	if schema == "" {
+		err = inerr.ErrSchemaEmpty
+	} else if table == "" {
+		err = inerr.ErrTableEmpty
+	} else {
+		err = fetch()
+	}
+
+	postArgs.QueryDuration = time.Since(postArgs.ExecuteAt).Milliseconds()
+	postArgs.IsExecute = err == nil
+	postArgs.Err = err
+
+	base.RunPostHooks(postArgs)
+
+	return err
+}
+
+// DescribeTable
+// columns、indexes、foreign keys and estimated size of table from information_schema
+func (m *MySQLEngine) DescribeTable(ctx context.Context, schema string, table string) (*common.TableDetail, error) {
+	var detail *common.TableDetail
+	err := describeWithHooks(ctx, m.EngineBase, common.MySQLEngine, common.ActionDescribeTable, schema, table, func() error {
+		var err error
+		detail, err = m.describeTable(ctx, schema, table)
+		return err
+	})
+	return detail, err
+}
+
+// ShowCreateTable
+// result of SHOW CREATE TABLE, view is supported too
+func (m *MySQLEngine) ShowCreateTable(ctx context.Context, schema string, table string) (*common.TableDDL, error) {
+	var ddl *common.TableDDL
+	err := describeWithHooks(ctx, m.EngineBase, common.MySQLEngine, common.ActionShowCreateTable, schema, table, func() error {
+		var err error
+		ddl, err = m.showCreateTable(ctx, schema, table)
+		return err
+	})
+	return ddl, err
+}
+
+func (m *MySQLEngine) describeTable(ctx context.Context, schema string, table string) (*common.TableDetail, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	detail := &common.TableDetail{
+		Schema:      schema,
+		Table:       table,
+		Columns:     []common.TableColumn{},
+		Indexes:     []common.TableIndex{},
+		ForeignKeys: []common.TableForeignKey{},
+	}
+
+	rows, err := m.driver.WithContext(ctx).Raw(
+		"select table_type, ifnull(engine, ''), ifnull(table_comment, ''), ifnull(table_rows, 0), "+
+			"ifnull(data_length, 0), ifnull(index_length, 0) from information_schema.tables "+
+			"where table_schema = ? and table_name = ?", schema, table).Rows()
+	if err != nil {
+		return nil, err
+	}
+	found := rows.Next()
+	if found {
+		err = rows.Scan(&detail.Type, &detail.Engine, &detail.Comment, &detail.Rows, &detail.DataLength, &detail.IndexLength)
+	}
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, inerr.ErrTableNotFound
+	}
+
+	if detail.Columns, err = m.tableColumns(ctx, schema, table); err != nil {
+		return nil, err
+	}
+	if detail.Indexes, err = m.tableIndexes(ctx, schema, table); err != nil {
+		return nil, err
+	}
+	if detail.ForeignKeys, err = m.tableForeignKeys(ctx, schema, table); err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+func (m *MySQLEngine) tableColumns(ctx context.Context, schema string, table string) ([]common.TableColumn, error) {
+	rows, err := m.driver.WithContext(ctx).Raw(
+		"select column_name, column_type, is_nullable, column_default, column_key, extra, column_comment "+
+			"from information_schema.columns where table_schema = ? and table_name = ? order by ordinal_position",
+		schema, table).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]common.TableColumn, 0)
+	for rows.Next() {
+		var column common.TableColumn
+		var nullable, key string
+		var dflt sql.NullString
+		if err = rows.Scan(&column.Name, &column.DatabaseType, &nullable, &dflt, &key, &column.Extra, &column.Comment); err != nil {
+			return nil, err
+		}
+
+		column.Nullable = nullable == "YES"
+		column.PrimaryKey = key == "PRI"
+		if dflt.Valid {
+			column.Default = &dflt.String
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// tableIndexes indexes of table, primary key first, column of functional index is skipped
+func (m *MySQLEngine) tableIndexes(ctx context.Context, schema string, table string) ([]common.TableIndex, error) {
+	rows, err := m.driver.WithContext(ctx).Raw(
+		"select index_name, column_name, non_unique, index_type, index_comment from information_schema.statistics "+
+			"where table_schema = ? and table_name = ? order by index_name <> 'PRIMARY', index_name, seq_in_index",
+		schema, table).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make([]common.TableIndex, 0)
+	for rows.Next() {
+		var name, indexType, comment string
+		var column sql.NullString
+		var nonUnique int
+		if err = rows.Scan(&name, &column, &nonUnique, &indexType, &comment); err != nil {
+			return nil, err
+		}
+
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, common.TableIndex{
+				Name:    name,
+				Columns: []string{},
+				Unique:  nonUnique == 0,
+				Primary: name == "PRIMARY",
+				Type:    indexType,
+				Comment: comment,
+			})
+		}
+		if column.Valid {
+			last := &indexes[len(indexes)-1]
+			last.Columns = append(last.Columns, column.String)
+		}
+	}
+	return indexes, rows.Err()
+}
+
+func (m *MySQLEngine) tableForeignKeys(ctx context.Context, schema string, table string) ([]common.TableForeignKey, error) {
+	rows, err := m.driver.WithContext(ctx).Raw(
+		"select k.constraint_name, k.column_name, k.referenced_table_schema, k.referenced_table_name, "+
+			"k.referenced_column_name, r.update_rule, r.delete_rule from information_schema.key_column_usage k "+
+			"join information_schema.referential_constraints r on r.constraint_schema = k.constraint_schema "+
+			"and r.constraint_name = k.constraint_name and r.table_name = k.table_name "+
+			"where k.table_schema = ? and k.table_name = ? and k.referenced_table_name is not null "+
+			"order by k.constraint_name, k.ordinal_position",
+		schema, table).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]common.TableForeignKey, 0)
+	for rows.Next() {
+		var fk common.TableForeignKey
+		var column, refColumn string
+		if err = rows.Scan(&fk.Name, &column, &fk.RefSchema, &fk.RefTable, &refColumn, &fk.OnUpdate, &fk.OnDelete); err != nil {
+			return nil, err
+		}
+
+		if len(foreignKeys) == 0 || foreignKeys[len(foreignKeys)-1].Name != fk.Name {
+			foreignKeys = append(foreignKeys, fk)
+		}
+		last := &foreignKeys[len(foreignKeys)-1]
+		last.Columns = append(last.Columns, column)
+		last.RefColumns = append(last.RefColumns, refColumn)
+	}
+	return foreignKeys, rows.Err()
+}
+
+// showCreateTable
+// SHOW CREATE TABLE returns Table、Create Table for table, View、Create View and charset for view,
+// the statement is always the second column
+func (m *MySQLEngine) showCreateTable(ctx context.Context, schema string, table string) (*common.TableDDL, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	rows, err := m.driver.WithContext(ctx).Raw(
+		fmt.Sprintf("show create table %s.%s", mysqlQuoteIdent(schema), mysqlQuoteIdent(table))).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	col, _ := rows.Columns()
+	tmpDest := common.UnifiedLabel(col)
+	if len(tmpDest) < 2 {
+		return nil, inerr.ErrFieldEmpty
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, inerr.ErrTableNotFound
+	}
+	if err = rows.Scan(tmpDest...); err != nil {
+		return nil, err
+	}
+
+	return &common.TableDDL{
+		Schema: schema,
+		Table:  table,
+		DDL:    *tmpDest[1].(*string),
+	}, nil
+}
+
+// mysqlQuoteIdent quote identifier by backtick
+func mysqlQuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// DescribeTable
+// columns、indexes、foreign keys of table from pragma, rows are counted
+func (s *SQLiteEngine) DescribeTable(ctx context.Context, schema string, table string) (*common.TableDetail, error) {
+	var detail *common.TableDetail
+	err := describeWithHooks(ctx, s.EngineBase, common.SQLiteEngine, common.ActionDescribeTable, schema, table, func() error {
+		var err error
+		detail, err = s.describeTable(ctx, schema, table)
+		return err
+	})
+	return detail, err
+}
+
+// ShowCreateTable
+// statement of table in sqlite_master, followed by statements of indexes created explicitly
+func (s *SQLiteEngine) ShowCreateTable(ctx context.Context, schema string, table string) (*common.TableDDL, error) {
+	var ddl *common.TableDDL
+	err := describeWithHooks(ctx, s.EngineBase, common.SQLiteEngine, common.ActionShowCreateTable, schema, table, func() error {
+		var err error
+		ddl, err = s.showCreateTable(ctx, schema, table)
+		return err
+	})
+	return ddl, err
+}
+
+// sqliteObjectType type of table or view in sqlite_master, empty if not found
+func (s *SQLiteEngine) sqliteObjectType(ctx context.Context, schema string, table string) (string, error) {
+	rows, err := s.driver.WithContext(ctx).Raw(fmt.Sprintf(
+		"select type from %s.sqlite_master where name = ? and type in ('table', 'view')",
+		SQLiteQuoteIdent(schema)), table).Rows()
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var objType string
+	if rows.Next() {
+		if err = rows.Scan(&objType); err != nil {
+			return "", err
+		}
+	}
+	return objType, rows.Err()
+}
+
+func (s *SQLiteEngine) describeTable(ctx context.Context, schema string, table string) (*common.TableDetail, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	objType, err := s.sqliteObjectType(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	if objType == "" {
+		return nil, inerr.ErrTableNotFound
+	}
+
+	detail := &common.TableDetail{
+		Schema:      schema,
+		Table:       table,
+		Type:        "BASE TABLE",
+		ForeignKeys: []common.TableForeignKey{},
+	}
+	if objType == "view" {
+		detail.Type = "VIEW"
+	}
+
+	var pk []string
+	if detail.Columns, pk, err = s.tableColumns(ctx, schema, table); err != nil {
+		return nil, err
+	}
+	if detail.Indexes, err = s.tableIndexes(ctx, schema, table, pk); err != nil {
+		return nil, err
+	}
+	if detail.ForeignKeys, err = s.tableForeignKeys(ctx, schema, table); err != nil {
+		return nil, err
+	}
+
+	if objType == "table" {
+		err = s.driver.WithContext(ctx).Raw(fmt.Sprintf("select count(*) from %s.%s",
+			SQLiteQuoteIdent(schema), SQLiteQuoteIdent(table))).Row().Scan(&detail.Rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return detail, nil
+}
+
+// tableColumns columns of table and columns of primary key in order
+func (s *SQLiteEngine) tableColumns(ctx context.Context, schema string, table string) ([]common.TableColumn, []string, error) {
+	rows, err := s.driver.WithContext(ctx).Raw(
+		`select name, type, "notnull", dflt_value, pk from pragma_table_info(?, ?) order by cid`,
+		table, schema).Rows()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]common.TableColumn, 0)
+	pkColumns := map[int]string{}
+	for rows.Next() {
+		var column common.TableColumn
+		var notNull, pk int
+		var dflt sql.NullString
+		if err = rows.Scan(&column.Name, &column.DatabaseType, &notNull, &dflt, &pk); err != nil {
+			return nil, nil, err
+		}
+
+		column.Nullable = notNull == 0 && pk == 0
+		column.PrimaryKey = pk > 0
+		if dflt.Valid {
+			column.Default = &dflt.String
+		}
+		if pk > 0 {
+			pkColumns[pk] = column.Name
+		}
+		columns = append(columns, column)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	pk := make([]string, 0, len(pkColumns))
+	for i := 1; i <= len(pkColumns); i++ {
+		pk = append(pk, pkColumns[i])
+	}
+	return columns, pk, nil
+}
+
+// tableIndexes
+// indexes of table, rowid primary key has no index in pragma_index_list, it is reported as PRIMARY
+func (s *SQLiteEngine) tableIndexes(ctx context.Context, schema string, table string, pk []string) ([]common.TableIndex, error) {
+	rows, err := s.driver.WithContext(ctx).Raw(
+		`select name, "unique", origin from pragma_index_list(?, ?) order by origin <> 'pk', name`,
+		table, schema).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]common.TableIndex, 0)
+	for rows.Next() {
+		var index common.TableIndex
+		var unique int
+		var origin string
+		if err = rows.Scan(&index.Name, &unique, &origin); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		index.Unique = unique == 1
+		index.Primary = origin == "pk"
+		indexes = append(indexes, index)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range indexes {
+		if err = s.driver.WithContext(ctx).Raw(
+			"select name from pragma_index_info(?, ?) order by seqno", indexes[i].Name, schema).
+			Scan(&indexes[i].Columns).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pk) > 0 && (len(indexes) == 0 || !indexes[0].Primary) {
+		indexes = append([]common.TableIndex{{
+			Name:    "PRIMARY",
+			Columns: pk,
+			Unique:  true,
+			Primary: true,
+		}}, indexes...)
+	}
+	return indexes, nil
+}
+
+// tableForeignKeys
+// foreign keys of table, sqlite does not name foreign key, RefColumns is empty if foreign key refers primary key implicitly
+func (s *SQLiteEngine) tableForeignKeys(ctx context.Context, schema string, table string) ([]common.TableForeignKey, error) {
+	rows, err := s.driver.WithContext(ctx).Raw(
+		`select id, "table", "from", "to", on_update, on_delete from pragma_foreign_key_list(?, ?) order by id, seq`,
+		table, schema).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]common.TableForeignKey, 0)
+	lastID := -1
+	for rows.Next() {
+		var id int
+		var fk common.TableForeignKey
+		var column string
+		var refColumn sql.NullString
+		if err = rows.Scan(&id, &fk.RefTable, &column, &refColumn, &fk.OnUpdate, &fk.OnDelete); err != nil {
+			return nil, err
+		}
+
+		if id != lastID {
+			fk.RefSchema = schema
+			fk.Columns = []string{}
+			fk.RefColumns = []string{}
+			foreignKeys = append(foreignKeys, fk)
+			lastID = id
+		}
+		last := &foreignKeys[len(foreignKeys)-1]
+		last.Columns = append(last.Columns, column)
+		if refColumn.Valid {
+			last.RefColumns = append(last.RefColumns, refColumn.String)
+		}
+	}
+	return foreignKeys, rows.Err()
+}
+
+func (s *SQLiteEngine) showCreateTable(ctx context.Context, schema string, table string) (*common.TableDDL, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	statements := make([]string, 0)
+	err := s.driver.WithContext(ctx).Raw(fmt.Sprintf(
+		"select sql from %s.sqlite_master where tbl_name = ? and sql is not null "+
+			"order by type not in ('table', 'view'), name",
+		SQLiteQuoteIdent(schema)), table).Scan(&statements).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return nil, inerr.ErrTableNotFound
+	}
+
+	return &common.TableDDL{
+		Schema: schema,
+		Table:  table,
+		DDL:    strings.Join(statements, ";\n") + ";",
+	}, nil
+}
+
+// postgres referential actions of pg_constraint.confupdtype、confdeltype
+var postgresReferentialActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// DescribeTable
+// columns from information_schema.columns, indexes from pg_indexes, foreign keys and estimated size from pg_catalog,
+// table is schema-qualified as returned by fetchTable, eg: public.users, schema of search_path is used if not qualified
+func (p *PostgresEngine) DescribeTable(ctx context.Context, schema string, table string) (*common.TableDetail, error) {
+	var detail *common.TableDetail
+	err := describeWithHooks(ctx, p.EngineBase, common.PostgresEngine, common.ActionDescribeTable, schema, table, func() error {
+		var err error
+		detail, err = p.describeTable(ctx, schema, table)
+		return err
+	})
+	return detail, err
+}
+
+// ShowCreateTable
+// postgres has no SHOW CREATE TABLE, statement is built from columns、constraints and pg_indexes,
+// view is printed by pg_get_viewdef
+func (p *PostgresEngine) ShowCreateTable(ctx context.Context, schema string, table string) (*common.TableDDL, error) {
+	var ddl *common.TableDDL
+	err := describeWithHooks(ctx, p.EngineBase, common.PostgresEngine, common.ActionShowCreateTable, schema, table, func() error {
+		var err error
+		ddl, err = p.showCreateTable(ctx, schema, table)
+		return err
+	})
+	return ddl, err
+}
+
+// postgresSplitTable namespace and name of schema-qualified table, namespace is empty if not qualified
+func postgresSplitTable(table string) (string, string) {
+	if i := strings.Index(table, "."); i >= 0 {
+		return table[:i], table[i+1:]
+	}
+	return "", table
+}
+
+// resolveTable namespace and name of table, namespace is current_schema() if table is not qualified
+func (p *PostgresEngine) resolveTable(ctx context.Context, table string) (string, string, error) {
+	namespace, name := postgresSplitTable(table)
+	if namespace != "" {
+		return namespace, name, nil
+	}
+
+	var current sql.NullString
+	if err := p.driver.WithContext(ctx).Raw("select current_schema()").Row().Scan(&current); err != nil {
+		return "", "", err
+	}
+	if !current.Valid {
+		return "", "", inerr.ErrTableNotFound
+	}
+	return current.String, name, nil
+}
+
+func (p *PostgresEngine) describeTable(ctx context.Context, schema string, table string) (*common.TableDetail, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	namespace, name, err := p.resolveTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &common.TableDetail{
+		Schema: schema,
+		Table:  table,
+	}
+
+	rows, err := p.driver.WithContext(ctx).Raw(
+		"select t.table_type, coalesce(obj_description(c.oid, 'pg_class'), ''), greatest(c.reltuples, 0)::bigint, "+
+			"pg_relation_size(c.oid), pg_indexes_size(c.oid) from information_schema.tables t "+
+			"join pg_namespace n on n.nspname = t.table_schema "+
+			"join pg_class c on c.relnamespace = n.oid and c.relname = t.table_name "+
+			"where t.table_schema = ? and t.table_name = ?", namespace, name).Rows()
+	if err != nil {
+		return nil, err
+	}
+	found := rows.Next()
+	if found {
+		err = rows.Scan(&detail.Type, &detail.Comment, &detail.Rows, &detail.DataLength, &detail.IndexLength)
+	}
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, inerr.ErrTableNotFound
+	}
+
+	if detail.Indexes, err = p.tableIndexes(ctx, namespace, name); err != nil {
+		return nil, err
+	}
+	if detail.Columns, err = p.tableColumns(ctx, namespace, name, detail.Indexes); err != nil {
+		return nil, err
+	}
+	if detail.ForeignKeys, err = p.tableForeignKeys(ctx, schema, namespace, name); err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+// tableColumns columns of table, type is printed by format_type so length and precision are kept
+func (p *PostgresEngine) tableColumns(ctx context.Context, namespace string, name string, indexes []common.TableIndex) ([]common.TableColumn, error) {
+	rows, err := p.driver.WithContext(ctx).Raw(
+		"select c.column_name, format_type(a.atttypid, a.atttypmod), c.is_nullable, c.column_default, "+
+			"case when c.is_identity = 'YES' then 'generated ' || lower(c.identity_generation) || ' as identity' else '' end, "+
+			"coalesce(col_description(a.attrelid, a.attnum), '') from information_schema.columns c "+
+			"join pg_attribute a on a.attrelid = format('%I.%I', c.table_schema, c.table_name)::regclass "+
+			"and a.attname = c.column_name "+
+			"where c.table_schema = ? and c.table_name = ? order by c.ordinal_position",
+		namespace, name).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pk := map[string]bool{}
+	if len(indexes) > 0 && indexes[0].Primary {
+		for _, column := range indexes[0].Columns {
+			pk[column] = true
+		}
+	}
+
+	columns := make([]common.TableColumn, 0)
+	for rows.Next() {
+		var column common.TableColumn
+		var nullable string
+		var dflt sql.NullString
+		if err = rows.Scan(&column.Name, &column.DatabaseType, &nullable, &dflt, &column.Extra, &column.Comment); err != nil {
+			return nil, err
+		}
+
+		column.Nullable = nullable == "YES"
+		column.PrimaryKey = pk[column.Name]
+		if dflt.Valid {
+			column.Default = &dflt.String
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// tableIndexes indexes of table in pg_indexes, primary key first, expression of functional index is skipped
+func (p *PostgresEngine) tableIndexes(ctx context.Context, namespace string, name string) ([]common.TableIndex, error) {
+	rows, err := p.driver.WithContext(ctx).Raw(
+		"select i.indexname, a.attname, x.indisunique, x.indisprimary, upper(am.amname), "+
+			"coalesce(obj_description(ic.oid, 'pg_class'), '') from pg_indexes i "+
+			"join pg_namespace n on n.nspname = i.schemaname "+
+			"join pg_class ic on ic.relnamespace = n.oid and ic.relname = i.indexname "+
+			"join pg_index x on x.indexrelid = ic.oid "+
+			"join pg_am am on am.oid = ic.relam "+
+			"cross join lateral unnest(x.indkey::int2[]) with ordinality k(attnum, ord) "+
+			"left join pg_attribute a on a.attrelid = x.indrelid and a.attnum = k.attnum "+
+			"where i.schemaname = ? and i.tablename = ? order by not x.indisprimary, i.indexname, k.ord",
+		namespace, name).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make([]common.TableIndex, 0)
+	for rows.Next() {
+		var index common.TableIndex
+		var column sql.NullString
+		if err = rows.Scan(&index.Name, &column, &index.Unique, &index.Primary, &index.Type, &index.Comment); err != nil {
+			return nil, err
+		}
+
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != index.Name {
+			index.Columns = []string{}
+			indexes = append(indexes, index)
+		}
+		if column.Valid {
+			last := &indexes[len(indexes)-1]
+			last.Columns = append(last.Columns, column.String)
+		}
+	}
+	return indexes, rows.Err()
+}
+
+// tableForeignKeys
+// foreign keys of table, RefSchema is the database and RefTable is schema-qualified as returned by fetchTable
+func (p *PostgresEngine) tableForeignKeys(ctx context.Context, schema string, namespace string, name string) ([]common.TableForeignKey, error) {
+	rows, err := p.driver.WithContext(ctx).Raw(
+		"select c.conname, a.attname, rn.nspname || '.' || rc.relname, ra.attname, c.confupdtype, c.confdeltype "+
+			"from pg_constraint c "+
+			"join pg_class t on t.oid = c.conrelid join pg_namespace n on n.oid = t.relnamespace "+
+			"join pg_class rc on rc.oid = c.confrelid join pg_namespace rn on rn.oid = rc.relnamespace "+
+			"cross join lateral unnest(c.conkey, c.confkey) with ordinality k(attnum, refattnum, ord) "+
+			"join pg_attribute a on a.attrelid = c.conrelid and a.attnum = k.attnum "+
+			"join pg_attribute ra on ra.attrelid = c.confrelid and ra.attnum = k.refattnum "+
+			"where c.contype = 'f' and n.nspname = ? and t.relname = ? order by c.conname, k.ord",
+		namespace, name).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]common.TableForeignKey, 0)
+	for rows.Next() {
+		var fk common.TableForeignKey
+		var column, refColumn, onUpdate, onDelete string
+		if err = rows.Scan(&fk.Name, &column, &fk.RefTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+
+		if len(foreignKeys) == 0 || foreignKeys[len(foreignKeys)-1].Name != fk.Name {
+			fk.RefSchema = schema
+			fk.OnUpdate = postgresReferentialActions[onUpdate]
+			fk.OnDelete = postgresReferentialActions[onDelete]
+			foreignKeys = append(foreignKeys, fk)
+		}
+		last := &foreignKeys[len(foreignKeys)-1]
+		last.Columns = append(last.Columns, column)
+		last.RefColumns = append(last.RefColumns, refColumn)
+	}
+	return foreignKeys, rows.Err()
+}
+
+// showCreateTable
+// CREATE TABLE with columns and constraints of table, followed by statements of indexes not created by constraints
+func (p *PostgresEngine) showCreateTable(ctx context.Context, schema string, table string) (*common.TableDDL, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	namespace, name, err := p.resolveTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var relKind, viewDef sql.NullString
+	rows, err := p.driver.WithContext(ctx).Raw(
+		"select c.relkind::text, case when c.relkind in ('v', 'm') then pg_get_viewdef(c.oid, true) end "+
+			"from pg_class c join pg_namespace n on n.oid = c.relnamespace "+
+			"where n.nspname = ? and c.relname = ? and c.relkind in ('r', 'p', 'v', 'm', 'f')", namespace, name).Rows()
+	if err != nil {
+		return nil, err
+	}
+	found := rows.Next()
+	if found {
+		err = rows.Scan(&relKind, &viewDef)
+	}
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, inerr.ErrTableNotFound
+	}
+
+	ident := postgresQuoteIdent(namespace) + "." + postgresQuoteIdent(name)
+	switch relKind.String {
+	case "v":
+		return &common.TableDDL{Schema: schema, Table: table, DDL: "CREATE VIEW " + ident + " AS\n" + viewDef.String}, nil
+	case "m":
+		return &common.TableDDL{Schema: schema, Table: table, DDL: "CREATE MATERIALIZED VIEW " + ident + " AS\n" + viewDef.String}, nil
+	}
+
+	columns, err := p.tableColumns(ctx, namespace, name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := make([]string, 0)
+	err = p.driver.WithContext(ctx).Raw(
+		"select 'CONSTRAINT ' || quote_ident(c.conname) || ' ' || pg_get_constraintdef(c.oid, true) "+
+			"from pg_constraint c join pg_class t on t.oid = c.conrelid join pg_namespace n on n.oid = t.relnamespace "+
+			"where n.nspname = ? and t.relname = ? and c.contype in ('p', 'u', 'f', 'c', 'x') "+
+			"order by c.contype <> 'p', c.contype, c.conname", namespace, name).Scan(&constraints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]string, 0)
+	err = p.driver.WithContext(ctx).Raw(
+		"select i.indexdef from pg_indexes i join pg_namespace n on n.nspname = i.schemaname "+
+			"join pg_class ic on ic.relnamespace = n.oid and ic.relname = i.indexname "+
+			"where i.schemaname = ? and i.tablename = ? "+
+			"and not exists (select 1 from pg_constraint c where c.conindid = ic.oid) order by i.indexname",
+		namespace, name).Scan(&indexes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.TableDDL{
+		Schema: schema,
+		Table:  table,
+		DDL:    postgresCreateTable(ident, columns, constraints, indexes),
+	}, nil
+}
+
+// postgresCreateTable statement of table built from columns, constraint definitions and index statements
+func postgresCreateTable(ident string, columns []common.TableColumn, constraints []string, indexes []string) string {
+	lines := make([]string, 0, len(columns)+len(constraints))
+	for _, column := range columns {
+		line := postgresQuoteIdent(column.Name) + " " + column.DatabaseType
+		if column.Extra != "" {
+			line += " " + strings.ToUpper(column.Extra)
+		}
+		if !column.Nullable {
+			line += " NOT NULL"
+		}
+		if column.Default != nil {
+			line += " DEFAULT " + *column.Default
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, constraints...)
+
+	statements := append([]string{"CREATE TABLE " + ident + " (\n  " + strings.Join(lines, ",\n  ") + "\n)"}, indexes...)
+	return strings.Join(statements, ";\n") + ";"
+}
+
+// postgresQuoteIdent quote identifier by double quote
+func postgresQuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestSQLiteDescribeTable(t *testing.T) {
+	eg, err := ForkSQLiteEngine(common.ConnConfig{FilePath: filepath.Join(t.TempDir(), "describe.db")})
+	require.NoError(t, err)
+	defer eg.Close()
+
+	ctx := context.Background()
+	opt := common.QueryOptions{Timeout: 5}
+	for _, sql := range []string{
+		"create table users (id integer primary key, name text not null default 'anonymous')",
+		"create table orders (id integer primary key, uid integer references users(id) on delete cascade, " +
+			"code text, amount real, unique (code))",
+		"create index idx_orders_uid_amount on orders(uid, amount)",
+		"insert into users(id, name) values (1, 'li'), (2, 'wang')",
+	} {
+		require.NoError(t, eg.Query(ctx, "main", "", sql, opt).Err, sql)
+	}
+
+	actions := make([]string, 0)
+	eg.RegistryQueryPost(func(args *common.PostHookArgs) {
+		actions = append(actions, args.Action+":"+args.Table)
+	})
+
+	detail, err := eg.DescribeTable(ctx, "main", "users")
+	require.NoError(t, err)
+	require.Equal(t, "BASE TABLE", detail.Type)
+	require.Equal(t, int64(2), detail.Rows)
+	require.Len(t, detail.Columns, 2)
+	require.True(t, detail.Columns[0].PrimaryKey)
+	require.False(t, detail.Columns[1].Nullable)
+	require.Equal(t, "'anonymous'", *detail.Columns[1].Default)
+	require.Equal(t, []common.TableIndex{{Name: "PRIMARY", Columns: []string{"id"}, Unique: true, Primary: true}}, detail.Indexes)
+	require.Empty(t, detail.ForeignKeys)
+
+	detail, err = eg.DescribeTable(ctx, "main", "orders")
+	require.NoError(t, err)
+	require.Len(t, detail.Indexes, 3)
+	require.Equal(t, "PRIMARY", detail.Indexes[0].Name)
+	require.Equal(t, []string{"uid", "amount"}, detail.Indexes[1].Columns)
+	require.False(t, detail.Indexes[1].Unique)
+	require.Equal(t, []string{"code"}, detail.Indexes[2].Columns)
+	require.True(t, detail.Indexes[2].Unique)
+	require.Equal(t, []common.TableForeignKey{{
+		Columns:    []string{"uid"},
+		RefSchema:  "main",
+		RefTable:   "users",
+		RefColumns: []string{"id"},
+		OnUpdate:   "NO ACTION",
+		OnDelete:   "CASCADE",
+	}}, detail.ForeignKeys)
+
+	ddl, err := eg.ShowCreateTable(ctx, "main", "orders")
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE orders (id integer primary key, uid integer references users(id) on delete cascade, "+
+		"code text, amount real, unique (code));\nCREATE INDEX idx_orders_uid_amount on orders(uid, amount);", ddl.DDL)
+
+	_, err = eg.DescribeTable(ctx, "main", "missing")
+	require.ErrorIs(t, err, inerr.ErrTableNotFound)
+
+	_, err = eg.ShowCreateTable(ctx, "main", "")
+	require.ErrorIs(t, err, inerr.ErrTableEmpty)
+
+	require.Equal(t, []string{
+		"describeTable:users", "describeTable:orders", "showCreateTable:orders",
+		"describeTable:missing", "showCreateTable:",
+	}, actions)
+}
+
+func TestPostgresCreateTable(t *testing.T) {
+	namespace, name := postgresSplitTable("public.users")
+	require.Equal(t, "public", namespace)
+	require.Equal(t, "users", name)
+	namespace, name = postgresSplitTable("users")
+	require.Equal(t, "", namespace)
+	require.Equal(t, "users", name)
+
+	dflt := "'anonymous'::text"
+	ddl := postgresCreateTable(`"public"."users"`, []common.TableColumn{
+		{Name: "id", DatabaseType: "bigint", Extra: "generated always as identity"},
+		{Name: "name", DatabaseType: "text", Default: &dflt},
+		{Name: "Nick", DatabaseType: "character varying(32)", Nullable: true},
+	}, []string{
+		`CONSTRAINT users_pkey PRIMARY KEY (id)`,
+	}, []string{
+		`CREATE INDEX idx_users_name ON public.users USING btree (name)`,
+	})
+	require.Equal(t, `CREATE TABLE "public"."users" (`+"\n"+
+		`  "id" bigint GENERATED ALWAYS AS IDENTITY NOT NULL,`+"\n"+
+		`  "name" text NOT NULL DEFAULT 'anonymous'::text,`+"\n"+
+		`  "Nick" character varying(32),`+"\n"+
+		`  CONSTRAINT users_pkey PRIMARY KEY (id)`+"\n"+
+		`);`+"\n"+
+		`CREATE INDEX idx_users_name ON public.users USING btree (name);`, ddl)
+}
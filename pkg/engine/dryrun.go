@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// mysqlDryRunPlan
+// count statement of rows affected by INSERT、REPLACE、UPDATE、DELETE, built from the same WHERE、ORDER BY、LIMIT
+// rows of INSERT ... VALUES are counted without query, countSQL is empty
+func mysqlDryRunPlan(sql string) (countSQL string, rows int64, err error) {
+	st, err := vsqlparser.Parse(sql)
+	if err != nil {
+		return "", 0, errors.Wrap(inerr.ErrDryRunUnsupported, err.Error())
+	}
+
+	var inner vsqlparser.SelectStatement
+	switch n := st.(type) {
+	case *vsqlparser.Insert:
+		switch r := n.Rows.(type) {
+		case vsqlparser.Values:
+			return "", int64(len(r)), nil
+		case vsqlparser.SelectStatement:
+			inner = r
+		default:
+			return "", 0, errors.Wrapf(inerr.ErrDryRunUnsupported, "insert rows %T", n.Rows)
+		}
+	case *vsqlparser.Update:
+		inner = dryRunSelect(n.TableExprs, n.Where, n.OrderBy, n.Limit)
+	case *vsqlparser.Delete:
+		inner = dryRunSelect(n.TableExprs, n.Where, n.OrderBy, n.Limit)
+	default:
+		return "", 0, errors.Wrapf(inerr.ErrDryRunUnsupported, "statement %T", st)
+	}
+
+	// count outside of derived table keeps LIMIT of statement
+	return fmt.Sprintf("select count(*) from (%s) as dry_run", vsqlparser.String(inner)), 0, nil
+}
+
+func dryRunSelect(from vsqlparser.TableExprs, where *vsqlparser.Where, orderBy vsqlparser.OrderBy, limit *vsqlparser.Limit) *vsqlparser.Select {
+	return &vsqlparser.Select{
+		SelectExprs: vsqlparser.SelectExprs{&vsqlparser.AliasedExpr{Expr: vsqlparser.NewIntLiteral("1")}},
+		From:        from,
+		Where:       where,
+		OrderBy:     orderBy,
+		Limit:       limit,
+	}
+}
+
+// explainIndexes indexes used by EXPLAIN rows, in order of first use
+func explainIndexes(rows []common.Row) []string {
+	indexes := make([]string, 0)
+	seen := map[string]struct{}{}
+	for _, row := range rows {
+		v, ok := row["key"]
+		if !ok || v == nil {
+			continue
+		}
+
+		key := fmt.Sprint(v)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		indexes = append(indexes, key)
+	}
+	return indexes
+}
+
+// redisCommandKeys keys of redis command, command not known is treated as command with one key
+func redisCommandKeys(cmd []string) []string {
+	if len(cmd) < 2 {
+		return nil
+	}
+
+	args := cmd[1:]
+	switch strings.ToLower(cmd[0]) {
+	case "del", "unlink", "exists", "touch", "mget", "sdiff", "sunion":
+		return args
+	case "mset", "msetnx":
+		keys := make([]string, 0, len(args)/2)
+		for i := 0; i < len(args); i += 2 {
+			keys = append(keys, args[i])
+		}
+		return keys
+	}
+	return args[:1]
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestMySQLDryRunPlan(t *testing.T) {
+	cases := []struct {
+		sql      string
+		countSQL string
+		rows     int64
+	}{
+		{
+			"update orders set amount = 0 where uid = 1 order by id limit 10",
+			"select count(*) from (select 1 from orders where uid = 1 order by id asc limit 10) as dry_run",
+			0,
+		},
+		{
+			"delete from orders where created_at < '2023-01-01'",
+			"select count(*) from (select 1 from orders where created_at < '2023-01-01') as dry_run",
+			0,
+		},
+		{
+			"update orders o join users u on o.uid = u.id set o.amount = 0 where u.name = 'li'",
+			"select count(*) from (select 1 from orders as o join users as u on o.uid = u.id where u.`name` = 'li') as dry_run",
+			0,
+		},
+		{
+			"insert into orders(id) values (1), (2), (3)",
+			"",
+			3,
+		},
+		{
+			"insert into orders_bak select * from orders where id > 1",
+			"select count(*) from (select * from orders where id > 1) as dry_run",
+			0,
+		},
+	}
+
+	for _, c := range cases {
+		countSQL, rows, err := mysqlDryRunPlan(c.sql)
+		require.NoError(t, err, c.sql)
+		require.Equal(t, c.countSQL, countSQL, c.sql)
+		require.Equal(t, c.rows, rows, c.sql)
+	}
+
+	for _, sql := range []string{"alter table orders add column c int", "drop table orders"} {
+		_, _, err := mysqlDryRunPlan(sql)
+		require.ErrorIs(t, err, inerr.ErrDryRunUnsupported, sql)
+	}
+}
+
+func TestExplainIndexes(t *testing.T) {
+	rows := []common.Row{
+		{"table": "o", "key": "idx_uid"},
+		{"table": "u", "key": nil},
+		{"table": "o", "key": "idx_uid"},
+		{"table": "t", "key": "PRIMARY"},
+	}
+	require.Equal(t, []string{"idx_uid", "PRIMARY"}, explainIndexes(rows))
+}
+
+func TestRedisCommandKeys(t *testing.T) {
+	require.Equal(t, []string{"k1", "k2", "k3"}, redisCommandKeys([]string{"DEL", "k1", "k2", "k3"}))
+	require.Equal(t, []string{"k1", "k2"}, redisCommandKeys([]string{"mset", "k1", "v1", "k2", "v2"}))
+	require.Equal(t, []string{"h"}, redisCommandKeys([]string{"hset", "h", "f", "v"}))
+	require.Nil(t, redisCommandKeys([]string{"flushdb"}))
+
+	require.True(t, isRedisReadCMD("GET"))
+	require.False(t, isRedisReadCMD("del"))
+	require.False(t, isRedisReadCMD("flushall"))
+}
@@ -1,16 +1,145 @@
 package engine
 
 import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
 	"github.com/ylh990835774/ay-go-components/pkg/common"
 )
 
+// Engine
+// ctx is usually the context of http request,
+// query is aborted when client disconnect or server shutdown
+// opt carries timeout and page params of the request
 type Engine interface {
-	Schema() ([]string, error)
-	Table(schema string) ([]string, error)
-	Query(schema string, table string, sql string, timeout int64) *common.QuerySet
+	Schema(ctx context.Context) ([]string, error)
+	Table(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error)
+	Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet
 
 	RegistryQueryPrev(common.PreHook)
 	RegistryQueryPost(common.PostHook)
 
 	Close() error
 }
+
+// pageTables
+// slice table names by page params, all names are returned if not paging
+func pageTables(names []string, opt common.QueryOptions) (*common.TableSet, error) {
+	if !opt.IsPaging() {
+		return &common.TableSet{Tables: names}, nil
+	}
+
+	offset, err := opt.PageOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	if offset >= int64(len(names)) {
+		return &common.TableSet{Tables: []string{}}, nil
+	}
+
+	end := offset + opt.PageLimit()
+	if end >= int64(len(names)) {
+		return &common.TableSet{Tables: names[offset:]}, nil
+	}
+
+	return &common.TableSet{
+		Tables:     names[offset:end],
+		NextCursor: common.EncodeOffsetCursor(end),
+	}, nil
+}
+
+// schemaWithHooks
+// run hooks around fetching schema list
+// post hooks can filter the list by modifying PostHookArgs.Names
+func schemaWithHooks(ctx context.Context, base *common.EngineBase, engineType string, fetch func() ([]string, error)) ([]string, error) {
+	return fetchWithHooks(ctx, base, engineType, common.ActionFetchSchema, "", fetch)
+}
+
+// tableWithHooks
+// run hooks around fetching table list of schema, the filtered list is set back to TableSet,
+// types of tables filtered out by hooks are removed too
+func tableWithHooks(ctx context.Context, base *common.EngineBase, engineType string, schema string, fetch func() (*common.TableSet, error)) (*common.TableSet, error) {
+	var tableSet *common.TableSet
+	tables, err := fetchWithHooks(ctx, base, engineType, common.ActionFetchTable, schema, func() ([]string, error) {
+		var err error
+		tableSet, err = fetch()
+		if err != nil {
+			return nil, err
+		}
+		return tableSet.Tables, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tableSet.Tables = tables
+	if tableSet.TableTypes != nil {
+		tableTypes := make(map[string]string, len(tables))
+		for _, name := range tables {
+			if tableType, ok := tableSet.TableTypes[name]; ok {
+				tableTypes[name] = tableType
+			}
+		}
+		tableSet.TableTypes = tableTypes
+	}
+	return tableSet, nil
+}
+
+func fetchWithHooks(ctx context.Context, base *common.EngineBase, engineType string, action string, schema string, fetch func() ([]string, error)) ([]string, error) {
+	// execute prev hooks
+	// prev hook failed, stop fetching
+	err := base.RunPrevHooks(&common.PrevHookArgs{
+		EngineType: engineType,
+		Action:     action,
+		Caller:     common.CallerFromContext(ctx),
+		Schema:     schema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	postArgs := &common.PostHookArgs{
+		EngineType: engineType,
+		Action:     action,
+		Caller:     common.CallerFromContext(ctx),
+		ExecuteAt:  time.Now(),
+		Schema:     schema,
+	}
+
+	names, err := fetch()
+
+	postArgs.QueryDuration = time.Since(postArgs.ExecuteAt).Milliseconds()
+	postArgs.IsExecute = err == nil
+	postArgs.Err = err
+	postArgs.Names = names
+
+	base.RunPostHooks(postArgs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// hook may set nil to hide all names
+	if postArgs.Names == nil {
+		return []string{}, nil
+	}
+	return postArgs.Names, nil
+}
+
+// rewrittenSQL
+// statement to execute after prev hooks,
+// statement rewritten by hooks is checked again by opt.PreCheck
+func rewrittenSQL(args *common.PrevHookArgs, opt common.QueryOptions) (string, error) {
+	if args.SQL == args.OriginalSQL || opt.PreCheck == nil {
+		return args.SQL, nil
+	}
+
+	sql, err := opt.PreCheck(args.SQL)
+	if err != nil {
+		return "", errors.Wrap(err, "rewritten statement preCheck failed")
+	}
+	return sql, nil
+}
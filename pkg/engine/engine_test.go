@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestPageTables(t *testing.T) {
+	names := []string{"t1", "t2", "t3", "t4", "t5"}
+
+	t.Run("not paging", func(t *testing.T) {
+		tableSet, err := pageTables(names, common.QueryOptions{})
+		require.NoError(t, err)
+		require.Equal(t, names, tableSet.Tables)
+		require.Empty(t, tableSet.NextCursor)
+	})
+
+	t.Run("page number", func(t *testing.T) {
+		tableSet, err := pageTables(names, common.QueryOptions{Page: 2, PageSize: 2})
+		require.NoError(t, err)
+		require.Equal(t, []string{"t3", "t4"}, tableSet.Tables)
+		require.Equal(t, common.EncodeOffsetCursor(4), tableSet.NextCursor)
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		tableSet, err := pageTables(names, common.QueryOptions{Cursor: common.EncodeOffsetCursor(4), PageSize: 2})
+		require.NoError(t, err)
+		require.Equal(t, []string{"t5"}, tableSet.Tables)
+		require.Empty(t, tableSet.NextCursor)
+
+		tableSet, err = pageTables(names, common.QueryOptions{Cursor: common.EncodeOffsetCursor(10)})
+		require.NoError(t, err)
+		require.Empty(t, tableSet.Tables)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"invalid", common.EncodeOffsetCursor(-1), "b2Zmc2V0OmE"} {
+			_, err := pageTables(names, common.QueryOptions{Cursor: cursor})
+			require.Equal(t, inerr.ErrCursorInvalid, err, cursor)
+		}
+	})
+}
+
+func TestTableWithHooks(t *testing.T) {
+	base := &common.EngineBase{}
+	base.BindPostHook(func(args *common.PostHookArgs) {
+		args.Names = []string{"orders"}
+	})
+
+	tableSet, err := tableWithHooks(context.Background(), base, common.MySQLEngine, "shop", func() (*common.TableSet, error) {
+		return &common.TableSet{
+			Tables:     []string{"orders", "secrets"},
+			TableTypes: map[string]string{"orders": "BASE TABLE", "secrets": "VIEW"},
+			Procedures: []string{"refund"},
+		}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"orders"}, tableSet.Tables)
+	require.Equal(t, map[string]string{"orders": "BASE TABLE"}, tableSet.TableTypes)
+	require.Equal(t, []string{"refund"}, tableSet.Procedures)
+}
+
+func TestRewrittenSQL(t *testing.T) {
+	preCheck := func(sql string) (string, error) {
+		if sql == "delete from t" {
+			return "", inerr.ErrSQLForbidden
+		}
+		return sql + " limit 100", nil
+	}
+
+	t.Run("not rewritten", func(t *testing.T) {
+		sql, err := rewrittenSQL(&common.PrevHookArgs{SQL: "select 1", OriginalSQL: "select 1"}, common.QueryOptions{PreCheck: preCheck})
+		require.NoError(t, err)
+		require.Equal(t, "select 1", sql)
+	})
+
+	t.Run("rewritten", func(t *testing.T) {
+		sql, err := rewrittenSQL(&common.PrevHookArgs{SQL: "select id from t", OriginalSQL: "select * from t"}, common.QueryOptions{PreCheck: preCheck})
+		require.NoError(t, err)
+		require.Equal(t, "select id from t limit 100", sql)
+	})
+
+	t.Run("rewritten forbidden", func(t *testing.T) {
+		_, err := rewrittenSQL(&common.PrevHookArgs{SQL: "delete from t", OriginalSQL: "select * from t"}, common.QueryOptions{PreCheck: preCheck})
+		require.ErrorIs(t, err, inerr.ErrSQLForbidden)
+	})
+
+	t.Run("system intercept ignored", func(t *testing.T) {
+		sql, err := rewrittenSQL(&common.PrevHookArgs{SQL: "delete from t", OriginalSQL: "select * from t"}, common.QueryOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "delete from t", sql)
+	})
+}
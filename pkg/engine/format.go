@@ -0,0 +1,313 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// fingerprintArg placeholder of literal in fingerprint, replaced by ? after the statement is printed
+const fingerprintArg = "__fingerprint"
+
+// clause keywords start a new line when formatting
+var formatClauseKeywords = map[string]bool{
+	"from":          true,
+	"where":         true,
+	"group":         true,
+	"having":        true,
+	"order":         true,
+	"limit":         true,
+	"union":         true,
+	"join":          true,
+	"straight_join": true,
+	"left":          true,
+	"right":         true,
+	"inner":         true,
+	"cross":         true,
+	"natural":       true,
+	"set":           true,
+	"values":        true,
+}
+
+// join modifiers, join after them is on the same line
+var formatJoinModifiers = map[string]bool{
+	"left":    true,
+	"right":   true,
+	"inner":   true,
+	"cross":   true,
+	"natural": true,
+	"outer":   true,
+}
+
+// FormatSQL
+// pretty-print each statement of sql by vitess sqlparser, keywords are upper case and clauses start a new line,
+// subquery is indented by two spaces, statement can not be parsed fails with inerr.ErrFormatUnsupported
+func FormatSQL(sql string) (*common.FormattedSQL, error) {
+	statements, err := SplitSQLScript(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &common.FormattedSQL{
+		Statements: make([]common.FormattedStatement, 0, len(statements)),
+	}
+	formatted := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		st, err := vsqlparser.ParseStrictDDL(statement)
+		if err != nil {
+			return nil, errors.Wrap(inerr.ErrFormatUnsupported, err.Error())
+		}
+
+		pretty := formatStatement(vsqlparser.String(st), positionalArgs(statement))
+		formatted = append(formatted, pretty)
+		result.Statements = append(result.Statements, common.FormattedStatement{
+			SQL:         pretty,
+			Fingerprint: SQLFingerprint(statement),
+		})
+	}
+
+	result.SQL = strings.Join(formatted, ";\n\n")
+	if len(formatted) > 1 {
+		result.SQL += ";"
+	}
+	return result, nil
+}
+
+type formatToken struct {
+	typ  int
+	gap  string
+	text string
+	word string // lower case keyword, empty if token is not keyword
+}
+
+// positionalArgs names of ? in sql, vitess names them :v1、:v2 ... when parsing
+func positionalArgs(sql string) map[string]bool {
+	args := map[string]bool{}
+
+	tokenizer := vsqlparser.NewStringTokenizer(sql)
+	for {
+		typ, val := tokenizer.Scan()
+		if typ == 0 || typ == vsqlparser.LEX_ERROR {
+			return args
+		}
+		if typ == vsqlparser.VALUE_ARG && sql[tokenizer.Pos-1] == '?' {
+			args[val] = true
+		}
+	}
+}
+
+// formatStatement
+// re-emit tokens of statement printed by vitess, text between tokens is kept except the line break before clauses,
+// positional args are printed as ? again
+func formatStatement(sql string, positional map[string]bool) string {
+	tokens, rest := formatTokens(sql)
+
+	var sb strings.Builder
+	// parenthesis levels, true if the level is a subquery
+	levels := make([]bool, 0)
+	indent := 0
+	for i, token := range tokens {
+		prev, prevPrev, next := "", "", formatToken{}
+		if i > 0 {
+			prev = tokens[i-1].word
+		}
+		if i > 1 {
+			prevPrev = tokens[i-2].word
+		}
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		newLine := false
+		switch {
+		case token.word == "select":
+			newLine = prev == "union" || (prevPrev == "union" && (prev == "all" || prev == "distinct"))
+		case !formatClauseKeywords[token.word] || (len(levels) > 0 && !levels[len(levels)-1]):
+		case next.typ == '(' && next.gap == "":
+			// function call, eg: left(name, 2)、values(col)
+		case token.word == "join":
+			newLine = !formatJoinModifiers[prev]
+		case formatJoinModifiers[token.word]:
+			newLine = !formatJoinModifiers[prev] && (next.word == "join" || next.word == "outer")
+		case token.word == "set":
+			newLine = prev != "character"
+		default:
+			newLine = true
+		}
+
+		if newLine && sb.Len() > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(strings.Repeat("  ", indent))
+		} else {
+			sb.WriteString(token.gap)
+		}
+		if token.typ == vsqlparser.VALUE_ARG && positional[token.text] {
+			token.text = "?"
+		}
+		sb.WriteString(token.text)
+
+		switch {
+		case token.typ == '(':
+			// subquery is known by the next token
+			levels = append(levels, next.word == "select")
+			if next.word == "select" {
+				indent++
+			}
+		case token.typ == ')' && len(levels) > 0:
+			if levels[len(levels)-1] {
+				indent--
+			}
+			levels = levels[:len(levels)-1]
+		}
+	}
+	sb.WriteString(rest)
+
+	return sb.String()
+}
+
+// formatTokens tokens of sql with the blanks before them, text can not be tokenized is returned as rest
+func formatTokens(sql string) ([]formatToken, string) {
+	tokens := make([]formatToken, 0)
+
+	tokenizer := vsqlparser.NewStringTokenizer(sql)
+	end := 0
+	for {
+		typ, _ := tokenizer.Scan()
+		if typ == 0 || typ == vsqlparser.LEX_ERROR {
+			return tokens, sql[end:]
+		}
+
+		start := end
+		for start < tokenizer.Pos && isFormatBlank(sql[start]) {
+			start++
+		}
+		token := formatToken{typ: typ, gap: sql[end:start], text: sql[start:tokenizer.Pos]}
+		end = tokenizer.Pos
+
+		if typ != vsqlparser.ID && isFormatWord(token.text) {
+			token.word = strings.ToLower(token.text)
+			token.text = strings.ToUpper(token.text)
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func isFormatBlank(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func isFormatWord(text string) bool {
+	if text == "" {
+		return false
+	}
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		if b != '_' && !(b >= 'a' && b <= 'z') && !(b >= 'A' && b <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// SQLFingerprint
+// shape of statement for grouping executions, literals and bind args are replaced by ?, IN list is folded into (?),
+// rows of INSERT ... VALUES are folded into one row and comments are dropped,
+// statement vitess can not parse is fingerprinted by tokens, empty if sql can not be tokenized
+func SQLFingerprint(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return ""
+	}
+
+	st, err := vsqlparser.ParseStrictDDL(sql)
+	if err != nil {
+		return tokenFingerprint(sql)
+	}
+
+	st = vsqlparser.Rewrite(st, func(cursor *vsqlparser.Cursor) bool {
+		switch n := cursor.Node().(type) {
+		case vsqlparser.Comments:
+			if n != nil {
+				cursor.Replace(vsqlparser.Comments(nil))
+			}
+		case *vsqlparser.Insert:
+			if rows, ok := n.Rows.(vsqlparser.Values); ok && len(rows) > 1 {
+				n.Rows = rows[:1]
+			}
+		case *vsqlparser.ComparisonExpr:
+			if n.Operator == vsqlparser.InOp || n.Operator == vsqlparser.NotInOp {
+				if _, ok := n.Right.(vsqlparser.ValTuple); ok {
+					n.Right = vsqlparser.ListArg(fingerprintArg)
+				}
+			}
+		case *vsqlparser.Literal, vsqlparser.Argument:
+			cursor.Replace(vsqlparser.Argument(fingerprintArg))
+		}
+		return true
+	}, nil).(vsqlparser.Statement)
+
+	fingerprint := vsqlparser.String(st)
+	fingerprint = strings.ReplaceAll(fingerprint, "::"+fingerprintArg, "(?)")
+	fingerprint = strings.ReplaceAll(fingerprint, ":"+fingerprintArg, "?")
+	return fingerprint
+}
+
+// SQLiteFingerprint
+// same as SQLFingerprint, but "..." is identifier in sqlite, so it is quoted by backtick before fingerprinting
+func SQLiteFingerprint(sql string) string {
+	tokens, rest := formatTokens(sql)
+
+	// text of keyword token is upper case, original text is taken from sql
+	var sb strings.Builder
+	offset := 0
+	for _, token := range tokens {
+		offset += len(token.gap)
+		text := sql[offset : offset+len(token.text)]
+		offset += len(token.text)
+
+		sb.WriteString(token.gap)
+		if token.typ == vsqlparser.STRING && strings.HasPrefix(text, `"`) {
+			name := strings.ReplaceAll(text[1:len(text)-1], `""`, `"`)
+			sb.WriteString("`" + strings.ReplaceAll(name, "`", "``") + "`")
+			continue
+		}
+		sb.WriteString(text)
+	}
+	sb.WriteString(rest)
+
+	return SQLFingerprint(sb.String())
+}
+
+// tokenFingerprint fingerprint by vitess tokenizer, keywords are lower case and blanks are folded into one space
+func tokenFingerprint(sql string) string {
+	tokens, rest := formatTokens(sql)
+	if strings.TrimSpace(rest) != "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, token := range tokens {
+		text := token.text
+		switch token.typ {
+		case vsqlparser.COMMENT:
+			continue
+		case vsqlparser.STRING, vsqlparser.INTEGRAL, vsqlparser.FLOAT, vsqlparser.HEX, vsqlparser.HEXNUM,
+			vsqlparser.BIT_LITERAL, vsqlparser.VALUE_ARG:
+			text = "?"
+		default:
+			if token.word != "" {
+				text = token.word
+			}
+		}
+
+		if token.gap != "" && sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(text)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestFormatSQL(t *testing.T) {
+	cases := []struct {
+		sql         string
+		formatted   string
+		fingerprint string
+	}{
+		{
+			sql: "select a, count(*) c from t1 left join t2 on t1.id=t2.id where a in (1,2,3) and b='x' group by a order by c desc limit 10",
+			formatted: "SELECT a, count(*) AS c\n" +
+				"FROM t1\n" +
+				"LEFT JOIN t2 ON t1.id = t2.id\n" +
+				"WHERE a IN (1, 2, 3) AND b = 'x'\n" +
+				"GROUP BY a\n" +
+				"ORDER BY c DESC\n" +
+				"LIMIT 10",
+			fingerprint: "select a, count(*) as c from t1 left join t2 on t1.id = t2.id where a in (?) and b = ? group by a order by c desc limit ?",
+		},
+		{
+			sql: "select id from users where id in (select uid from orders where amount > 100)",
+			formatted: "SELECT id\n" +
+				"FROM users\n" +
+				"WHERE id IN (SELECT uid\n" +
+				"  FROM orders\n" +
+				"  WHERE amount > 100)",
+			fingerprint: "select id from users where id in (select uid from orders where amount > ?)",
+		},
+		{
+			sql: "select left(name, 2) from t union all select b from u",
+			formatted: "SELECT LEFT(`name`, 2)\n" +
+				"FROM t\n" +
+				"UNION ALL\n" +
+				"SELECT b\n" +
+				"FROM u",
+			fingerprint: "select left(`name`, ?) from t union all select b from u",
+		},
+		{
+			sql: "update t set a=1, b = 'q' where id = ?",
+			formatted: "UPDATE t\n" +
+				"SET a = 1, b = 'q'\n" +
+				"WHERE id = ?",
+			fingerprint: "update t set a = ?, b = ? where id = ?",
+		},
+		{
+			sql: "insert into t(a,b) values (1,'x'),(2,'y')",
+			formatted: "INSERT INTO t(a, b)\n" +
+				"VALUES (1, 'x'), (2, 'y')",
+			fingerprint: "insert into t(a, b) values (?, ?)",
+		},
+		{
+			sql: "/* audit */ delete from t where id = :id",
+			formatted: "DELETE\n" +
+				"FROM t\n" +
+				"WHERE id = :id",
+			fingerprint: "delete from t where id = ?",
+		},
+	}
+
+	for _, c := range cases {
+		result, err := FormatSQL(c.sql)
+		require.NoError(t, err, c.sql)
+		require.Equal(t, c.formatted, result.SQL, c.sql)
+		require.Len(t, result.Statements, 1)
+		require.Equal(t, c.formatted, result.Statements[0].SQL)
+		require.Equal(t, c.fingerprint, result.Statements[0].Fingerprint, c.sql)
+	}
+
+	// script is formatted statement by statement
+	result, err := FormatSQL("select * from t where id = 1; select * from t where id = 2")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT *\nFROM t\nWHERE id = 1;\n\nSELECT *\nFROM t\nWHERE id = 2;", result.SQL)
+	require.Len(t, result.Statements, 2)
+	require.Equal(t, result.Statements[0].Fingerprint, result.Statements[1].Fingerprint)
+
+	_, err = FormatSQL("select from where")
+	require.ErrorIs(t, err, inerr.ErrFormatUnsupported)
+
+	_, err = FormatSQL(" ; ")
+	require.ErrorIs(t, err, inerr.ErrSQLEmpty)
+}
+
+func TestSQLFingerprint(t *testing.T) {
+	require.Equal(t, "", SQLFingerprint(" "))
+	require.Equal(t,
+		SQLFingerprint("SELECT * FROM users WHERE id IN (1, 2, 3) AND name = 'a'"),
+		SQLFingerprint("select *   from users where id in (4) and name = \"b\""),
+	)
+	require.NotEqual(t, SQLFingerprint("select * from users where id = 1"), SQLFingerprint("select * from orders where id = 1"))
+
+	// statement vitess can not parse is fingerprinted by tokens
+	require.Equal(t, "select x::int from t where a = ? and b = ?", SQLFingerprint("SELECT x::int FROM t\nWHERE a = 'x' AND b = 10"))
+}
+
+func TestSQLiteFingerprint(t *testing.T) {
+	// "..." is identifier, not string literal
+	require.NotEqual(t,
+		SQLiteFingerprint(`select "name" from users where id = 1`),
+		SQLiteFingerprint(`select "email" from users where id = 2`),
+	)
+	require.Equal(t,
+		SQLiteFingerprint(`select "name" from users where id = 1`),
+		SQLiteFingerprint("select name from users where id = 2"),
+	)
+	require.Equal(t, "select `order` from t where a = ? and b in (?)", SQLiteFingerprint(`SELECT "order" FROM t WHERE a = 'x' AND b IN (1, 2)`))
+	require.Equal(t, "select `a\"b` from t", SQLiteFingerprint(`select "a""b" from t`))
+}
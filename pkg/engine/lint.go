@@ -0,0 +1,374 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// name of builtin lint rules
+const (
+	LintRuleNoWhere           = "no-where"
+	LintRuleSelectStar        = "select-star"
+	LintRuleCartesianJoin     = "cartesian-join"
+	LintRuleLeadingWildcard   = "leading-wildcard-like"
+	LintRuleOrderByRand       = "order-by-rand"
+	LintRuleLargeTableDDL     = "large-table-ddl"
+	LintRuleDangerousFunction = "dangerous-function"
+	// issue of statement can not be parsed
+	LintRuleUnparsed = "unparsed"
+)
+
+// DefaultDangerousFunctions functions rejected by LintDangerousFunction if not set
+var DefaultDangerousFunctions = []string{"sleep", "benchmark", "load_file", "get_lock", "release_lock", "sys_exec", "sys_eval"}
+
+// DefaultLintRules
+// builtin rules with default thresholds, level of rule can be changed by caller
+func DefaultLintRules() []common.LintRule {
+	return []common.LintRule{
+		LintNoWhere(),
+		LintSelectStar(50),
+		LintCartesianJoin(),
+		LintLeadingWildcard(),
+		LintOrderByRand(),
+		LintLargeTableDDL(1000000),
+		LintDangerousFunction(),
+	}
+}
+
+// LintNoWhere update or delete statement without where clause
+func LintNoWhere() common.LintRule {
+	return common.LintRule{
+		Name:  LintRuleNoWhere,
+		Level: common.LintLevelError,
+		Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+			msgs := make([]string, 0)
+			_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+				switch n := node.(type) {
+				case *vsqlparser.Update:
+					if n.Where == nil {
+						msgs = append(msgs, "update statement without where clause")
+					}
+				case *vsqlparser.Delete:
+					if n.Where == nil {
+						msgs = append(msgs, "delete statement without where clause")
+					}
+				}
+				return true, nil
+			}, st)
+			return msgs
+		},
+	}
+}
+
+// LintSelectStar
+// select * on table with more than maxColumns columns,
+// every select * is reported when maxColumns <= 0
+func LintSelectStar(maxColumns int) common.LintRule {
+	return common.LintRule{
+		Name:  LintRuleSelectStar,
+		Level: common.LintLevelWarn,
+		Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+			msgs := make([]string, 0)
+			_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+				sel, ok := node.(*vsqlparser.Select)
+				if !ok {
+					return true, nil
+				}
+
+				for _, expr := range sel.SelectExprs {
+					star, ok := expr.(*vsqlparser.StarExpr)
+					if !ok {
+						continue
+					}
+
+					if maxColumns <= 0 {
+						msgs = append(msgs, "select * is used")
+						continue
+					}
+
+					for _, tb := range starTables(star, sel.From) {
+						stats := lintTableStats(lc, tb)
+						if stats != nil && stats.Columns > maxColumns {
+							msgs = append(msgs, fmt.Sprintf("select * on table %s with %d columns", vsqlparser.String(tb), stats.Columns))
+						}
+					}
+				}
+				return true, nil
+			}, st)
+			return msgs
+		},
+	}
+}
+
+// LintCartesianJoin join without condition, or tables separated by comma without where clause
+func LintCartesianJoin() common.LintRule {
+	return common.LintRule{
+		Name:  LintRuleCartesianJoin,
+		Level: common.LintLevelWarn,
+		Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+			msgs := make([]string, 0)
+			_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+				switch n := node.(type) {
+				case *vsqlparser.Select:
+					if len(n.From) > 1 && n.Where == nil {
+						msgs = append(msgs, "tables are joined without where clause")
+					}
+				case *vsqlparser.JoinTableExpr:
+					if (n.Join == vsqlparser.NormalJoinType || n.Join == vsqlparser.StraightJoinType) &&
+						n.Condition.On == nil && len(n.Condition.Using) == 0 {
+						msgs = append(msgs, fmt.Sprintf("join without condition: %s", vsqlparser.String(n)))
+					}
+				}
+				return true, nil
+			}, st)
+			return msgs
+		},
+	}
+}
+
+// LintLeadingWildcard like pattern start with wildcard can not use index
+func LintLeadingWildcard() common.LintRule {
+	return common.LintRule{
+		Name:  LintRuleLeadingWildcard,
+		Level: common.LintLevelWarn,
+		Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+			msgs := make([]string, 0)
+			_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+				cmp, ok := node.(*vsqlparser.ComparisonExpr)
+				if !ok || (cmp.Operator != vsqlparser.LikeOp && cmp.Operator != vsqlparser.NotLikeOp) {
+					return true, nil
+				}
+
+				lit, ok := cmp.Right.(*vsqlparser.Literal)
+				if ok && lit.Type == vsqlparser.StrVal && (strings.HasPrefix(lit.Val, "%") || strings.HasPrefix(lit.Val, "_")) {
+					msgs = append(msgs, fmt.Sprintf("like pattern start with wildcard: %s", vsqlparser.String(cmp)))
+				}
+				return true, nil
+			}, st)
+			return msgs
+		},
+	}
+}
+
+// LintOrderByRand order by rand() sort all rows
+func LintOrderByRand() common.LintRule {
+	return common.LintRule{
+		Name:  LintRuleOrderByRand,
+		Level: common.LintLevelWarn,
+		Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+			msgs := make([]string, 0)
+			_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+				order, ok := node.(*vsqlparser.Order)
+				if !ok {
+					return true, nil
+				}
+
+				if fn, ok := order.Expr.(*vsqlparser.FuncExpr); ok && fn.Name.Lowered() == "rand" {
+					msgs = append(msgs, "order by rand()")
+				}
+				return true, nil
+			}, st)
+			return msgs
+		},
+	}
+}
+
+// LintLargeTableDDL alter、drop、truncate、rename table with more than maxRows rows
+func LintLargeTableDDL(maxRows int64) common.LintRule {
+	return common.LintRule{
+		Name:  LintRuleLargeTableDDL,
+		Level: common.LintLevelError,
+		Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+			tables := make([]vsqlparser.TableName, 0)
+			switch n := st.(type) {
+			case *vsqlparser.AlterTable:
+				tables = append(tables, n.Table)
+			case *vsqlparser.DropTable:
+				tables = append(tables, n.FromTables...)
+			case *vsqlparser.TruncateTable:
+				tables = append(tables, n.Table)
+			case *vsqlparser.RenameTable:
+				for _, pair := range n.TablePairs {
+					tables = append(tables, pair.FromTable)
+				}
+			}
+
+			msgs := make([]string, 0)
+			for _, tb := range tables {
+				stats := lintTableStats(lc, tb)
+				if stats != nil && stats.Rows > maxRows {
+					msgs = append(msgs, fmt.Sprintf("ddl on table %s with about %d rows", vsqlparser.String(tb), stats.Rows))
+				}
+			}
+			return msgs
+		},
+	}
+}
+
+// LintDangerousFunction call of functions, DefaultDangerousFunctions if names not set
+func LintDangerousFunction(names ...string) common.LintRule {
+	if len(names) == 0 {
+		names = DefaultDangerousFunctions
+	}
+
+	funcs := map[string]struct{}{}
+	for _, name := range names {
+		funcs[strings.ToLower(name)] = struct{}{}
+	}
+
+	return common.LintRule{
+		Name:  LintRuleDangerousFunction,
+		Level: common.LintLevelError,
+		Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+			msgs := make([]string, 0)
+			_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+				fn, ok := node.(*vsqlparser.FuncExpr)
+				if !ok {
+					return true, nil
+				}
+
+				if _, ok := funcs[fn.Name.Lowered()]; ok {
+					msgs = append(msgs, fmt.Sprintf("function %s is not allowed", fn.Name.Lowered()))
+				}
+				return true, nil
+			}, st)
+			return msgs
+		},
+	}
+}
+
+// lintStatement
+// run lint rules on statement, issues of all rules are returned
+// ErrSQLLintRejected is returned when rule of error level is violated
+// statement can not be parsed is rejected if any rule of error level is set, same as row policies, otherwise not checked
+func lintStatement(sql string, rules []common.LintRule, lc *common.LintContext) ([]common.LintIssue, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	st, err := vsqlparser.Parse(sql)
+	if err != nil {
+		for _, rule := range rules {
+			if rule.Check != nil && rule.Level != common.LintLevelWarn {
+				return []common.LintIssue{{
+					Rule:    LintRuleUnparsed,
+					Level:   common.LintLevelError,
+					Message: fmt.Sprintf("statement can not be parsed: %s", err),
+				}}, inerr.ErrSQLLintRejected
+			}
+		}
+		return nil, nil
+	}
+
+	issues := make([]common.LintIssue, 0)
+	rejected := false
+	for _, rule := range rules {
+		if rule.Check == nil {
+			continue
+		}
+
+		level := rule.Level
+		if level != common.LintLevelWarn {
+			level = common.LintLevelError
+		}
+
+		for _, msg := range runLintRule(rule, st, lc) {
+			issues = append(issues, common.LintIssue{
+				Rule:    rule.Name,
+				Level:   level,
+				Message: msg,
+			})
+			rejected = rejected || level == common.LintLevelError
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil, nil
+	}
+
+	if rejected {
+		return issues, inerr.ErrSQLLintRejected
+	}
+	return issues, nil
+}
+
+// runLintRule panic of rule is reported as violation
+func runLintRule(rule common.LintRule, st vsqlparser.Statement, lc *common.LintContext) (msgs []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msgs = []string{fmt.Sprintf("lint rule panic: %v", r)}
+		}
+	}()
+
+	return rule.Check(st, lc)
+}
+
+// newLintContext statistics of table are fetched once for a statement
+func newLintContext(ctx context.Context, schema string, fetch func(ctx context.Context, schema string, table string) (*common.TableStats, error)) *common.LintContext {
+	cache := map[string]*common.TableStats{}
+	return &common.LintContext{
+		Schema: schema,
+		TableStats: func(schema string, table string) (*common.TableStats, error) {
+			key := schema + "." + table
+			if stats, ok := cache[key]; ok {
+				return stats, nil
+			}
+
+			stats, err := fetch(ctx, schema, table)
+			if err != nil {
+				return nil, err
+			}
+
+			cache[key] = stats
+			return stats, nil
+		},
+	}
+}
+
+// lintTableStats statistics of table, nil if not available
+func lintTableStats(lc *common.LintContext, tb vsqlparser.TableName) *common.TableStats {
+	if lc == nil || lc.TableStats == nil || tb.Name.IsEmpty() {
+		return nil
+	}
+
+	schema := lc.Schema
+	if !tb.Qualifier.IsEmpty() {
+		schema = tb.Qualifier.String()
+	}
+
+	stats, err := lc.TableStats(schema, tb.Name.String())
+	if err != nil {
+		return nil
+	}
+	return stats
+}
+
+// starTables tables expanded by star expression, alias of table is resolved
+func starTables(star *vsqlparser.StarExpr, from vsqlparser.TableExprs) []vsqlparser.TableName {
+	tables := make([]vsqlparser.TableName, 0)
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *vsqlparser.DerivedTable:
+			return false, nil
+		case *vsqlparser.AliasedTableExpr:
+			tb, ok := n.Expr.(vsqlparser.TableName)
+			if !ok {
+				return true, nil
+			}
+
+			if star.TableName.IsEmpty() ||
+				(!n.As.IsEmpty() && n.As.String() == star.TableName.Name.String()) ||
+				(n.As.IsEmpty() && tb.Name.String() == star.TableName.Name.String()) {
+				tables = append(tables, tb)
+			}
+		}
+		return true, nil
+	}, from)
+
+	return tables
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestLintRules(t *testing.T) {
+	lc := &common.LintContext{
+		Schema: "test",
+		TableStats: func(schema string, table string) (*common.TableStats, error) {
+			if table == "wide" {
+				return &common.TableStats{Rows: 10, Columns: 100}, nil
+			}
+			if table == "big" {
+				return &common.TableStats{Rows: 5000000, Columns: 3}, nil
+			}
+			return &common.TableStats{Rows: 10, Columns: 3}, nil
+		},
+	}
+
+	cases := []struct {
+		rule     common.LintRule
+		violated []string
+		passed   []string
+	}{
+		{
+			LintNoWhere(),
+			[]string{"update t set a = 1", "delete from t"},
+			[]string{"update t set a = 1 where id = 1", "delete from t where id = 1"},
+		},
+		{
+			LintSelectStar(50),
+			[]string{"select * from wide", "select w.* from wide w join t on w.id = t.id"},
+			[]string{"select * from t", "select t.* from wide w join t on w.id = t.id", "select id from wide"},
+		},
+		{
+			LintCartesianJoin(),
+			[]string{"select * from a, b", "select * from a join b"},
+			[]string{"select * from a, b where a.id = b.id", "select * from a join b on a.id = b.id", "select * from a join b using (id)"},
+		},
+		{
+			LintLeadingWildcard(),
+			[]string{"select * from t where name like '%a'", "select * from t where name not like '_a'"},
+			[]string{"select * from t where name like 'a%'"},
+		},
+		{
+			LintOrderByRand(),
+			[]string{"select * from t order by rand() limit 1"},
+			[]string{"select * from t order by id"},
+		},
+		{
+			LintLargeTableDDL(1000000),
+			[]string{"alter table big add column c int", "drop table t, big", "truncate table big"},
+			[]string{"alter table t add column c int", "drop table t"},
+		},
+		{
+			LintDangerousFunction(),
+			[]string{"select sleep(10)", "select * from t where id = 1 and sleep(3)", "select load_file('/etc/passwd')"},
+			[]string{"select now()"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.rule.Name, func(t *testing.T) {
+			for _, sql := range c.violated {
+				st, err := vsqlparser.Parse(sql)
+				require.NoError(t, err, sql)
+				require.NotEmpty(t, c.rule.Check(st, lc), sql)
+			}
+
+			for _, sql := range c.passed {
+				st, err := vsqlparser.Parse(sql)
+				require.NoError(t, err, sql)
+				require.Empty(t, c.rule.Check(st, lc), sql)
+			}
+		})
+	}
+}
+
+func TestLintStatement(t *testing.T) {
+	rules := []common.LintRule{
+		LintLeadingWildcard(),
+		LintDangerousFunction(),
+		{
+			Name: "panic",
+			Check: func(st vsqlparser.Statement, lc *common.LintContext) []string {
+				if _, ok := st.(*vsqlparser.Delete); ok {
+					panic("boom")
+				}
+				return nil
+			},
+		},
+	}
+
+	issues, err := lintStatement("select * from t", rules, nil)
+	require.NoError(t, err)
+	require.Nil(t, issues)
+
+	issues, err = lintStatement("select * from t where title like '%a'", rules, nil)
+	require.NoError(t, err)
+	require.Equal(t, []common.LintIssue{{
+		Rule:    LintRuleLeadingWildcard,
+		Level:   common.LintLevelWarn,
+		Message: "like pattern start with wildcard: title like '%a'",
+	}}, issues)
+
+	issues, err = lintStatement("select sleep(1) from t where title like '%a'", rules, nil)
+	require.ErrorIs(t, err, inerr.ErrSQLLintRejected)
+	require.Len(t, issues, 2)
+
+	// level of rule is error if not set, panic is reported as violation
+	issues, err = lintStatement("delete from t", rules, nil)
+	require.ErrorIs(t, err, inerr.ErrSQLLintRejected)
+	require.Equal(t, common.LintLevelError, issues[0].Level)
+	require.Contains(t, issues[0].Message, "boom")
+
+	// statement can not be parsed is rejected by rule of error level
+	issues, err = lintStatement("select sleep(1) from", rules, nil)
+	require.ErrorIs(t, err, inerr.ErrSQLLintRejected)
+	require.Equal(t, LintRuleUnparsed, issues[0].Rule)
+
+	issues, err = lintStatement("select sleep(1) from", []common.LintRule{LintLeadingWildcard()}, nil)
+	require.NoError(t, err)
+	require.Nil(t, issues)
+}
+
+func TestSQLiteTableStats(t *testing.T) {
+	eg, err := ForkSQLiteEngine(common.ConnConfig{FilePath: filepath.Join(t.TempDir(), "stats.db")})
+	require.NoError(t, err)
+	defer eg.Close()
+
+	ctx := context.Background()
+	for _, sql := range []string{
+		"create table orders (id integer primary key, uid integer, amount real)",
+		"create index idx_orders_uid on orders(uid)",
+		"insert into orders(uid, amount) values (1, 1.5), (2, 2.5), (3, 3.5)",
+	} {
+		require.NoError(t, eg.Query(ctx, "main", "", sql, common.QueryOptions{Timeout: 5}).Err, sql)
+	}
+
+	// rows are not counted before table is analyzed
+	stats, err := eg.tableStats(ctx, "main", "orders")
+	require.NoError(t, err)
+	require.Equal(t, &common.TableStats{Columns: 3}, stats)
+
+	require.NoError(t, eg.driver.Exec("analyze").Error)
+	stats, err = eg.tableStats(ctx, "main", "orders")
+	require.NoError(t, err)
+	require.Equal(t, &common.TableStats{Columns: 3, Rows: 3}, stats)
+}
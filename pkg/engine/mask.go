@@ -0,0 +1,440 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// redis commands return metadata or key names instead of values, result is not masked
+var redisMetaCMD = map[string]struct{}{
+	"type":    {},
+	"ttl":     {},
+	"pttl":    {},
+	"exists":  {},
+	"strlen":  {},
+	"hlen":    {},
+	"hexists": {},
+	"hkeys":   {},
+	"llen":    {},
+	"scard":   {},
+	"zcard":   {},
+	"object":  {},
+	"keys":    {},
+	"scan":    {},
+}
+
+type maskTable struct {
+	schema string
+	name   string
+}
+
+// maskSource
+// tables and source columns of result columns parsed from statement
+// tables is nil when statement can not be parsed, rules are matched by schema and column only
+type maskSource struct {
+	schema string
+	tables []maskTable
+
+	// source columns of result column by position, nil when select has star expression
+	positions map[int][]string
+	// source columns of aliased expression, key is lower case alias
+	aliases map[string][]string
+	// source of some result column is unknown, eg: branch of union has star expression,
+	// every result column is masked by the first rule matches tables
+	unresolved bool
+}
+
+// sqlMaskSource
+// parse tables and source columns from statement,
+// so masked column can not be read by alias、expression or union, eg: select concat(phone) as p
+// result column of union is named by the first branch, it takes source columns of every branch
+func sqlMaskSource(sql string, schema string) *maskSource {
+	src := &maskSource{schema: schema}
+
+	st, err := vsqlparser.Parse(sql)
+	if err != nil {
+		return src
+	}
+
+	src.tables = make([]maskTable, 0)
+	src.aliases = map[string][]string{}
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case vsqlparser.TableName:
+			if n.Name.IsEmpty() {
+				break
+			}
+
+			tbSchema := schema
+			if !n.Qualifier.IsEmpty() {
+				tbSchema = n.Qualifier.String()
+			}
+			src.tables = append(src.tables, maskTable{schema: tbSchema, name: n.Name.String()})
+		case *vsqlparser.AliasedExpr:
+			if !n.As.IsEmpty() {
+				alias := strings.ToLower(n.As.String())
+				src.aliases[alias] = append(src.aliases[alias], exprColumns(n.Expr)...)
+			}
+		case *vsqlparser.Union:
+			// union in derived table is read by names of the first branch
+			branches := selectBranches(n)
+			positions, ok := branchPositions(branches)
+			if !ok {
+				src.unresolved = true
+				break
+			}
+			for i, expr := range branches[0].SelectExprs {
+				if name := resultName(expr); name != "" {
+					src.aliases[name] = append(src.aliases[name], positions[i]...)
+				}
+			}
+		}
+		return true, nil
+	}, st)
+
+	if branches := selectBranches(st); branches != nil {
+		positions, ok := branchPositions(branches)
+		src.positions = positions
+		src.unresolved = src.unresolved || !ok
+	}
+
+	return src
+}
+
+// selectBranches selects of statement, branches of union and parenthesized select are flattened, nil if not select
+func selectBranches(node vsqlparser.SQLNode) []*vsqlparser.Select {
+	switch n := node.(type) {
+	case *vsqlparser.Select:
+		return []*vsqlparser.Select{n}
+	case *vsqlparser.ParenSelect:
+		return selectBranches(n.Select)
+	case *vsqlparser.Union:
+		branches := selectBranches(n.FirstStatement)
+		for _, us := range n.UnionSelects {
+			branches = append(branches, selectBranches(us.Statement)...)
+		}
+		return branches
+	}
+	return nil
+}
+
+// branchPositions
+// source columns of result column by position, columns of every branch at the same position are merged,
+// positions is nil when select has star expression, columns of star keep their names so single select is resolved
+// if other expressions are named by their columns, false if source of some result column is unknown
+func branchPositions(branches []*vsqlparser.Select) (map[int][]string, bool) {
+	positions := map[int][]string{}
+	for _, sel := range branches {
+		for i, expr := range sel.SelectExprs {
+			aliased, ok := expr.(*vsqlparser.AliasedExpr)
+			if !ok {
+				if len(branches) > 1 {
+					return nil, false
+				}
+				return nil, starResolved(sel.SelectExprs)
+			}
+			positions[i] = append(positions[i], exprColumns(aliased.Expr)...)
+		}
+	}
+	return positions, true
+}
+
+// starResolved expressions besides star are aliased, plain column or use no column
+func starResolved(exprs vsqlparser.SelectExprs) bool {
+	for _, expr := range exprs {
+		aliased, ok := expr.(*vsqlparser.AliasedExpr)
+		if !ok || !aliased.As.IsEmpty() {
+			continue
+		}
+		if _, ok = aliased.Expr.(*vsqlparser.ColName); !ok && len(exprColumns(aliased.Expr)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// resultName lower case name of result column, empty for star expression
+func resultName(expr vsqlparser.SelectExpr) string {
+	aliased, ok := expr.(*vsqlparser.AliasedExpr)
+	if !ok {
+		return ""
+	}
+	if !aliased.As.IsEmpty() {
+		return strings.ToLower(aliased.As.String())
+	}
+	if col, ok := aliased.Expr.(*vsqlparser.ColName); ok {
+		return strings.ToLower(col.Name.String())
+	}
+	return strings.ToLower(vsqlparser.String(aliased.Expr))
+}
+
+func exprColumns(expr vsqlparser.Expr) []string {
+	cols := make([]string, 0)
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		if col, ok := node.(*vsqlparser.ColName); ok {
+			cols = append(cols, col.Name.String())
+		}
+		return true, nil
+	}, expr)
+
+	return cols
+}
+
+// columnRule first rule matches result column, nil if no rule matches
+func (src *maskSource) columnRule(rules []common.MaskRule, idx int, column string) *common.MaskRule {
+	candidates := []string{column}
+	candidates = append(candidates, src.positions[idx]...)
+	candidates = append(candidates, src.aliases[strings.ToLower(column)]...)
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Column == "" || !src.matchTable(rule) {
+			continue
+		}
+
+		for _, c := range candidates {
+			if maskMatch(rule.Column, c) {
+				return rule
+			}
+		}
+	}
+
+	if src.unresolved {
+		for i := range rules {
+			if rules[i].Column != "" && src.matchTable(&rules[i]) {
+				return &rules[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (src *maskSource) matchTable(rule *common.MaskRule) bool {
+	if src.tables == nil {
+		return maskMatch(rule.Schema, src.schema)
+	}
+
+	for _, tb := range src.tables {
+		if maskMatch(rule.Schema, tb.schema) && maskMatch(rule.Table, tb.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSQLRows apply mask rules to rows of sql statement result
+func maskSQLRows(queryRes *common.QuerySet, rules []common.MaskRule, sql string, schema string) {
+	if len(rules) == 0 {
+		return
+	}
+
+	maskQueryRows(queryRes, rules, sqlMaskSource(sql, schema))
+}
+
+func maskQueryRows(queryRes *common.QuerySet, rules []common.MaskRule, src *maskSource) {
+	colRules := columnMaskRules(queryRes, rules, src)
+	for _, row := range queryRes.Rows {
+		maskRow(row, queryRes.Columns, colRules)
+	}
+}
+
+// columnMaskRules
+// rule of each result column, nil if column is not masked, column with rule is marked as masked in column types
+func columnMaskRules(queryRes *common.QuerySet, rules []common.MaskRule, src *maskSource) []*common.MaskRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	colRules := make([]*common.MaskRule, len(queryRes.Columns))
+	for i, col := range queryRes.Columns {
+		rule := src.columnRule(rules, i, col)
+		if rule == nil {
+			continue
+		}
+
+		colRules[i] = rule
+		if i < len(queryRes.ColumnTypes) {
+			queryRes.ColumnTypes[i].Masked = true
+		}
+	}
+	return colRules
+}
+
+// maskRow apply rules of columns to a single row
+func maskRow(row common.Row, cols []string, colRules []*common.MaskRule) {
+	for i, rule := range colRules {
+		if rule == nil {
+			continue
+		}
+		if v, ok := row[cols[i]]; ok {
+			row[cols[i]] = maskValue(v, rule)
+		}
+	}
+}
+
+// maskRedisResult
+// apply mask rules to result of redis command, cmd is the tokens of command
+// field of value is known by HGET、HMGET、HGETALL, rules with Field are applied to other commands of the key too
+func maskRedisResult(res interface{}, cmd []string, rules []common.MaskRule) interface{} {
+	if len(rules) == 0 || len(cmd) < 2 {
+		return res
+	}
+
+	name := strings.ToLower(cmd[0])
+	if _, ok := redisMetaCMD[name]; ok {
+		return res
+	}
+
+	key := cmd[1]
+	switch name {
+	case "mget":
+		values, ok := res.([]interface{})
+		if !ok {
+			break
+		}
+		for i := range values {
+			if i+1 < len(cmd) {
+				values[i] = maskRedisValue(values[i], redisRule(rules, cmd[i+1], "", false))
+			}
+		}
+		return values
+	case "hget":
+		if len(cmd) >= 3 {
+			return maskRedisValue(res, redisRule(rules, key, cmd[2], true))
+		}
+	case "hmget":
+		values, ok := res.([]interface{})
+		if !ok {
+			break
+		}
+		for i := range values {
+			if i+2 < len(cmd) {
+				values[i] = maskRedisValue(values[i], redisRule(rules, key, cmd[i+2], true))
+			}
+		}
+		return values
+	case "hgetall":
+		switch values := res.(type) {
+		case []interface{}:
+			// field and value in turn
+			for i := 0; i+1 < len(values); i += 2 {
+				values[i+1] = maskRedisValue(values[i+1], redisRule(rules, key, fmt.Sprint(values[i]), true))
+			}
+			return values
+		case map[interface{}]interface{}:
+			for field, v := range values {
+				values[field] = maskRedisValue(v, redisRule(rules, key, fmt.Sprint(field), true))
+			}
+			return values
+		}
+	}
+
+	return maskRedisValue(res, redisRule(rules, key, "", false))
+}
+
+// redisRule first rule matches key and field, field is ignored if it is unknown
+func redisRule(rules []common.MaskRule, key string, field string, fieldKnown bool) *common.MaskRule {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Key == "" || !maskMatch(rule.Key, key) {
+			continue
+		}
+
+		if fieldKnown && !maskMatch(rule.Field, field) {
+			continue
+		}
+		return rule
+	}
+
+	return nil
+}
+
+// maskRedisValue mask every element of list、set、zset and hash value
+func maskRedisValue(v interface{}, rule *common.MaskRule) interface{} {
+	if rule == nil {
+		return v
+	}
+
+	switch r := v.(type) {
+	case []interface{}:
+		for i := range r {
+			r[i] = maskRedisValue(r[i], rule)
+		}
+		return r
+	case map[interface{}]interface{}:
+		for k, e := range r {
+			r[k] = maskRedisValue(e, rule)
+		}
+		return r
+	}
+
+	return maskValue(v, rule)
+}
+
+// maskValue mask value by strategy of rule, NULL is kept
+func maskValue(v interface{}, rule *common.MaskRule) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprint(v)
+	}
+
+	switch rule.Strategy {
+	case common.MaskStrategyPartial:
+		return maskPartial(s, rule.KeepPrefix, rule.KeepSuffix)
+	case common.MaskStrategyHash:
+		if rule.Salt == "" {
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:])
+		}
+
+		mac := hmac.New(sha256.New, []byte(rule.Salt))
+		mac.Write([]byte(s))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	return common.MaskRedacted
+}
+
+// maskPartial
+// keep prefix and suffix characters, eg: 138****5678
+// value not longer than prefix and suffix is masked entirely
+func maskPartial(s string, prefix int, suffix int) string {
+	if prefix <= 0 && suffix <= 0 {
+		prefix, suffix = 3, 4
+	}
+	if prefix < 0 {
+		prefix = 0
+	}
+	if suffix < 0 {
+		suffix = 0
+	}
+
+	r := []rune(s)
+	if len(r) <= prefix+suffix {
+		return strings.Repeat("*", len(r))
+	}
+
+	return string(r[:prefix]) + strings.Repeat("*", len(r)-prefix-suffix) + string(r[len(r)-suffix:])
+}
+
+// maskMatch match name by glob pattern ignore case, empty pattern match all
+func maskMatch(pattern string, name string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return err == nil && ok
+}
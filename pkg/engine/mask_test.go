@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+)
+
+func TestMaskValue(t *testing.T) {
+	partial := &common.MaskRule{Strategy: common.MaskStrategyPartial}
+	require.Equal(t, "138****5678", maskValue("13812345678", partial))
+	require.Equal(t, "***", maskValue("abc", partial))
+	require.Equal(t, "1***", maskValue(int64(1234), &common.MaskRule{Strategy: common.MaskStrategyPartial, KeepPrefix: 1}))
+	require.Nil(t, maskValue(nil, partial))
+
+	hash := &common.MaskRule{Strategy: common.MaskStrategyHash}
+	require.Equal(t, "a665a45920422f9d417e4867efdc4fb8a04a1f3fff1fa07e998e86f7f7a27ae3", maskValue("123", hash))
+	require.NotEqual(t, maskValue("123", hash), maskValue("123", &common.MaskRule{Strategy: common.MaskStrategyHash, Salt: "salt"}))
+
+	require.Equal(t, common.MaskRedacted, maskValue("token", &common.MaskRule{Strategy: common.MaskStrategyRedact}))
+	require.Equal(t, common.MaskRedacted, maskValue("token", &common.MaskRule{Strategy: "unknown"}))
+}
+
+func TestMaskSQLRows(t *testing.T) {
+	rules := []common.MaskRule{
+		{Table: "user*", Column: "phone", Strategy: common.MaskStrategyPartial},
+		{Schema: "admin", Column: "token", Strategy: common.MaskStrategyRedact},
+	}
+
+	cases := []struct {
+		name   string
+		sql    string
+		schema string
+		cols   []string
+		masked []bool
+	}{
+		{"column", "select id, phone from user_info", "test", []string{"id", "phone"}, []bool{false, true}},
+		{"alias", "select phone as p from user_info", "test", []string{"p"}, []bool{true}},
+		{"expression", "select concat(phone, '') from user_info", "test", []string{"concat(phone, '')"}, []bool{true}},
+		{"star", "select * from user_info", "test", []string{"id", "phone"}, []bool{false, true}},
+		{"join", "select o.id, u.phone from orders o join user_info u on o.uid = u.id", "test", []string{"id", "phone"}, []bool{false, true}},
+		{"other table", "select phone from orders", "test", []string{"phone"}, []bool{false}},
+		{"qualified schema", "select token from admin.account", "test", []string{"token"}, []bool{true}},
+		{"other schema", "select token from account", "test", []string{"token"}, []bool{false}},
+		{"unparsed", "select phone from \"orders\" where", "test", []string{"phone"}, []bool{true}},
+		{"union", "select id from orders union all select phone from user_info", "test", []string{"id"}, []bool{true}},
+		{"paren union", "(select id, note from orders) union (select id, concat(phone) from user_info)", "test",
+			[]string{"id", "note"}, []bool{false, true}},
+		{"derived union", "select a from (select id a from orders union select phone from user_info) t", "test",
+			[]string{"a"}, []bool{true}},
+		{"union star", "select id, note from orders union select * from user_info", "test",
+			[]string{"id", "note"}, []bool{true, true}},
+		{"union other table", "select id from orders union select id from user_info", "test", []string{"id"}, []bool{false}},
+		{"star expression", "select *, concat(phone) from user_info", "test",
+			[]string{"id", "concat(phone)"}, []bool{true, true}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			row := common.Row{}
+			for _, col := range c.cols {
+				row[col] = "13812345678"
+			}
+			queryRes := &common.QuerySet{
+				Columns:     c.cols,
+				ColumnTypes: make([]common.ColumnMeta, len(c.cols)),
+				Rows:        []common.Row{row},
+			}
+
+			maskSQLRows(queryRes, rules, c.sql, c.schema)
+
+			for i, col := range c.cols {
+				if c.masked[i] {
+					require.NotEqual(t, "13812345678", row[col], col)
+				} else {
+					require.Equal(t, "13812345678", row[col], col)
+				}
+				require.Equal(t, c.masked[i], queryRes.ColumnTypes[i].Masked, col)
+			}
+		})
+	}
+}
+
+func TestMaskRedisResult(t *testing.T) {
+	rules := []common.MaskRule{
+		{Key: "user:*", Field: "phone", Strategy: common.MaskStrategyPartial},
+		{Key: "token:*", Strategy: common.MaskStrategyRedact},
+	}
+
+	require.Equal(t, common.MaskRedacted, maskRedisResult("abc", []string{"get", "token:1"}, rules))
+	require.Equal(t, "abc", maskRedisResult("abc", []string{"get", "other"}, rules))
+	require.Equal(t, "string", maskRedisResult("string", []string{"type", "token:1"}, rules))
+
+	require.Equal(t, "138****5678", maskRedisResult("13812345678", []string{"hget", "user:1", "phone"}, rules))
+	require.Equal(t, "li", maskRedisResult("li", []string{"hget", "user:1", "name"}, rules))
+
+	require.Equal(t,
+		[]interface{}{"name", "li", "phone", "138****5678"},
+		maskRedisResult([]interface{}{"name", "li", "phone", "13812345678"}, []string{"hgetall", "user:1"}, rules))
+
+	require.Equal(t,
+		[]interface{}{"li", "138****5678"},
+		maskRedisResult([]interface{}{"li", "13812345678"}, []string{"hmget", "user:1", "name", "phone"}, rules))
+
+	require.Equal(t,
+		[]interface{}{"abc", common.MaskRedacted, nil},
+		maskRedisResult([]interface{}{"abc", "def", nil}, []string{"mget", "other", "token:1", "token:2"}, rules))
+
+	// field is unknown, value of hash key is masked entirely
+	require.Equal(t,
+		[]interface{}{"**", "138****5678"},
+		maskRedisResult([]interface{}{"li", "13812345678"}, []string{"hvals", "user:1"}, rules))
+}
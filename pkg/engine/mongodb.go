@@ -0,0 +1,959 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDefaultLimit default limit of find、aggregate and distinct command when user not set
+const MongoDefaultLimit = 100
+
+type MongoEngine struct {
+	driver *mongo.Client
+	*common.EngineBase
+}
+
+// MongoCMD
+// shell-like mongodb command, eg: db.user.find({"age": {"$gt": 18}}).sort({"_id": -1}).limit(10)
+type MongoCMD struct {
+	Collection string
+	Method     string // lower case method name
+	SQLType    common.SQLType
+
+	Args []interface{}
+
+	// cursor modifiers, sort and skip are only valid for find,
+	// limit is valid for find、aggregate without $out/$merge stage and distinct
+	Sort  interface{}
+	Skip  int64
+	Limit int64
+}
+
+func (m *MongoEngine) RegistryQueryPrev(hook common.PreHook) {
+	m.BindPrevHook(hook)
+}
+
+func (m *MongoEngine) RegistryQueryPost(hook common.PostHook) {
+	m.BindPostHook(hook)
+}
+
+func (m *MongoEngine) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return m.driver.Disconnect(ctx)
+}
+
+// Schema
+// schema list is fetched with hooks of fetchSchema action
+func (m *MongoEngine) Schema(ctx context.Context) ([]string, error) {
+	return schemaWithHooks(ctx, m.EngineBase, common.MongoDBEngine, func() ([]string, error) {
+		return m.fetchSchema(ctx)
+	})
+}
+
+// Table
+// table list is fetched with hooks of fetchTable action
+func (m *MongoEngine) Table(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	return tableWithHooks(ctx, m.EngineBase, common.MongoDBEngine, schema, func() (*common.TableSet, error) {
+		return m.fetchTable(ctx, schema, opt)
+	})
+}
+
+func (m *MongoEngine) fetchSchema(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	return m.driver.ListDatabaseNames(ctx, bson.D{})
+}
+
+func (m *MongoEngine) fetchTable(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	if schema == "" {
+		return nil, inerr.ErrSchemaEmpty
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	collections, err := m.driver.Database(schema).ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	// collection names are returned unordered
+	sort.Strings(collections)
+
+	return pageTables(collections, opt)
+}
+
+func (m *MongoEngine) Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet {
+	queryRes := &common.QuerySet{
+		EngineType: common.MongoDBEngine,
+		Action:     common.ActionSQLQuery,
+		IsExecute:  false,
+		Err:        nil,
+	}
+
+	// execute query prev hook
+	// query prev hook failed and stop query
+	prevArgs := &common.PrevHookArgs{
+		EngineType:  common.MongoDBEngine,
+		Action:      common.ActionSQLQuery,
+		Caller:      common.CallerFromContext(ctx),
+		Schema:      schema,
+		SQL:         sql,
+		OriginalSQL: sql,
+	}
+	err := m.RunPrevHooks(prevArgs)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// statement may be rewritten by prev hooks
+	originalSQL := sql
+	sql, err = rewrittenSQL(prevArgs, opt)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// registry query post hook
+	defer func() {
+		m.RunPostHooks(&common.PostHookArgs{
+			EngineType:    common.MongoDBEngine,
+			Action:        common.ActionSQLQuery,
+			Caller:        common.CallerFromContext(ctx),
+			IsExecute:     queryRes.IsExecute,
+			ExecuteAt:     queryRes.ExecuteAt,
+			QueryDuration: queryRes.QueryDuration,
+			Err:           queryRes.Err,
+			Schema:        schema,
+			SQL:           sql,
+			OriginalSQL:   originalSQL,
+			AffectedRows:  queryRes.AffectedRows,
+		})
+	}()
+
+	if schema == "" {
+		queryRes.Err = inerr.ErrSchemaEmpty
+		return queryRes
+	}
+
+	if sql == "" {
+		queryRes.Err = inerr.ErrMongoCMDEmpty
+		return queryRes
+	}
+
+	// bind params are only supported by MySQL and SQLite
+	if len(opt.Params) > 0 {
+		queryRes.Err = inerr.ErrParamUnsupported
+		return queryRes
+	}
+
+	// dry run is only supported by MySQL and Redis, statement is never executed
+	if opt.DryRun {
+		queryRes.Err = inerr.ErrDryRunUnsupported
+		return queryRes
+	}
+
+	cmd, err := ParseMongoCMD(sql)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// page window of find and aggregate command
+	var offset, size int64
+	if opt.IsPaging() {
+		offset, err = opt.PageOffset()
+		if err != nil {
+			queryRes.Err = err
+			return queryRes
+		}
+		size = opt.PageLimit()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opt.Timeout)*time.Second)
+	defer cancel()
+
+	coll := m.driver.Database(schema).Collection(cmd.Collection)
+
+	// run mongodb command by user provided
+	queryRes.ExecuteAt = time.Now()
+	docs, affected, err := runMongoCMD(ctx, coll, cmd, offset, size)
+	queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// one more document is fetched when next page exists
+	if size > 0 && int64(len(docs)) > size {
+		docs = docs[:size]
+		queryRes.NextCursor = common.EncodeOffsetCursor(offset + size)
+	}
+
+	cols := make([]string, 0)
+	seen := map[string]struct{}{}
+	rowList := make([]common.Row, 0, len(docs))
+	for _, doc := range docs {
+		singleRow := make(common.Row, len(doc))
+		for _, elem := range doc {
+			if _, ok := seen[elem.Key]; !ok {
+				seen[elem.Key] = struct{}{}
+				cols = append(cols, elem.Key)
+			}
+			singleRow[elem.Key] = mongoDisplayValue(elem.Value)
+		}
+		rowList = append(rowList, singleRow)
+	}
+
+	queryRes.SQL = sql
+	queryRes.IsExecute = true
+	queryRes.Err = nil
+	queryRes.Total = len(rowList)
+	queryRes.Columns = cols
+	queryRes.Rows = rowList
+	queryRes.AffectedRows = affected
+
+	// mask sensitive top level fields, collection is matched as table
+	maskQueryRows(queryRes, opt.MaskRules, &maskSource{
+		schema: schema,
+		tables: []maskTable{{schema: schema, name: cmd.Collection}},
+	})
+
+	return queryRes
+}
+
+func (m *MongoEngine) InitialDriver(conn common.ConnConfig, schema string) error {
+	cli, err := newMongoClient(conn)
+	if err != nil {
+		return err
+	}
+
+	m.driver = cli
+	m.ConnConfig = conn
+
+	if err = m.Ping(); err != nil {
+		m.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (m *MongoEngine) Reset() {
+	err := m.Close()
+	if err != nil {
+		fmt.Printf("mongodb client close failed: %s\n", err)
+	}
+	m.driver = nil
+	m.EngineBase = &common.EngineBase{}
+}
+
+func (m *MongoEngine) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return m.driver.Ping(ctx, nil)
+}
+
+func NewMongoEngine() *MongoEngine {
+	return &MongoEngine{
+		nil,
+		&common.EngineBase{},
+	}
+}
+
+func ForkMongoEngine(conn common.ConnConfig) (*MongoEngine, error) {
+	cli, err := newMongoClient(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoEngine{
+		cli,
+		common.NewEngineBase(conn),
+	}, nil
+}
+
+func newMongoClient(conn common.ConnConfig) (*mongo.Client, error) {
+	opt := options.Client().
+		SetHosts([]string{fmt.Sprintf("%s:%d", conn.IP, conn.Port)}).
+		SetConnectTimeout(15 * time.Second).
+		SetServerSelectionTimeout(15 * time.Second)
+
+	if conn.UserName != "" {
+		opt.SetAuth(options.Credential{
+			Username: conn.UserName,
+			Password: conn.Password,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return mongo.Connect(ctx, opt)
+}
+
+// mongoWindow
+// skip and limit of find and aggregate, size > 0 means paging, at most size+1 documents start from offset are returned,
+// the window is inside skip/limit set by user, false if the window is out of limit
+func mongoWindow(cmd *MongoCMD, offset int64, size int64) (int64, int64, bool) {
+	skip := cmd.Skip
+	limit := cmd.Limit
+	if size > 0 {
+		skip += offset
+		limit = size + 1
+		if cmd.Limit > 0 {
+			if cmd.Limit <= offset {
+				return 0, 0, false
+			}
+			if cmd.Limit-offset < limit {
+				limit = cmd.Limit - offset
+			}
+		}
+	}
+	return skip, limit, true
+}
+
+// runMongoCMD
+// size > 0 means paging, find and aggregate return at most size+1 documents start from offset,
+// the window is inside skip/limit set by user
+func runMongoCMD(ctx context.Context, coll *mongo.Collection, cmd *MongoCMD, offset int64, size int64) ([]bson.D, int64, error) {
+	switch cmd.SQLType {
+	case common.StmtMongoFind:
+		skip, limit, ok := mongoWindow(cmd, offset, size)
+		if !ok {
+			return []bson.D{}, 0, nil
+		}
+
+		opt := options.Find()
+		if len(cmd.Args) > 1 {
+			opt.SetProjection(cmd.Args[1])
+		}
+		if cmd.Sort != nil {
+			opt.SetSort(cmd.Sort)
+		}
+		if skip > 0 {
+			opt.SetSkip(skip)
+		}
+		if limit > 0 {
+			opt.SetLimit(limit)
+		}
+
+		cur, err := coll.Find(ctx, mongoArg(cmd.Args, 0), opt)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		docs := make([]bson.D, 0)
+		if err = cur.All(ctx, &docs); err != nil {
+			return nil, 0, err
+		}
+		return docs, 0, nil
+	case common.StmtMongoFindOne:
+		opt := options.FindOne()
+		if len(cmd.Args) > 1 {
+			opt.SetProjection(cmd.Args[1])
+		}
+
+		doc := bson.D{}
+		err := coll.FindOne(ctx, mongoArg(cmd.Args, 0), opt).Decode(&doc)
+		if err == mongo.ErrNoDocuments {
+			return []bson.D{}, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return []bson.D{doc}, 0, nil
+	case common.StmtMongoAggregate, common.StmtMongoAggregateOut:
+		pipeline := mongoArg(cmd.Args, 0)
+
+		// append page and limit stages, pipeline with $out/$merge stage should be the last one
+		if stages, ok := pipeline.(bson.A); ok && cmd.SQLType == common.StmtMongoAggregate {
+			skip, limit, ok := mongoWindow(cmd, offset, size)
+			if !ok {
+				return []bson.D{}, 0, nil
+			}
+
+			limited := make(bson.A, 0, len(stages)+2)
+			limited = append(limited, stages...)
+			if skip > 0 {
+				limited = append(limited, bson.D{{Key: "$skip", Value: skip}})
+			}
+			if limit > 0 {
+				limited = append(limited, bson.D{{Key: "$limit", Value: limit}})
+			}
+			pipeline = limited
+		}
+
+		cur, err := coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		docs := make([]bson.D, 0)
+		if err = cur.All(ctx, &docs); err != nil {
+			return nil, 0, err
+		}
+		return docs, 0, nil
+	case common.StmtMongoCountDocuments:
+		count, err := coll.CountDocuments(ctx, mongoArg(cmd.Args, 0))
+		if err != nil {
+			return nil, 0, err
+		}
+		return []bson.D{{{Key: "count", Value: count}}}, 0, nil
+	case common.StmtMongoEstimatedDocumentCount:
+		count, err := coll.EstimatedDocumentCount(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []bson.D{{{Key: "count", Value: count}}}, 0, nil
+	case common.StmtMongoDistinct:
+		field, ok := mongoArg(cmd.Args, 0).(string)
+		if !ok {
+			return nil, 0, errors.Wrap(inerr.ErrMongoCMDParse, "distinct field should be a string")
+		}
+
+		values, err := coll.Distinct(ctx, field, mongoArg(cmd.Args, 1))
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// distinct has no limit option, values are returned in one reply and truncated
+		if cmd.Limit > 0 && int64(len(values)) > cmd.Limit {
+			values = values[:cmd.Limit]
+		}
+
+		docs := make([]bson.D, 0, len(values))
+		for _, v := range values {
+			docs = append(docs, bson.D{{Key: field, Value: v}})
+		}
+		return docs, 0, nil
+	case common.StmtMongoInsertOne:
+		res, err := coll.InsertOne(ctx, mongoArg(cmd.Args, 0))
+		if err != nil {
+			return nil, 0, err
+		}
+		return []bson.D{{{Key: "insertedId", Value: res.InsertedID}}}, 1, nil
+	case common.StmtMongoInsertMany:
+		docs, ok := mongoArg(cmd.Args, 0).(bson.A)
+		if !ok {
+			return nil, 0, errors.Wrap(inerr.ErrMongoCMDParse, "insertMany documents should be an array")
+		}
+
+		res, err := coll.InsertMany(ctx, docs)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []bson.D{{{Key: "insertedCount", Value: len(res.InsertedIDs)}}}, int64(len(res.InsertedIDs)), nil
+	case common.StmtMongoUpdateOne, common.StmtMongoUpdateMany:
+		var res *mongo.UpdateResult
+		var err error
+		if cmd.SQLType == common.StmtMongoUpdateOne {
+			res, err = coll.UpdateOne(ctx, mongoArg(cmd.Args, 0), mongoArg(cmd.Args, 1))
+		} else {
+			res, err = coll.UpdateMany(ctx, mongoArg(cmd.Args, 0), mongoArg(cmd.Args, 1))
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return []bson.D{{
+			{Key: "matchedCount", Value: res.MatchedCount},
+			{Key: "modifiedCount", Value: res.ModifiedCount},
+		}}, res.ModifiedCount, nil
+	case common.StmtMongoDeleteOne, common.StmtMongoDeleteMany:
+		var res *mongo.DeleteResult
+		var err error
+		if cmd.SQLType == common.StmtMongoDeleteOne {
+			res, err = coll.DeleteOne(ctx, mongoArg(cmd.Args, 0))
+		} else {
+			res, err = coll.DeleteMany(ctx, mongoArg(cmd.Args, 0))
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return []bson.D{{{Key: "deletedCount", Value: res.DeletedCount}}}, res.DeletedCount, nil
+	}
+
+	return nil, 0, inerr.ErrMongoCMDUnSupported
+}
+
+// mongoArg return the idx argument, empty filter document as default
+func mongoArg(args []interface{}, idx int) interface{} {
+	if idx >= len(args) || args[idx] == nil {
+		return bson.D{}
+	}
+	return args[idx]
+}
+
+// mongoDisplayValue convert bson value to value which can be displayed on console
+func mongoDisplayValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, string, bool, int32, int64, float64:
+		return val
+	case primitive.ObjectID:
+		return val.Hex()
+	case primitive.DateTime:
+		return val.Time().Format("2006-01-02 15:04:05")
+	case primitive.Decimal128:
+		return val.String()
+	case primitive.Binary:
+		if len(val.Data) > BUF {
+			return BLOB_FIELD_NOT_DISPLA
+		}
+		return fmt.Sprintf("%x", val.Data)
+	case bson.D, bson.A, bson.M:
+		raw, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: val}}, false, false)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+
+		wrapper := struct {
+			V json.RawMessage `json:"v"`
+		}{}
+		if err = json.Unmarshal(raw, &wrapper); err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(wrapper.V)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// ParseMongoCMD
+// parse shell-like command: db.<collection>.<method>(<args>)[.sort(<doc>)][.skip(n)][.limit(n)]
+// collection which name container '.' can be provided by db.getCollection("name")
+// arguments are (relaxed) extended json, bare keys、single quote string、
+// ObjectId()、ISODate()、NumberLong()、NumberInt()、NumberDecimal() are also accepted
+func ParseMongoCMD(sql string) (*MongoCMD, error) {
+	s := strings.TrimSpace(sql)
+	s = strings.TrimSpace(strings.TrimSuffix(s, ";"))
+	if s == "" {
+		return nil, inerr.ErrMongoCMDEmpty
+	}
+
+	if !strings.HasPrefix(s, "db.") {
+		return nil, errors.Wrap(inerr.ErrMongoCMDParse, "command should start with 'db.'")
+	}
+
+	calls, err := splitMongoCalls(s[len("db."):])
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &MongoCMD{}
+
+	// collection part
+	if len(calls) > 0 && calls[0].name == "getcollection" && calls[0].isCall {
+		args, err := parseMongoArgs(calls[0].args)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := mongoArg(args, 0).(string)
+		if len(args) != 1 || !ok {
+			return nil, errors.Wrap(inerr.ErrMongoCMDParse, "getCollection need a collection name")
+		}
+		cmd.Collection = name
+		calls = calls[1:]
+	} else if len(calls) > 0 && !calls[0].isCall {
+		cmd.Collection = calls[0].raw
+		calls = calls[1:]
+	}
+
+	if cmd.Collection == "" || len(calls) == 0 {
+		return nil, errors.Wrap(inerr.ErrMongoCMDParse, "collection and method should be provided")
+	}
+
+	// method part
+	method := calls[0]
+	if !method.isCall {
+		return nil, errors.Wrapf(inerr.ErrMongoCMDParse, "%s is not a method call", method.raw)
+	}
+
+	sqlType, has := common.MongoDBCMDTOSQLType[method.name]
+	if !has {
+		return nil, errors.Wrap(inerr.ErrMongoCMDUnSupported, method.raw)
+	}
+
+	cmd.Method = method.name
+	cmd.SQLType = sqlType
+	cmd.Args, err = parseMongoArgs(method.args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validMongoArgs(cmd); err != nil {
+		return nil, err
+	}
+
+	// cursor modifiers
+	for _, modifier := range calls[1:] {
+		limitOnly := cmd.SQLType == common.StmtMongoAggregate || cmd.SQLType == common.StmtMongoDistinct
+		if !modifier.isCall || (cmd.SQLType != common.StmtMongoFind && !(limitOnly && modifier.name == "limit")) {
+			return nil, errors.Wrapf(inerr.ErrMongoCMDParse, "unexpected %s after %s", modifier.raw, cmd.Method)
+		}
+
+		args, err := parseMongoArgs(modifier.args)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != 1 {
+			return nil, errors.Wrapf(inerr.ErrMongoCMDParse, "%s need one argument", modifier.raw)
+		}
+
+		switch modifier.name {
+		case "sort":
+			cmd.Sort = args[0]
+		case "skip":
+			cmd.Skip, err = mongoInt(args[0])
+		case "limit":
+			cmd.Limit, err = mongoInt(args[0])
+		default:
+			return nil, errors.Wrap(inerr.ErrMongoCMDUnSupported, modifier.raw)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// aggregate with $out/$merge stage write data
+	if cmd.SQLType == common.StmtMongoAggregate {
+		pipeline, _ := mongoArg(cmd.Args, 0).(bson.A)
+		for _, stage := range pipeline {
+			stageDoc, ok := stage.(bson.D)
+			if !ok {
+				continue
+			}
+			for _, elem := range stageDoc {
+				if elem.Key == "$out" || elem.Key == "$merge" {
+					cmd.SQLType = common.StmtMongoAggregateOut
+				}
+			}
+		}
+
+		if cmd.SQLType == common.StmtMongoAggregateOut && cmd.Limit > 0 {
+			return nil, errors.Wrapf(inerr.ErrMongoCMDParse, "limit is not allowed by %s with $out/$merge stage", cmd.Method)
+		}
+	}
+
+	return cmd, nil
+}
+
+// IsMongoCMDSafe
+// valid mongodb command by white list
+// append default limit to find、aggregate without $out/$merge stage and distinct command if limit is not set
+func IsMongoCMDSafe(sql string, whiteList []common.SQLType) (string, bool, error) {
+	return IsMongoCMDSafeWithLimit(sql, whiteList, MongoDefaultLimit)
+}
+
+// IsMongoCMDSafeWithLimit
+// same as IsMongoCMDSafe, limit is appended to find、aggregate and distinct command without limit
+func IsMongoCMDSafeWithLimit(sql string, whiteList []common.SQLType, limit int64) (string, bool, error) {
+	if sql == "" {
+		return sql, false, inerr.ErrMongoCMDEmpty
+	}
+
+	cmd, err := ParseMongoCMD(sql)
+	if err != nil {
+		return sql, false, err
+	}
+
+	isPass := false
+	for _, c := range whiteList {
+		if cmd.SQLType == c {
+			isPass = true
+			break
+		}
+	}
+
+	if !isPass {
+		return sql, false, nil
+	}
+
+	// avoid querySet is too big, aggregate with $out/$merge stage returns nothing
+	switch cmd.SQLType {
+	case common.StmtMongoFind, common.StmtMongoAggregate, common.StmtMongoDistinct:
+		if cmd.Limit == 0 {
+			s := strings.TrimSpace(sql)
+			s = strings.TrimSpace(strings.TrimSuffix(s, ";"))
+			return fmt.Sprintf("%s.limit(%d)", s, limit), true, nil
+		}
+	}
+
+	return sql, true, nil
+}
+
+func validMongoArgs(cmd *MongoCMD) error {
+	minArgs, maxArgs := 0, 2
+	switch cmd.SQLType {
+	case common.StmtMongoAggregate, common.StmtMongoInsertOne, common.StmtMongoInsertMany,
+		common.StmtMongoDeleteOne, common.StmtMongoDeleteMany:
+		minArgs, maxArgs = 1, 1
+	case common.StmtMongoCountDocuments:
+		maxArgs = 1
+	case common.StmtMongoEstimatedDocumentCount:
+		maxArgs = 0
+	case common.StmtMongoDistinct:
+		minArgs = 1
+	case common.StmtMongoUpdateOne, common.StmtMongoUpdateMany:
+		minArgs = 2
+	}
+
+	if len(cmd.Args) < minArgs || len(cmd.Args) > maxArgs {
+		return errors.Wrapf(inerr.ErrMongoCMDParse, "%s need %d to %d arguments, got %d", cmd.Method, minArgs, maxArgs, len(cmd.Args))
+	}
+
+	return nil
+}
+
+func mongoInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case float64:
+		return int64(n), nil
+	}
+	return 0, errors.Wrapf(inerr.ErrMongoCMDParse, "%v is not a number", v)
+}
+
+type mongoCall struct {
+	raw    string
+	name   string // lower case
+	isCall bool
+	args   string
+}
+
+// splitMongoCalls split "user.find({...}).limit(1)" into parts separated by top level '.'
+func splitMongoCalls(s string) ([]mongoCall, error) {
+	calls := make([]mongoCall, 0)
+	for len(s) > 0 {
+		idx := 0
+		for idx < len(s) && s[idx] != '.' && s[idx] != '(' {
+			idx++
+		}
+
+		name := strings.TrimSpace(s[:idx])
+		if name == "" {
+			return nil, errors.Wrap(inerr.ErrMongoCMDParse, "empty name")
+		}
+
+		call := mongoCall{raw: name, name: strings.ToLower(name)}
+		if idx < len(s) && s[idx] == '(' {
+			end, err := matchMongoParen(s, idx)
+			if err != nil {
+				return nil, err
+			}
+
+			call.isCall = true
+			call.args = s[idx+1 : end]
+			call.raw = s[:end+1]
+			idx = end + 1
+
+			rest := strings.TrimSpace(s[idx:])
+			if rest != "" && rest[0] != '.' {
+				return nil, errors.Wrapf(inerr.ErrMongoCMDParse, "unexpected %q", rest)
+			}
+			s = rest
+		} else {
+			s = s[idx:]
+		}
+
+		calls = append(calls, call)
+		s = strings.TrimPrefix(s, ".")
+	}
+
+	return calls, nil
+}
+
+// matchMongoParen return index of the paren matched s[start]
+func matchMongoParen(s string, start int) (int, error) {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				if c != ')' {
+					return 0, errors.Wrap(inerr.ErrMongoCMDParse, "brackets mismatch")
+				}
+				return i, nil
+			}
+		}
+	}
+
+	return 0, errors.Wrap(inerr.ErrMongoCMDParse, "brackets not closed")
+}
+
+// parseMongoArgs decode comma separated shell arguments
+func parseMongoArgs(s string) ([]interface{}, error) {
+	if strings.TrimSpace(s) == "" {
+		return []interface{}{}, nil
+	}
+
+	jsonArgs, err := mongoShellToJSON(s)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := struct {
+		Args bson.A `bson:"args"`
+	}{}
+	err = bson.UnmarshalExtJSON([]byte(`{"args":[`+jsonArgs+`]}`), false, &wrapper)
+	if err != nil {
+		return nil, errors.Wrap(inerr.ErrMongoCMDParse, err.Error())
+	}
+
+	return wrapper.Args, nil
+}
+
+// mongoShellToJSON convert mongo shell literal to extended json
+func mongoShellToJSON(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\'':
+			str, next, err := readMongoString(s, i)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(strconv.Quote(str))
+			i = next
+		case c == '_' || c == '$' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(s) && (s[i] == '_' || s[i] == '$' || s[i] == '.' ||
+				unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i]))) {
+				i++
+			}
+			ident := s[start:i]
+
+			j := i
+			for j < len(s) && unicode.IsSpace(rune(s[j])) {
+				j++
+			}
+
+			switch {
+			case j < len(s) && s[j] == ':':
+				// bare key
+				b.WriteString(strconv.Quote(ident))
+			case j < len(s) && s[j] == '(':
+				end, err := matchMongoParen(s, j)
+				if err != nil {
+					return "", err
+				}
+				lit, err := mongoShellConstructor(ident, strings.TrimSpace(s[j+1:end]))
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(lit)
+				i = end + 1
+			default:
+				b.WriteString(ident)
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String(), nil
+}
+
+func readMongoString(s string, start int) (string, int, error) {
+	quote := s[start]
+	var b strings.Builder
+	for i := start + 1; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			next := s[i+1]
+			switch next {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(next)
+			}
+			i++
+			continue
+		}
+		if c == quote {
+			return b.String(), i + 1, nil
+		}
+		b.WriteByte(c)
+	}
+
+	return "", 0, errors.Wrap(inerr.ErrMongoCMDParse, "string not closed")
+}
+
+func mongoShellConstructor(name string, arg string) (string, error) {
+	val := arg
+	if len(arg) > 0 && (arg[0] == '"' || arg[0] == '\'') {
+		str, next, err := readMongoString(arg, 0)
+		if err != nil {
+			return "", err
+		}
+		if next != len(arg) {
+			return "", errors.Wrapf(inerr.ErrMongoCMDParse, "%s need one argument", name)
+		}
+		val = str
+	}
+
+	switch name {
+	case "ObjectId":
+		return fmt.Sprintf(`{"$oid":%s}`, strconv.Quote(val)), nil
+	case "ISODate":
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+			t, err := time.ParseInLocation(layout, val, time.UTC)
+			if err == nil {
+				return fmt.Sprintf(`{"$date":%s}`, strconv.Quote(t.Format(time.RFC3339Nano))), nil
+			}
+		}
+		return "", errors.Wrapf(inerr.ErrMongoCMDParse, "invalid date %s", val)
+	case "NumberLong":
+		return fmt.Sprintf(`{"$numberLong":%s}`, strconv.Quote(val)), nil
+	case "NumberInt":
+		return fmt.Sprintf(`{"$numberInt":%s}`, strconv.Quote(val)), nil
+	case "NumberDecimal":
+		return fmt.Sprintf(`{"$numberDecimal":%s}`, strconv.Quote(val)), nil
+	}
+
+	return "", errors.Wrapf(inerr.ErrMongoCMDUnSupported, "%s()", name)
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+)
+
+func TestParseMongoCMD(t *testing.T) {
+	testCase := []struct {
+		cmd        string
+		collection string
+		sqlType    common.SQLType
+		argCount   int
+		limit      int64
+	}{
+		{`db.user.find()`, "user", common.StmtMongoFind, 0, 0},
+		{`db.user.find({"age": {"$gt": 18}})`, "user", common.StmtMongoFind, 1, 0},
+		{`db.user.find({age: {$gt: 18}}, {name: 1}).sort({_id: -1}).skip(10).limit(5);`, "user", common.StmtMongoFind, 2, 5},
+		{`db.getCollection("system.users").findOne({'_id': ObjectId("5f1d7f1b2a3c4d5e6f708192")})`, "system.users", common.StmtMongoFindOne, 1, 0},
+		{`db.order.aggregate([{$match: {created: {$gte: ISODate("2023-01-01")}}}, {$group: {_id: "$status", n: {$sum: 1}}}])`, "order", common.StmtMongoAggregate, 1, 0},
+		{`db.order.aggregate([{$match: {}}, {$out: "order_bak"}])`, "order", common.StmtMongoAggregateOut, 1, 0},
+		{`db.order.countDocuments({status: "paid"})`, "order", common.StmtMongoCountDocuments, 1, 0},
+		{`db.order.estimatedDocumentCount()`, "order", common.StmtMongoEstimatedDocumentCount, 0, 0},
+		{`db.order.distinct("status")`, "order", common.StmtMongoDistinct, 1, 0},
+		{`db.order.distinct("status", {paid: true}).limit(10)`, "order", common.StmtMongoDistinct, 2, 10},
+		{`db.order.aggregate([{$match: {}}]).limit(20)`, "order", common.StmtMongoAggregate, 1, 20},
+		{`db.order.deleteMany({status: "closed"})`, "order", common.StmtMongoDeleteMany, 1, 0},
+		{`db.order.updateOne({_id: NumberLong("1")}, {$set: {status: "paid"}})`, "order", common.StmtMongoUpdateOne, 2, 0},
+	}
+
+	for _, item := range testCase {
+		cmd, err := ParseMongoCMD(item.cmd)
+		require.NoError(t, err, item.cmd)
+		require.Equal(t, item.collection, cmd.Collection, item.cmd)
+		require.Equal(t, item.sqlType, cmd.SQLType, item.cmd)
+		require.Len(t, cmd.Args, item.argCount, item.cmd)
+		require.Equal(t, item.limit, cmd.Limit, item.cmd)
+	}
+
+	invalidCase := []string{
+		``,
+		`show dbs`,
+		`db.user`,
+		`db.user.find({}`,
+		`db.user.drop()`,
+		`db.user.count({})`,
+		`db.user.findOne({}).limit(1)`,
+		`db.user.aggregate()`,
+		`db.user.aggregate([{$match: {}}]).sort({_id: 1})`,
+		`db.user.aggregate([{$match: {}}, {$out: "bak"}]).limit(1)`,
+		`db.user.distinct("name").skip(1)`,
+		`db.user.find({a: 1}) db.user.find()`,
+		`db.user.find({a: new Date()})`,
+	}
+
+	for _, item := range invalidCase {
+		_, err := ParseMongoCMD(item)
+		require.Error(t, err, item)
+	}
+}
+
+func TestIsMongoCMDSafe(t *testing.T) {
+	testCase := []struct {
+		cmd    string
+		isSafe bool
+		result string
+	}{
+		{`db.user.find({})`, true, `db.user.find({}).limit(100)`},
+		{`db.user.find({}).limit(10);`, true, `db.user.find({}).limit(10);`},
+		{`db.user.countDocuments({})`, true, `db.user.countDocuments({})`},
+		{`db.user.aggregate([{$match: {}}])`, true, `db.user.aggregate([{$match: {}}]).limit(100)`},
+		{`db.user.aggregate([{$match: {}}]).limit(5)`, true, `db.user.aggregate([{$match: {}}]).limit(5)`},
+		{`db.user.distinct("name");`, true, `db.user.distinct("name").limit(100)`},
+		{`db.user.aggregate([{$merge: {into: "bak"}}])`, false, `db.user.aggregate([{$merge: {into: "bak"}}])`},
+		{`db.user.insertOne({name: "li"})`, false, `db.user.insertOne({name: "li"})`},
+		{`db.user.deleteOne({name: "li"})`, false, `db.user.deleteOne({name: "li"})`},
+	}
+
+	for _, item := range testCase {
+		sql, isSafe, err := IsMongoCMDSafe(item.cmd, common.DefaultMongoDBWhiteCMD)
+		require.NoError(t, err, item.cmd)
+		require.Equal(t, item.isSafe, isSafe, item.cmd)
+		require.Equal(t, item.result, sql, item.cmd)
+	}
+}
+
+func TestMongoWindow(t *testing.T) {
+	cases := []struct {
+		cmd          MongoCMD
+		offset, size int64
+		skip, limit  int64
+		ok           bool
+	}{
+		{MongoCMD{Limit: 100}, 0, 0, 0, 100, true},
+		{MongoCMD{Skip: 5}, 0, 0, 5, 0, true},
+		{MongoCMD{Limit: 100}, 20, 10, 20, 11, true},
+		{MongoCMD{Skip: 5, Limit: 25}, 20, 10, 25, 5, true},
+		{MongoCMD{Limit: 20}, 20, 10, 0, 0, false},
+	}
+
+	for _, c := range cases {
+		skip, limit, ok := mongoWindow(&c.cmd, c.offset, c.size)
+		require.Equal(t, c.ok, ok)
+		require.Equal(t, c.skip, skip)
+		require.Equal(t, c.limit, limit)
+	}
+}
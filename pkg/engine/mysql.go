@@ -3,11 +3,13 @@ package engine
 import (
 	"context"
 	"database/sql"
-	"encoding/hex"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	mmysql "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
 	"github.com/ylh990835774/ay-go-components/pkg/common"
 	"github.com/ylh990835774/ay-go-components/pkg/inerr"
 	mydriver "gorm.io/driver/mysql"
@@ -41,11 +43,31 @@ func (m *MySQLEngine) Close() error {
 	return orm.Close()
 }
 
-func (m *MySQLEngine) Schema() ([]string, error) {
-	rows, err := m.driver.Raw("SHOW DATABASES;").Rows()
+// Schema
+// schema list is fetched with hooks of fetchSchema action
+func (m *MySQLEngine) Schema(ctx context.Context) ([]string, error) {
+	return schemaWithHooks(ctx, m.EngineBase, common.MySQLEngine, func() ([]string, error) {
+		return m.fetchSchema(ctx)
+	})
+}
+
+// Table
+// table list is fetched with hooks of fetchTable action
+func (m *MySQLEngine) Table(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	return tableWithHooks(ctx, m.EngineBase, common.MySQLEngine, schema, func() (*common.TableSet, error) {
+		return m.fetchTable(ctx, schema, opt)
+	})
+}
+
+func (m *MySQLEngine) fetchSchema(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	rows, err := m.driver.WithContext(ctx).Raw("SHOW DATABASES;").Rows()
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	col, _ := rows.Columns()
 
@@ -66,38 +88,105 @@ func (m *MySQLEngine) Schema() ([]string, error) {
 	return schemas, nil
 }
 
-func (m *MySQLEngine) Table(schema string) ([]string, error) {
+// fetchTable
+// tables and views of schema from information_schema, schema of connection is not relied on,
+// procedures、functions、triggers and events are returned with the first page
+func (m *MySQLEngine) fetchTable(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
 	if schema == "" {
 		return nil, inerr.ErrSchemaEmpty
 	}
 
-	rows, err := m.driver.Raw("SHOW TABLES;").Rows()
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	rows, err := m.driver.WithContext(ctx).Raw(
+		"select table_name, table_type from information_schema.tables where table_schema = ? order by table_name",
+		schema).Rows()
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	col, _ := rows.Columns()
+	tables := make([]string, 0)
+	tableTypes := map[string]string{}
+	for rows.Next() {
+		var name, tableType string
+		if err = rows.Scan(&name, &tableType); err != nil {
+			return nil, err
+		}
 
-	tmpDest := common.UnifiedLabel(col)
-	if len(tmpDest) == 0 {
-		return nil, inerr.ErrFieldEmpty
+		tables = append(tables, name)
+		tableTypes[name] = tableType
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	schemas := make([]string, 0)
+	tableSet, err := pageTables(tables, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	tableSet.TableTypes = make(map[string]string, len(tableSet.Tables))
+	for _, name := range tableSet.Tables {
+		tableSet.TableTypes[name] = tableTypes[name]
+	}
+
+	if offset, _ := opt.PageOffset(); offset > 0 {
+		return tableSet, nil
+	}
+
+	if err = m.fetchSchemaObjects(ctx, schema, tableSet); err != nil {
+		return nil, err
+	}
+	return tableSet, nil
+}
+
+// fetchSchemaObjects stored procedures、functions、triggers and events of schema
+func (m *MySQLEngine) fetchSchemaObjects(ctx context.Context, schema string, tableSet *common.TableSet) error {
+	rows, err := m.driver.WithContext(ctx).Raw(
+		"select routine_name, routine_type from information_schema.routines where routine_schema = ? order by routine_name",
+		schema).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tableSet.Procedures = make([]string, 0)
+	tableSet.Functions = make([]string, 0)
 	for rows.Next() {
-		if err = rows.Scan(tmpDest...); err != nil {
-			return nil, err
+		var name, routineType string
+		if err = rows.Scan(&name, &routineType); err != nil {
+			return err
 		}
 
-		j := *tmpDest[0].(*string)
-		schemas = append(schemas, j)
+		if routineType == "FUNCTION" {
+			tableSet.Functions = append(tableSet.Functions, name)
+		} else {
+			tableSet.Procedures = append(tableSet.Procedures, name)
+		}
 	}
-	return schemas, nil
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	tableSet.Triggers = make([]string, 0)
+	err = m.driver.WithContext(ctx).Raw(
+		"select trigger_name from information_schema.triggers where trigger_schema = ? order by trigger_name",
+		schema).Scan(&tableSet.Triggers).Error
+	if err != nil {
+		return err
+	}
+
+	tableSet.Events = make([]string, 0)
+	return m.driver.WithContext(ctx).Raw(
+		"select event_name from information_schema.events where event_schema = ? order by event_name",
+		schema).Scan(&tableSet.Events).Error
 }
 
 // Query
 // include Select、DDL statement and so on
-func (m *MySQLEngine) Query(schema string, table string, sql string, timeout int64) *common.QuerySet {
+func (m *MySQLEngine) Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet {
 	queryRes := &common.QuerySet{
 		EngineType: common.MySQLEngine,
 		Action:     common.ActionSQLQuery,
@@ -107,36 +196,65 @@ func (m *MySQLEngine) Query(schema string, table string, sql string, timeout int
 
 	// execute query prev hook
 	// query prev hook failed, stop query
-	if m.QueryPrev != nil {
-		err := m.QueryPrev(&common.PrevHookArgs{
-			EngineType: common.MySQLEngine,
-			Action:     common.ActionSQLQuery,
-			Schema:     schema,
-			SQL:        sql,
-		})
-		if err != nil {
-			queryRes.Err = err
-			return queryRes
-		}
+	prevArgs := &common.PrevHookArgs{
+		EngineType:  common.MySQLEngine,
+		Action:      common.ActionSQLQuery,
+		Caller:      common.CallerFromContext(ctx),
+		Schema:      schema,
+		SQL:         sql,
+		OriginalSQL: sql,
+	}
+	err := m.RunPrevHooks(prevArgs)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// statement may be rewritten by prev hooks
+	originalSQL := sql
+	sql, err = rewrittenSQL(prevArgs, opt)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
 	}
 
 	// registry query post hook
+	// statement rejected by row policies or lint rules is also passed to post hooks
+	// rollback statements are archived by post hooks without masking
+	var rollbackSQL []string
 	defer func() {
-		if m.QueryPost != nil {
-			m.QueryPost(&common.PostHookArgs{
-				EngineType:    common.MySQLEngine,
-				Action:        common.ActionSQLQuery,
-				IsExecute:     queryRes.IsExecute,
-				ExecuteAt:     queryRes.ExecuteAt,
-				QueryDuration: queryRes.QueryDuration,
-				Err:           queryRes.Err,
-				Schema:        schema,
-				SQL:           sql,
-				AffectedRows:  queryRes.AffectedRows,
-			})
-		}
+		m.RunPostHooks(&common.PostHookArgs{
+			EngineType:    common.MySQLEngine,
+			Action:        common.ActionSQLQuery,
+			Caller:        common.CallerFromContext(ctx),
+			IsExecute:     queryRes.IsExecute,
+			ExecuteAt:     queryRes.ExecuteAt,
+			QueryDuration: queryRes.QueryDuration,
+			Err:           queryRes.Err,
+			Schema:        schema,
+			SQL:           sql,
+			OriginalSQL:   originalSQL,
+			Fingerprint:   SQLFingerprint(sql),
+			AffectedRows:  queryRes.AffectedRows,
+			RollbackSQL:   rollbackSQL,
+		})
 	}()
 
+	// row policies are applied to statement rewritten by hooks
+	policySQL, err := MySQLApplyRowPolicies(sql, schema, opt.RowPolicies)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+	sql = policySQL
+
+	// lint rules run on the statement to execute
+	queryRes.Warnings, err = lintStatement(sql, opt.LintRules, newLintContext(ctx, schema, m.tableStats))
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
 	if schema == "" {
 		queryRes.Err = inerr.ErrSchemaEmpty
 		return queryRes
@@ -155,9 +273,21 @@ func (m *MySQLEngine) Query(schema string, table string, sql string, timeout int
 	}
 
 	// query main
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opt.Timeout)*time.Second)
 	defer cancel()
 
+	// dry run report impact of write statement, statement is not executed
+	if opt.DryRun {
+		switch sqlType {
+		case common.StmtInsert, common.StmtReplace, common.StmtUpdate, common.StmtDelete, common.StmtDDL:
+			queryRes.ExecuteAt = time.Now()
+			queryRes.Err = m.dryRun(ctx, sql, opt.Params, queryRes)
+			queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+			queryRes.SQL = sql
+			return queryRes
+		}
+	}
+
 	// start query
 	queryRes.ExecuteAt = time.Now()
 
@@ -165,20 +295,28 @@ func (m *MySQLEngine) Query(schema string, table string, sql string, timeout int
 	// not query statement
 	// use Exec()
 	case common.StmtInsert, common.StmtUpdate, common.StmtDelete, common.StmtDDL:
-		d := m.driver.WithContext(ctx).Exec(sql)
+		if opt.GenerateRollback && (sqlType == common.StmtUpdate || sqlType == common.StmtDelete) {
+			queryRes.AffectedRows, rollbackSQL, queryRes.RollbackSQL, queryRes.Err = m.execWithRollback(ctx, schema, sql, opt)
+
+			// query finished
+			queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+			queryRes.SQL = sql
+			queryRes.IsExecute = queryRes.Err == nil
+			return queryRes
+		}
+
+		queryRes.AffectedRows, queryRes.Err = execWithParams(ctx, m.driver, sql, opt.Params)
 
 		// query finished
 		queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
 		queryRes.SQL = sql
 		queryRes.IsExecute = true
-		queryRes.Err = d.Error
-		queryRes.AffectedRows = d.RowsAffected
 
 		return queryRes
 	}
 
 	// common quey statement
-	rows, err := m.driver.WithContext(ctx).Raw(sql).Rows()
+	rows, err := queryWithParams(ctx, m.driver, sql, opt.Params)
 
 	// query finished
 	queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
@@ -190,56 +328,207 @@ func (m *MySQLEngine) Query(schema string, table string, sql string, timeout int
 
 	defer rows.Close()
 
-	cols, err := rows.Columns()
+	// executed statement is known by row stream before rows
+	queryRes.SQL = sql
+	err = scanQueryRows(rows, opt, queryRes, sql, schema)
 	if err != nil {
 		queryRes.Err = err
 		return queryRes
 	}
 
-	rowList := make([]common.Row, 0)
-	for rows.Next() {
-		results := make(map[string]interface{})
-		singleRow := make(map[string]interface{})
+	queryRes.IsExecute = true
+	queryRes.Err = nil
+
+	return queryRes
+}
+
+// execWithRollback
+// affected rows are snapshot by SELECT ... FOR UPDATE in the same transaction before statement executed,
+// so reverse statements match the rows changed by statement
+// statement is not executed if reverse statements can not be generated or affected rows exceed RollbackMaxRows
+// reverse statements are returned twice, the second ones have values of columns matched by mask rules masked
+func (m *MySQLEngine) execWithRollback(ctx context.Context, schema string, sql string, opt common.QueryOptions) (int64, []string, []string, error) {
+	plan, err := mysqlRollbackPlan(sql)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if plan == nil {
+		return 0, nil, nil, errors.Wrap(inerr.ErrRollbackUnsupported, "statement can not be parsed")
+	}
 
-		err := mapScan(rows, results)
+	var primaryKeys []string
+	if !plan.isDelete {
+		tableSchema := schema
+		if !plan.table.Qualifier.IsEmpty() {
+			tableSchema = plan.table.Qualifier.String()
+		}
+
+		primaryKeys, err = m.primaryKeys(ctx, tableSchema, plan.table.Name.String())
 		if err != nil {
-			queryRes.Err = err
-			return queryRes
+			return 0, nil, nil, errors.Wrap(err, "fetch primary key failed")
+		}
+	}
+
+	var affectedRows int64
+	var statements, masked []string
+	err = m.driver.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		snapshot, err := scanRollbackSnapshot(ctx, tx, plan.snapshot, opt.Params, opt.RollbackRowLimit())
+		if err != nil {
+			return errors.Wrap(err, "snapshot affected rows failed")
 		}
 
-		for key := range results {
-			switch r := results[key].(type) {
-			case []uint8:
-				if len(r) > BUF {
-					singleRow[key] = BLOB_FIELD_NOT_DISPLA
-				} else {
-					switch hex.EncodeToString(r) {
-					case "01":
-						singleRow[key] = "true"
-					case "00":
-						singleRow[key] = "false"
-					default:
-						singleRow[key] = string(r)
-					}
-				}
-			case time.Time:
-				singleRow[key] = r.Format("2006-01-02 15:04:05")
-			case nil:
-				singleRow[key] = r
+		statements, err = plan.statements(snapshot, primaryKeys, nil)
+		if err != nil {
+			return err
+		}
+		masked = statements
+		if masks := plan.masks(snapshot, opt.MaskRules, schema); masks != nil {
+			if masked, err = plan.statements(snapshot, primaryKeys, masks); err != nil {
+				return err
 			}
 		}
 
-		rowList = append(rowList, singleRow)
+		affectedRows, err = execWithParams(ctx, tx, sql, opt.Params)
+		return err
+	})
+	if err != nil {
+		return 0, nil, nil, err
 	}
 
-	queryRes.SQL = sql
-	queryRes.IsExecute = true
-	queryRes.Err = nil
-	queryRes.Total = len(rowList)
-	queryRes.Columns = removeDuplicateElement(cols)
-	queryRes.Rows = rowList
+	return affectedRows, statements, masked, nil
+}
 
-	return queryRes
+// dryRun
+// execution plan of statement is returned as rows of result,
+// affected rows are estimated by COUNT(*) with the same WHERE clause
+func (m *MySQLEngine) dryRun(ctx context.Context, sql string, params []common.QueryParam, queryRes *common.QuerySet) error {
+	countSQL, estimatedRows, err := mysqlDryRunPlan(sql)
+	if err != nil {
+		return err
+	}
+
+	rows, err := queryWithParams(ctx, m.driver, "explain "+sql, params)
+	if err != nil {
+		return errors.Wrap(err, "explain statement failed")
+	}
+	defer rows.Close()
+
+	if err = scanQueryRows(rows, common.QueryOptions{}, queryRes, "", ""); err != nil {
+		return errors.Wrap(err, "explain statement failed")
+	}
+
+	if countSQL != "" {
+		if estimatedRows, err = m.countWithParams(ctx, countSQL, params); err != nil {
+			return errors.Wrap(err, "count affected rows failed")
+		}
+	}
+
+	queryRes.DryRun = &common.DryRunReport{
+		EstimatedRows: estimatedRows,
+		Indexes:       explainIndexes(queryRes.Rows),
+	}
+	return nil
+}
+
+// countWithParams result of count statement
+func (m *MySQLEngine) countWithParams(ctx context.Context, countSQL string, params []common.QueryParam) (int64, error) {
+	rows, err := queryWithParams(ctx, m.driver, countSQL, params)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var count int64
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			return 0, err
+		}
+	}
+	return count, rows.Err()
+}
+
+// primaryKeys columns of primary key in order
+func (m *MySQLEngine) primaryKeys(ctx context.Context, schema string, table string) ([]string, error) {
+	rows, err := m.driver.WithContext(ctx).Raw(
+		"select column_name from information_schema.key_column_usage "+
+			"where table_schema = ? and table_name = ? and constraint_name = 'PRIMARY' order by ordinal_position",
+		schema, table).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// scanRollbackSnapshot
+// rows of snapshot statement with database type of columns, scanning stops at the row exceeding maxRows
+func scanRollbackSnapshot(ctx context.Context, tx *gorm.DB, snapshotSQL string, params []common.QueryParam, maxRows int64) (*rollbackSnapshot, error) {
+	rows, err := queryWithParams(ctx, tx, snapshotSQL, params)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshot := &rollbackSnapshot{}
+	if snapshot.columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, ct := range columnTypes {
+		snapshot.dbTypes = append(snapshot.dbTypes, ct.DatabaseTypeName())
+	}
+
+	for rows.Next() {
+		if int64(len(snapshot.rows)) >= maxRows {
+			return nil, errors.Wrapf(inerr.ErrRollbackTooManyRows, "max rows %d", maxRows)
+		}
+
+		values := make([]interface{}, len(snapshot.columns))
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		snapshot.rows = append(snapshot.rows, values)
+	}
+	return snapshot, rows.Err()
+}
+
+// tableStats estimated rows and columns of table from information_schema
+func (m *MySQLEngine) tableStats(ctx context.Context, schema string, table string) (*common.TableStats, error) {
+	stats := &common.TableStats{}
+
+	err := m.driver.WithContext(ctx).Raw(
+		"select ifnull(table_rows, 0) from information_schema.tables where table_schema = ? and table_name = ?",
+		schema, table).Row().Scan(&stats.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.driver.WithContext(ctx).Raw(
+		"select count(*) from information_schema.columns where table_schema = ? and table_name = ?",
+		schema, table).Row().Scan(&stats.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
 }
 
 func (m *MySQLEngine) InitialDriver(conn common.ConnConfig, schema string) error {
@@ -263,6 +552,17 @@ func (m *MySQLEngine) Reset() {
 	m.EngineBase = &common.EngineBase{}
 }
 
+func (m *MySQLEngine) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orm, err := m.driver.DB()
+	if err != nil {
+		return err
+	}
+	return orm.PingContext(ctx)
+}
+
 func NewMySQLEngine() *MySQLEngine {
 	return &MySQLEngine{
 		nil,
@@ -324,6 +624,29 @@ func MySQLSQLType(sql string) (common.SQLType, error) {
 	return common.SQLType(statementType), nil
 }
 
+// SplitSQLScript
+// split script into statements by semicolon with vitess tokenizer,
+// semicolon in string or comment is not a separator, empty statements are dropped
+func SplitSQLScript(script string) ([]string, error) {
+	pieces, err := vsqlparser.SplitStatementToPieces(script)
+	if err != nil {
+		return nil, errors.Wrap(err, "split script failed")
+	}
+
+	statements := make([]string, 0, len(pieces))
+	for _, piece := range pieces {
+		piece = strings.TrimSpace(piece)
+		if piece != "" {
+			statements = append(statements, piece)
+		}
+	}
+
+	if len(statements) == 0 {
+		return nil, inerr.ErrSQLEmpty
+	}
+	return statements, nil
+}
+
 // MySQLTableFromSQL
 // parse table name from select sql
 // because table name provide by user is not real table that execute
@@ -337,6 +660,15 @@ func MySQLTableFromSelectSQL(sql string) (string, error) {
 }
 
 func MySQLPreCheck(sql string, allowSQLType []common.SQLType) (string, bool, error) {
+	return MySQLPreCheckWithLimit(sql, allowSQLType, 100)
+}
+
+// MySQLPreCheckWithLimit
+// same as MySQLPreCheck, limit is added to select statement without limit
+// paginated query set limit as the end row of current page
+// bind variables ? and :name are parsed by vitess, ? is printed as :v1、:v2... if statement is rewritten,
+// MySQLBindParams matches them with positional params in order
+func MySQLPreCheckWithLimit(sql string, allowSQLType []common.SQLType, limit int64) (string, bool, error) {
 	// valid sql is non empty
 	if sql == "" {
 		return "", false, inerr.ErrSQLEmpty
@@ -377,12 +709,11 @@ func MySQLPreCheck(sql string, allowSQLType []common.SQLType) (string, bool, err
 			}
 
 			// add limit
-			// default limit 100
 			sqlStObj.SetLimit(&vsqlparser.Limit{
 				Offset: nil,
 				Rowcount: &vsqlparser.Literal{
 					Type: vsqlparser.IntVal,
-					Val:  "100",
+					Val:  strconv.FormatInt(limit, 10),
 				},
 			})
 
@@ -397,7 +728,7 @@ func MySQLPreCheck(sql string, allowSQLType []common.SQLType) (string, bool, err
 				Offset: nil,
 				Rowcount: &vsqlparser.Literal{
 					Type: vsqlparser.IntVal,
-					Val:  "100",
+					Val:  strconv.FormatInt(limit, 10),
 				},
 			})
 
@@ -426,28 +757,100 @@ func removeDuplicateElement(addrs []string) []string {
 	return result
 }
 
-func mapScan(r *sql.Rows, dest map[string]interface{}) error {
-	// ignore r.started, since we needn't use reflect for anything.
-	columns, err := r.Columns()
+// scanQueryRows
+// convert rows to console rows and fill columns、rows of query set, duplicate column name will be renamed
+// values are rendered by column type and masked by mask rules of stmt executed in schema
+// when paging, rows before page offset are skipped and rows after current page are not read,
+// so only one page is held in memory, rows are not held at all when RowStream is set
+func scanQueryRows(rows *sql.Rows, opt common.QueryOptions, queryRes *common.QuerySet, stmt string, schema string) error {
+	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return err
 	}
 
-	values := make([]interface{}, len(columns))
-	for i := range values {
-		values[i] = new(interface{})
+	cols := make([]string, 0, len(columnTypes))
+	for _, ct := range columnTypes {
+		cols = append(cols, ct.Name())
 	}
+	cols = removeDuplicateElement(cols)
+	metas := columnMetas(cols, columnTypes)
 
-	err = r.Scan(values...)
-	if err != nil {
-		return err
+	queryRes.Columns = cols
+	queryRes.ColumnTypes = metas
+
+	// mask sensitive columns before result reach caller
+	var colRules []*common.MaskRule
+	if len(opt.MaskRules) > 0 {
+		colRules = columnMaskRules(queryRes, opt.MaskRules, sqlMaskSource(stmt, schema))
+	}
+
+	if opt.RowStream != nil {
+		if err = opt.RowStream.Begin(queryRes); err != nil {
+			return err
+		}
+	}
+
+	var offset int64
+	limit := int64(-1)
+	if opt.IsPaging() {
+		offset, err = opt.PageOffset()
+		if err != nil {
+			return err
+		}
+		limit = opt.PageLimit()
 	}
 
-	ele := removeDuplicateElement(columns)
+	var nextCursor string
+	var total int64
+	rowList := make([]common.Row, 0)
 
-	for i, column := range ele {
-		dest[column] = *(values[i].(*interface{}))
+	// skip rows of previous pages
+	// Next() keeps returning false after rows are exhausted
+	for i := int64(0); i < offset; i++ {
+		if !rows.Next() {
+			break
+		}
 	}
 
-	return r.Err()
+	for rows.Next() {
+		// one more row exists after current page
+		if limit >= 0 && total == limit {
+			nextCursor = common.EncodeOffsetCursor(offset + limit)
+			break
+		}
+
+		values := make([]interface{}, len(cols))
+		for i := range values {
+			values[i] = new(interface{})
+		}
+
+		if err = rows.Scan(values...); err != nil {
+			return err
+		}
+
+		singleRow := make(common.Row, len(cols))
+		for i, meta := range metas {
+			singleRow[meta.Name] = renderColumnValue(*(values[i].(*interface{})), meta.Kind)
+		}
+		maskRow(singleRow, cols, colRules)
+		total++
+
+		if opt.RowStream != nil {
+			if err = opt.RowStream.Row(singleRow); err != nil {
+				return err
+			}
+			continue
+		}
+		rowList = append(rowList, singleRow)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	queryRes.Total = int(total)
+	queryRes.Rows = rowList
+	queryRes.NextCursor = nextCursor
+
+	return nil
 }
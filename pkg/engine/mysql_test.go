@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
 )
 
 func TestMySQLPreCheck(t *testing.T) {
@@ -130,3 +131,22 @@ func TestMySQLPreCheck(t *testing.T) {
 		}
 	})
 }
+
+func TestSplitSQLScript(t *testing.T) {
+	statements, err := SplitSQLScript("create table t (id int);\n insert into t values (1), (2);\n\n" +
+		"update t set name = 'a;b' where id = 1; -- comment; not a statement\n delete from t where id = 2;;  ")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"create table t (id int)",
+		"insert into t values (1), (2)",
+		"update t set name = 'a;b' where id = 1",
+		"-- comment; not a statement\n delete from t where id = 2",
+	}, statements)
+
+	statements, err = SplitSQLScript("select 1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"select 1"}, statements)
+
+	_, err = SplitSQLScript(" ; ;")
+	require.ErrorIs(t, err, inerr.ErrSQLEmpty)
+}
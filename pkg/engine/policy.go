@@ -0,0 +1,412 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// MySQLApplyRowPolicies
+// inject predicates of row policies into select、update、delete statement on protected tables
+// predicate of table in from clause is added to where clause,
+// predicate of nullable table of outer join is added to on clause, so rows of other table are kept
+// subqueries、derived tables and union selects are filtered separately
+// statement is rejected if protected table is referenced in other ways, eg: insert、ddl、join using
+func MySQLApplyRowPolicies(sql string, schema string, policies []common.RowPolicy) (string, error) {
+	if len(policies) == 0 {
+		return sql, nil
+	}
+
+	st, err := vsqlparser.Parse(sql)
+	if err != nil {
+		return "", errors.Wrap(inerr.ErrRowPolicyUnsupported, err.Error())
+	}
+
+	r := &rowPolicyRewriter{
+		schema:   schema,
+		policies: policies,
+		handled:  map[*vsqlparser.AliasedTableExpr]bool{},
+	}
+
+	switch st.(type) {
+	case *vsqlparser.Select, *vsqlparser.Union, *vsqlparser.ParenSelect, *vsqlparser.Update, *vsqlparser.Delete:
+	default:
+		// other statements can not be filtered by where clause
+		if table, ok := r.referencedTable(st); ok {
+			return "", errors.Wrap(inerr.ErrRowPolicyUnsupported, table)
+		}
+		return sql, nil
+	}
+
+	// statements with from clause, include subqueries、derived tables and union selects
+	nodes := make([]vsqlparser.SQLNode, 0)
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		switch node.(type) {
+		case *vsqlparser.Select, *vsqlparser.Update, *vsqlparser.Delete:
+			nodes = append(nodes, node)
+		}
+		return true, nil
+	}, st)
+
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *vsqlparser.Select:
+			n.Where, err = r.filter(n.From, n.Where)
+		case *vsqlparser.Update:
+			if err = r.checkUpdate(n); err == nil {
+				n.Where, err = r.filter(n.TableExprs, n.Where)
+			}
+		case *vsqlparser.Delete:
+			n.Where, err = r.filter(n.TableExprs, n.Where)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// every protected table should be filtered
+	var unhandled string
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		if te, ok := node.(*vsqlparser.AliasedTableExpr); ok && !r.handled[te] {
+			if tb, ok := te.Expr.(vsqlparser.TableName); ok && len(r.matchPolicies(tb)) > 0 {
+				unhandled = vsqlparser.String(tb)
+				return false, nil
+			}
+		}
+		return true, nil
+	}, st)
+	if unhandled != "" {
+		return "", errors.Wrap(inerr.ErrRowPolicyUnsupported, unhandled)
+	}
+
+	if !r.injected {
+		return sql, nil
+	}
+	return vsqlparser.String(st), nil
+}
+
+type rowPolicyRewriter struct {
+	schema   string
+	policies []common.RowPolicy
+
+	// protected tables which predicates are injected
+	handled  map[*vsqlparser.AliasedTableExpr]bool
+	injected bool
+}
+
+// filter add predicates of protected tables in from clause to where clause
+func (r *rowPolicyRewriter) filter(from vsqlparser.TableExprs, where *vsqlparser.Where) (*vsqlparser.Where, error) {
+	preds := make([]vsqlparser.Expr, 0)
+	for _, te := range from {
+		p, err := r.tableExpr(te)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, p...)
+	}
+
+	if len(preds) == 0 {
+		return where, nil
+	}
+
+	expr := andExprs(preds)
+	if where == nil || where.Expr == nil {
+		return vsqlparser.NewWhere(vsqlparser.WhereClause, expr), nil
+	}
+
+	where.Expr = &vsqlparser.AndExpr{Left: where.Expr, Right: expr}
+	return where, nil
+}
+
+// tableExpr
+// return predicates should be added by parent,
+// predicates of nullable side of outer join are added to on clause of the join
+func (r *rowPolicyRewriter) tableExpr(te vsqlparser.TableExpr) ([]vsqlparser.Expr, error) {
+	switch t := te.(type) {
+	case *vsqlparser.AliasedTableExpr:
+		tb, ok := t.Expr.(vsqlparser.TableName)
+		if !ok {
+			// derived table is filtered as a select statement
+			return nil, nil
+		}
+
+		policies := r.matchPolicies(tb)
+		if len(policies) == 0 {
+			return nil, nil
+		}
+
+		// columns of predicate are qualified by alias or table name
+		qualifier := tb
+		if !t.As.IsEmpty() {
+			qualifier = vsqlparser.TableName{Name: t.As}
+		}
+
+		preds := make([]vsqlparser.Expr, 0, len(policies))
+		for _, policy := range policies {
+			pred, err := rowPolicyPredicate(policy, qualifier)
+			if err != nil {
+				return nil, err
+			}
+			preds = append(preds, pred)
+		}
+
+		r.handled[t] = true
+		r.injected = true
+		return preds, nil
+	case *vsqlparser.ParenTableExpr:
+		preds := make([]vsqlparser.Expr, 0)
+		for _, e := range t.Exprs {
+			p, err := r.tableExpr(e)
+			if err != nil {
+				return nil, err
+			}
+			preds = append(preds, p...)
+		}
+		return preds, nil
+	case *vsqlparser.JoinTableExpr:
+		left, err := r.tableExpr(t.LeftExpr)
+		if err != nil {
+			return nil, err
+		}
+		right, err := r.tableExpr(t.RightExpr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch t.Join {
+		case vsqlparser.LeftJoinType:
+			if err = addJoinCondition(t, right); err != nil {
+				return nil, err
+			}
+			return left, nil
+		case vsqlparser.RightJoinType:
+			if err = addJoinCondition(t, left); err != nil {
+				return nil, err
+			}
+			return right, nil
+		case vsqlparser.NaturalLeftJoinType:
+			if len(right) > 0 {
+				return nil, errors.Wrap(inerr.ErrRowPolicyUnsupported, "natural left join")
+			}
+			return left, nil
+		case vsqlparser.NaturalRightJoinType:
+			if len(left) > 0 {
+				return nil, errors.Wrap(inerr.ErrRowPolicyUnsupported, "natural right join")
+			}
+			return right, nil
+		}
+
+		// inner join, rows are filtered by where clause
+		return append(left, right...), nil
+	}
+
+	return nil, errors.Wrapf(inerr.ErrRowPolicyUnsupported, "table expression %T", te)
+}
+
+// checkUpdate
+// protected rows can not be moved out of policy by assigning columns of predicate
+func (r *rowPolicyRewriter) checkUpdate(update *vsqlparser.Update) error {
+	for _, table := range r.fromTables(update.TableExprs) {
+		for _, policy := range r.matchPolicies(table) {
+			cols, err := rowPolicyColumns(policy)
+			if err != nil {
+				return err
+			}
+
+			for _, updateExpr := range update.Exprs {
+				if _, ok := cols[strings.ToLower(updateExpr.Name.Name.String())]; ok {
+					return errors.Wrapf(inerr.ErrRowPolicyUnsupported, "update column %s of row policy", updateExpr.Name.Name.String())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *rowPolicyRewriter) fromTables(from vsqlparser.TableExprs) []vsqlparser.TableName {
+	tables := make([]vsqlparser.TableName, 0)
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *vsqlparser.DerivedTable:
+			return false, nil
+		case *vsqlparser.AliasedTableExpr:
+			if tb, ok := n.Expr.(vsqlparser.TableName); ok {
+				tables = append(tables, tb)
+			}
+		}
+		return true, nil
+	}, from)
+
+	return tables
+}
+
+// referencedTable protected table referenced by statement
+func (r *rowPolicyRewriter) referencedTable(st vsqlparser.Statement) (string, bool) {
+	var table string
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		if tb, ok := node.(vsqlparser.TableName); ok && len(r.matchPolicies(tb)) > 0 {
+			table = vsqlparser.String(tb)
+			return false, nil
+		}
+		return true, nil
+	}, st)
+
+	return table, table != ""
+}
+
+func (r *rowPolicyRewriter) matchPolicies(tb vsqlparser.TableName) []common.RowPolicy {
+	if tb.Name.IsEmpty() {
+		return nil
+	}
+
+	schema := r.schema
+	if !tb.Qualifier.IsEmpty() {
+		schema = tb.Qualifier.String()
+	}
+
+	policies := make([]common.RowPolicy, 0)
+	for _, policy := range r.policies {
+		if policy.Table == "" {
+			continue
+		}
+		if maskMatch(policy.Schema, schema) && maskMatch(policy.Table, tb.Name.String()) {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+func addJoinCondition(join *vsqlparser.JoinTableExpr, preds []vsqlparser.Expr) error {
+	if len(preds) == 0 {
+		return nil
+	}
+
+	if len(join.Condition.Using) > 0 {
+		return errors.Wrap(inerr.ErrRowPolicyUnsupported, "outer join with using clause")
+	}
+
+	expr := andExprs(preds)
+	if join.Condition.On != nil {
+		expr = &vsqlparser.AndExpr{Left: join.Condition.On, Right: expr}
+	}
+	join.Condition.On = expr
+	return nil
+}
+
+func andExprs(exprs []vsqlparser.Expr) vsqlparser.Expr {
+	expr := exprs[0]
+	for _, e := range exprs[1:] {
+		expr = &vsqlparser.AndExpr{Left: expr, Right: e}
+	}
+	return expr
+}
+
+// rowPolicyPredicate
+// parse predicate of policy, ? is replaced by args,
+// unqualified columns out of subquery are qualified by qualifier
+func rowPolicyPredicate(policy common.RowPolicy, qualifier vsqlparser.TableName) (vsqlparser.Expr, error) {
+	expr, err := parseRowPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var argErr error
+	expr = vsqlparser.Rewrite(expr, func(c *vsqlparser.Cursor) bool {
+		arg, ok := c.Node().(vsqlparser.Argument)
+		if !ok {
+			return true
+		}
+
+		// ? is parsed as positional argument v1、v2 ...
+		idx, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(string(arg), ":"), "v"))
+		if err != nil || idx < 1 || idx > len(policy.Args) {
+			argErr = errors.Wrapf(inerr.ErrRowPolicyInvalid, "argument %s of %s", string(arg), policy.Predicate)
+			return false
+		}
+
+		lit, err := rowPolicyLiteral(policy.Args[idx-1])
+		if err != nil {
+			argErr = err
+			return false
+		}
+		c.Replace(lit)
+		return true
+	}, nil).(vsqlparser.Expr)
+	if argErr != nil {
+		return nil, argErr
+	}
+
+	vsqlparser.Rewrite(expr, func(c *vsqlparser.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *vsqlparser.Subquery:
+			return false
+		case *vsqlparser.ColName:
+			if n.Qualifier.IsEmpty() {
+				n.Qualifier = qualifier
+			}
+		}
+		return true
+	}, nil)
+
+	return expr, nil
+}
+
+func parseRowPolicy(policy common.RowPolicy) (vsqlparser.Expr, error) {
+	st, err := vsqlparser.Parse("select 1 from dual where " + policy.Predicate)
+	if err != nil {
+		return nil, errors.Wrapf(inerr.ErrRowPolicyInvalid, "%s: %v", policy.Predicate, err)
+	}
+
+	sel, ok := st.(*vsqlparser.Select)
+	if !ok || sel.Where == nil || sel.Limit != nil || sel.OrderBy != nil || sel.GroupBy != nil {
+		return nil, errors.Wrap(inerr.ErrRowPolicyInvalid, policy.Predicate)
+	}
+
+	return sel.Where.Expr, nil
+}
+
+// rowPolicyColumns unqualified lower case column names of predicate
+func rowPolicyColumns(policy common.RowPolicy) (map[string]struct{}, error) {
+	expr, err := parseRowPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := map[string]struct{}{}
+	_ = vsqlparser.Walk(func(node vsqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *vsqlparser.Subquery:
+			return false, nil
+		case *vsqlparser.ColName:
+			cols[strings.ToLower(n.Name.String())] = struct{}{}
+		}
+		return true, nil
+	}, expr)
+
+	return cols, nil
+}
+
+func rowPolicyLiteral(v interface{}) (vsqlparser.Expr, error) {
+	switch r := v.(type) {
+	case nil:
+		return &vsqlparser.NullVal{}, nil
+	case bool:
+		return vsqlparser.BoolVal(r), nil
+	case string:
+		return vsqlparser.NewStrLiteral(r), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return vsqlparser.NewIntLiteral(fmt.Sprint(r)), nil
+	case float32:
+		return vsqlparser.NewFloatLiteral(strconv.FormatFloat(float64(r), 'f', -1, 32)), nil
+	case float64:
+		return vsqlparser.NewFloatLiteral(strconv.FormatFloat(r, 'f', -1, 64)), nil
+	}
+
+	return nil, errors.Wrapf(inerr.ErrRowPolicyInvalid, "argument type %T", v)
+}
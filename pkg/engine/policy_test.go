@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestMySQLApplyRowPolicies(t *testing.T) {
+	policies := []common.RowPolicy{
+		{Table: "orders", Predicate: "tenant_id = ?", Args: []interface{}{7}},
+		{Schema: "crm", Table: "customer*", Predicate: "tenant_id = ? and deleted = ?", Args: []interface{}{"t'1", false}},
+	}
+
+	t.Run("rewrite", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			sql    string
+			expect string
+		}{
+			{
+				"not protected",
+				"select * from users",
+				"select * from users",
+			},
+			{
+				"select",
+				"select * from orders where id = 1 or id = 2 limit 10",
+				"select * from orders where (id = 1 or id = 2) and orders.tenant_id = 7 limit 10",
+			},
+			{
+				"alias and schema",
+				"select * from crm.customer c",
+				"select * from crm.customer as c where c.tenant_id = 't\\'1' and c.deleted = false",
+			},
+			{
+				"inner join",
+				"select * from orders o join users u on o.uid = u.id",
+				"select * from orders as o join users as u on o.uid = u.id where o.tenant_id = 7",
+			},
+			{
+				"left join nullable side",
+				"select * from users u left join orders o on o.uid = u.id",
+				"select * from users as u left join orders as o on o.uid = u.id and o.tenant_id = 7",
+			},
+			{
+				"right join nullable side",
+				"select * from orders o right join users u on o.uid = u.id",
+				"select * from orders as o right join users as u on o.uid = u.id and o.tenant_id = 7",
+			},
+			{
+				"subquery",
+				"select * from users where id in (select uid from orders)",
+				"select * from users where id in (select uid from orders where orders.tenant_id = 7)",
+			},
+			{
+				"derived table",
+				"select * from (select * from orders) t",
+				"select * from (select * from orders where orders.tenant_id = 7) as t",
+			},
+			{
+				"union",
+				"select id from orders union select id from users",
+				"select id from orders where orders.tenant_id = 7 union select id from users",
+			},
+			{
+				"update",
+				"update orders set amount = 1 where id = 1",
+				"update orders set amount = 1 where id = 1 and orders.tenant_id = 7",
+			},
+			{
+				"delete",
+				"delete from orders",
+				"delete from orders where orders.tenant_id = 7",
+			},
+			{
+				"show",
+				"show tables",
+				"show tables",
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				sql, err := MySQLApplyRowPolicies(c.sql, "crm", policies)
+				require.NoError(t, err)
+				require.Equal(t, c.expect, sql)
+			})
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		SQLList := []string{
+			"insert into orders(id) values (1)",
+			"insert into users select * from orders",
+			"drop table orders",
+			"desc orders",
+			"select * from users left join orders using (id)",
+			"update orders set tenant_id = 8 where id = 1",
+			"select * from",
+		}
+
+		for _, sql := range SQLList {
+			_, err := MySQLApplyRowPolicies(sql, "crm", policies)
+			require.ErrorIs(t, err, inerr.ErrRowPolicyUnsupported, sql)
+		}
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := MySQLApplyRowPolicies("select * from orders", "crm", []common.RowPolicy{
+			{Table: "orders", Predicate: "tenant_id = ? and region = ?", Args: []interface{}{1}},
+		})
+		require.ErrorIs(t, err, inerr.ErrRowPolicyInvalid)
+	})
+}
@@ -0,0 +1,1048 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// PostgresDefaultDB database connected when schema is not provided
+const PostgresDefaultDB = "postgres"
+
+type PostgresEngine struct {
+	driver *gorm.DB
+	*common.EngineBase
+}
+
+func (p *PostgresEngine) RegistryQueryPrev(hook common.PreHook) {
+	p.BindPrevHook(hook)
+}
+
+func (p *PostgresEngine) RegistryQueryPost(hook common.PostHook) {
+	p.BindPostHook(hook)
+}
+
+func (p *PostgresEngine) Close() error {
+	orm, err := p.driver.DB()
+	if err != nil {
+		return err
+	}
+	return orm.Close()
+}
+
+// Schema
+// schema list is fetched with hooks of fetchSchema action
+func (p *PostgresEngine) Schema(ctx context.Context) ([]string, error) {
+	return schemaWithHooks(ctx, p.EngineBase, common.PostgresEngine, func() ([]string, error) {
+		return p.fetchSchema(ctx)
+	})
+}
+
+// Table
+// table list is fetched with hooks of fetchTable action
+func (p *PostgresEngine) Table(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	return tableWithHooks(ctx, p.EngineBase, common.PostgresEngine, schema, func() (*common.TableSet, error) {
+		return p.fetchTable(ctx, schema, opt)
+	})
+}
+
+// fetchSchema
+// list databases which allow connection
+func (p *PostgresEngine) fetchSchema(ctx context.Context) ([]string, error) {
+	return p.scanNames(ctx, "SELECT datname FROM pg_database WHERE datistemplate = false AND datallowconn ORDER BY datname;")
+}
+
+// fetchTable
+// list schema-qualified tables of database, eg: public.user
+func (p *PostgresEngine) fetchTable(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	if schema == "" {
+		return nil, inerr.ErrSchemaEmpty
+	}
+
+	tables, err := p.scanNames(ctx, `SELECT table_schema || '.' || table_name FROM information_schema.tables
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY table_schema, table_name;`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pageTables(tables, opt)
+}
+
+func (p *PostgresEngine) scanNames(ctx context.Context, sql string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	rows, err := p.driver.WithContext(ctx).Raw(sql).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Query
+// include Select、DDL statement and so on
+func (p *PostgresEngine) Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet {
+	queryRes := &common.QuerySet{
+		EngineType: common.PostgresEngine,
+		Action:     common.ActionSQLQuery,
+		IsExecute:  false,
+		Err:        nil,
+	}
+
+	// execute query prev hook
+	// query prev hook failed, stop query
+	prevArgs := &common.PrevHookArgs{
+		EngineType:  common.PostgresEngine,
+		Action:      common.ActionSQLQuery,
+		Caller:      common.CallerFromContext(ctx),
+		Schema:      schema,
+		SQL:         sql,
+		OriginalSQL: sql,
+	}
+	err := p.RunPrevHooks(prevArgs)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// statement may be rewritten by prev hooks
+	originalSQL := sql
+	sql, err = rewrittenSQL(prevArgs, opt)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// registry query post hook
+	defer func() {
+		p.RunPostHooks(&common.PostHookArgs{
+			EngineType:    common.PostgresEngine,
+			Action:        common.ActionSQLQuery,
+			Caller:        common.CallerFromContext(ctx),
+			IsExecute:     queryRes.IsExecute,
+			ExecuteAt:     queryRes.ExecuteAt,
+			QueryDuration: queryRes.QueryDuration,
+			Err:           queryRes.Err,
+			Schema:        schema,
+			SQL:           sql,
+			OriginalSQL:   originalSQL,
+			Fingerprint:   PostgresFingerprint(sql),
+			AffectedRows:  queryRes.AffectedRows,
+		})
+	}()
+
+	if schema == "" {
+		queryRes.Err = inerr.ErrSchemaEmpty
+		return queryRes
+	}
+
+	if sql == "" {
+		queryRes.Err = inerr.ErrSQLEmpty
+		return queryRes
+	}
+
+	// bind params are only supported by MySQL and SQLite
+	if len(opt.Params) > 0 {
+		queryRes.Err = inerr.ErrParamUnsupported
+		return queryRes
+	}
+
+	// dry run is only supported by MySQL and Redis, statement is never executed
+	if opt.DryRun {
+		queryRes.Err = inerr.ErrDryRunUnsupported
+		return queryRes
+	}
+
+	// fetch sql type
+	sqlType, err := PostgresSQLType(sql)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// query main
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opt.Timeout)*time.Second)
+	defer cancel()
+
+	// start query
+	queryRes.ExecuteAt = time.Now()
+
+	switch sqlType {
+	// not query statement
+	// use Exec()
+	case common.StmtInsert, common.StmtUpdate, common.StmtDelete, common.StmtDDL:
+		d := p.driver.WithContext(ctx).Exec(sql)
+
+		// query finished
+		queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+		queryRes.SQL = sql
+		queryRes.IsExecute = true
+		queryRes.Err = d.Error
+		queryRes.AffectedRows = d.RowsAffected
+
+		return queryRes
+	}
+
+	// common quey statement
+	rows, err := p.driver.WithContext(ctx).Raw(sql).Rows()
+
+	// query finished
+	queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	defer rows.Close()
+
+	// executed statement is known by row stream before rows
+	queryRes.SQL = sql
+	err = scanQueryRows(rows, opt, queryRes, sql, schema)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	queryRes.IsExecute = true
+	queryRes.Err = nil
+
+	return queryRes
+}
+
+func (p *PostgresEngine) InitialDriver(conn common.ConnConfig, schema string) error {
+	dsn := postgresDSN(conn.IP, conn.Port, conn.UserName, conn.Password, schema)
+	cli, err := newPostgresClient(dsn)
+	if err != nil {
+		return err
+	}
+
+	p.driver = cli
+	p.ConnConfig = conn
+	return nil
+}
+
+func (p *PostgresEngine) Reset() {
+	err := p.Close()
+	if err != nil {
+		fmt.Printf("postgres engine close failed:%s\n", err)
+	}
+	p.driver = nil
+	p.EngineBase = &common.EngineBase{}
+}
+
+func (p *PostgresEngine) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orm, err := p.driver.DB()
+	if err != nil {
+		return err
+	}
+	return orm.PingContext(ctx)
+}
+
+func NewPostgresEngine() *PostgresEngine {
+	return &PostgresEngine{
+		nil,
+		&common.EngineBase{},
+	}
+}
+
+func ForkPostgresEngine(conn common.ConnConfig, schema string) (*PostgresEngine, error) {
+	dsn := postgresDSN(conn.IP, conn.Port, conn.UserName, conn.Password, schema)
+	cli, err := newPostgresClient(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresEngine{
+		cli,
+		common.NewEngineBase(conn),
+	}, nil
+}
+
+func postgresDSN(ip string, port int, username string, password string, database string) string {
+	if database == "" {
+		database = PostgresDefaultDB
+	}
+
+	// keyword/value connection string, value quoted by single quote
+	quote := func(v string) string {
+		v = strings.ReplaceAll(v, `\`, `\\`)
+		return "'" + strings.ReplaceAll(v, "'", `\'`) + "'"
+	}
+
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable connect_timeout=15",
+		quote(ip), port, quote(username), quote(password), quote(database))
+}
+
+func newPostgresClient(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// PostgresSQLType
+// classify postgres statement into SQLType by leading keywords
+func PostgresSQLType(sql string) (common.SQLType, error) {
+	st, err := parsePostgresStatement(sql)
+	if err != nil {
+		return common.StmtUnknown, err
+	}
+
+	return st.sqlType(), nil
+}
+
+// PostgresTableFromSelectSQL
+// the postgres equivalent of MySQLTableFromSelectSQL, table of select statement over a single table,
+// schema is kept if table is qualified, eg: public.users, unquoted name is folded to lower case
+// statement with join、subquery、set operation or several tables in FROM is not over a single table
+func PostgresTableFromSelectSQL(sql string) (string, error) {
+	st, err := parsePostgresStatement(sql)
+	if err != nil {
+		return "", err
+	}
+
+	if st.words[0].word != "select" || st.words[0].depth != 0 || st.hasTopLevel("union", "intersect", "except", "join") {
+		return "", inerr.ErrExportNotSingleTable
+	}
+
+	// FROM clause ends at the next top level clause
+	start, end := -1, len(st.body)
+	for _, w := range st.words {
+		if w.depth != 0 {
+			continue
+		}
+		if start < 0 && w.word == "from" {
+			start = w.pos + len(w.word)
+			continue
+		}
+		if start >= 0 && postgresClauseAfterFrom[w.word] {
+			end = w.pos
+			break
+		}
+	}
+	if start < 0 {
+		return "", inerr.ErrExportNotSingleTable
+	}
+
+	table, ok := postgresTableName(st.body[start:end])
+	if !ok {
+		return "", inerr.ErrExportNotSingleTable
+	}
+	return table, nil
+}
+
+// postgresClauseAfterFrom keywords of clauses following FROM
+var postgresClauseAfterFrom = map[string]bool{
+	"where": true, "group": true, "having": true, "window": true, "order": true,
+	"limit": true, "offset": true, "fetch": true, "for": true,
+}
+
+// postgresTableName parse clause of a single table with optional alias, eg: public."User" AS u
+func postgresTableName(clause string) (string, bool) {
+	names := make([]string, 0)
+	i := 0
+	for {
+		for i < len(clause) && isPostgresSpace(clause[i]) {
+			i++
+		}
+		name, j := postgresIdent(clause, i)
+		if j == i {
+			return "", false
+		}
+		names = append(names, name)
+		i = j
+
+		for i < len(clause) && isPostgresSpace(clause[i]) {
+			i++
+		}
+		if i >= len(clause) || clause[i] != '.' {
+			break
+		}
+		i++
+	}
+
+	// ONLY、LATERAL are not table name
+	if len(names) == 1 && (names[0] == "only" || names[0] == "lateral") {
+		return "", false
+	}
+
+	// rest is empty or alias, function call、TABLESAMPLE and other table are not allowed
+	rest := strings.TrimSpace(clause[i:])
+	if len(rest) > 3 && strings.EqualFold(rest[:2], "as") && isPostgresSpace(rest[2]) {
+		rest = strings.TrimSpace(rest[2:])
+	}
+	if _, j := postgresIdent(rest, 0); j != len(rest) {
+		return "", false
+	}
+
+	return strings.Join(names, "."), true
+}
+
+// postgresIdent
+// identifier start at i and its end offset, quoted identifier is unquoted and others are folded to lower case
+// end is i if there is no identifier
+func postgresIdent(s string, i int) (string, int) {
+	if i < len(s) && s[i] == '"' {
+		j, err := postgresQuotedEnd(s, i)
+		if err != nil {
+			return "", i
+		}
+		return strings.ReplaceAll(s[i+1:j-1], `""`, `"`), j
+	}
+
+	j := i
+	for j < len(s) && (isPostgresWordChar(s[j]) || (j > i && s[j] == '$')) {
+		j++
+	}
+	return strings.ToLower(s[i:j]), j
+}
+
+// PostgresPreCheck
+// the postgres equivalent of MySQLPreCheck
+// valid statement type by allowSQLType and add default limit to select statement
+func PostgresPreCheck(sql string, allowSQLType []common.SQLType) (string, bool, error) {
+	return PostgresPreCheckWithLimit(sql, allowSQLType, 100)
+}
+
+// PostgresPreCheckWithLimit
+// same as PostgresPreCheck, limit is added to select statement without limit
+func PostgresPreCheckWithLimit(sql string, allowSQLType []common.SQLType, limit int64) (string, bool, error) {
+	// valid sql is non empty
+	if sql == "" {
+		return "", false, inerr.ErrSQLEmpty
+	}
+
+	st, err := parsePostgresStatement(sql)
+	if err != nil {
+		return sql, false, err
+	}
+
+	sqlType := st.sqlType()
+
+	// valid sql is allowed to execute by allowSQLType
+	isPass := false
+	for _, alType := range allowSQLType {
+		if alType != sqlType {
+			continue
+		}
+
+		isPass = true
+		break
+	}
+
+	if !isPass {
+		return sql, false, nil
+	}
+
+	// add default limit if sql is select statement and no set limit
+	// avoid querySet is too big
+	// limit is added before locking clause, trailing comments are dropped
+	isRows := sqlType == common.StmtSelect || sqlType == common.StmtLockTables || sqlType == common.StmtCallProc
+	if isRows && st.isQuery() && !st.hasTopLevel("limit", "fetch") {
+		if pos := st.lockingClause(); pos >= 0 {
+			return fmt.Sprintf("%s LIMIT %d %s", strings.TrimSpace(st.body[:pos]), limit, st.body[pos:]), true, nil
+		}
+		return fmt.Sprintf("%s LIMIT %d", st.body, limit), true, nil
+	}
+
+	// other valid statement
+	return sql, true, nil
+}
+
+type postgresWord struct {
+	word  string // lower case
+	depth int    // parentheses depth
+	pos   int    // byte offset in body
+	call  bool   // followed by '(', eg: function call
+}
+
+// postgresStatement a single statement split into words
+// literal、quoted identifier and comment are skipped
+// body is the statement without ';' and trailing comments
+type postgresStatement struct {
+	body  string
+	words []postgresWord
+}
+
+// parsePostgresStatement tokenize sql and reject multi statement
+func parsePostgresStatement(sql string) (*postgresStatement, error) {
+	st := &postgresStatement{}
+
+	depth := 0
+	end := -1
+	// end offset of the last token which is not blank or comment
+	last := 0
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+
+		// content after ';' should only be blank or comment
+		if end >= 0 && !isPostgresSpace(c) && !strings.HasPrefix(sql[i:], "--") && !strings.HasPrefix(sql[i:], "/*") {
+			return nil, inerr.ErrSQLMultiStatement
+		}
+
+		switch {
+		case isPostgresSpace(c):
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--") || strings.HasPrefix(sql[i:], "/*"):
+			j, err := postgresCommentEnd(sql, i)
+			if err != nil {
+				return nil, err
+			}
+			i = j
+			continue
+		case c == '\'' || c == '"' || (c == '$' && postgresDollarTag(sql[i:]) != ""):
+			j, err := postgresQuotedEnd(sql, i)
+			if err != nil {
+				return nil, err
+			}
+			i = j
+		case c == ';':
+			end = i
+			i++
+			continue
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+		case isPostgresWordChar(c):
+			j := i
+			for j < len(sql) && (isPostgresWordChar(sql[j]) || sql[j] == '$') {
+				j++
+			}
+			k := j
+			for k < len(sql) && isPostgresSpace(sql[k]) {
+				k++
+			}
+			st.words = append(st.words, postgresWord{word: strings.ToLower(sql[i:j]), depth: depth, pos: i, call: k < len(sql) && sql[k] == '('})
+			i = j
+		default:
+			i++
+		}
+		last = i
+	}
+
+	st.body = strings.TrimSpace(sql[:last])
+	start := len(sql[:last]) - len(strings.TrimLeft(sql[:last], " \t\n\r\f\v"))
+	for k := range st.words {
+		st.words[k].pos -= start
+	}
+
+	if len(st.words) == 0 {
+		return nil, inerr.ErrSQLEmpty
+	}
+
+	return st, nil
+}
+
+// postgresCommentEnd end offset of line comment or block comment start at i, block comment can be nested
+func postgresCommentEnd(sql string, i int) (int, error) {
+	if strings.HasPrefix(sql[i:], "--") {
+		next := strings.IndexByte(sql[i:], '\n')
+		if next < 0 {
+			return len(sql), nil
+		}
+		return i + next + 1, nil
+	}
+
+	level := 0
+	j := i
+	for j < len(sql) {
+		if strings.HasPrefix(sql[j:], "/*") {
+			level++
+			j += 2
+		} else if strings.HasPrefix(sql[j:], "*/") {
+			level--
+			j += 2
+			if level == 0 {
+				break
+			}
+		} else {
+			j++
+		}
+	}
+	if level != 0 {
+		return 0, inerr.ErrSQLNotClosed
+	}
+	return j, nil
+}
+
+// postgresQuotedEnd end offset of string literal、quoted identifier or dollar quoted string start at i
+func postgresQuotedEnd(sql string, i int) (int, error) {
+	c := sql[i]
+	if c == '$' {
+		tag := postgresDollarTag(sql[i:])
+		k := strings.Index(sql[i+len(tag):], tag)
+		if k < 0 {
+			return 0, inerr.ErrSQLNotClosed
+		}
+		return i + len(tag) + k + len(tag), nil
+	}
+
+	// '' or "" is escaped quote
+	j := i + 1
+	for {
+		k := strings.IndexByte(sql[j:], c)
+		if k < 0 {
+			return 0, inerr.ErrSQLNotClosed
+		}
+		j += k + 1
+		if j < len(sql) && sql[j] == c {
+			j++
+			continue
+		}
+		return j, nil
+	}
+}
+
+// SplitPostgresScript
+// split script into statements by semicolon, semicolon in string、quoted identifier、dollar quoted body or comment
+// is not a separator, statements of only blank or comment are dropped
+func SplitPostgresScript(script string) ([]string, error) {
+	statements := make([]string, 0)
+	start := 0
+	hasToken := false
+	i := 0
+	for i < len(script) {
+		c := script[i]
+		switch {
+		case isPostgresSpace(c):
+			i++
+		case strings.HasPrefix(script[i:], "--") || strings.HasPrefix(script[i:], "/*"):
+			j, err := postgresCommentEnd(script, i)
+			if err != nil {
+				return nil, errors.Wrap(err, "split script failed")
+			}
+			i = j
+		case c == '\'' || c == '"' || (c == '$' && postgresDollarTag(script[i:]) != ""):
+			j, err := postgresQuotedEnd(script, i)
+			if err != nil {
+				return nil, errors.Wrap(err, "split script failed")
+			}
+			i = j
+			hasToken = true
+		case c == ';':
+			if hasToken {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			i++
+			start = i
+			hasToken = false
+		default:
+			i++
+			hasToken = true
+		}
+	}
+	if hasToken {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+
+	if len(statements) == 0 {
+		return nil, inerr.ErrSQLEmpty
+	}
+	return statements, nil
+}
+
+type postgresToken struct {
+	text string
+	gap  bool // blank or comment before token
+}
+
+// PostgresFingerprint
+// shape of statement by postgres tokenizer, literals、$n params and dollar quoted strings are replaced by ?,
+// quoted identifiers are kept as they are, keywords are lower case, IN list is folded into (?) and comments are dropped,
+// empty if sql can not be tokenized
+func PostgresFingerprint(sql string) string {
+	tokens := make([]postgresToken, 0)
+	gap := false
+	emit := func(text string) {
+		tokens = append(tokens, postgresToken{text: text, gap: gap})
+		gap = false
+	}
+
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+		switch {
+		case isPostgresSpace(c):
+			gap = true
+			i++
+		case strings.HasPrefix(sql[i:], "--") || strings.HasPrefix(sql[i:], "/*"):
+			j, err := postgresCommentEnd(sql, i)
+			if err != nil {
+				return ""
+			}
+			gap = true
+			i = j
+		case c == '"':
+			j, err := postgresQuotedEnd(sql, i)
+			if err != nil {
+				return ""
+			}
+			emit(sql[i:j])
+			i = j
+		case c == '\'' || (c == '$' && postgresDollarTag(sql[i:]) != ""):
+			j, err := postgresQuotedEnd(sql, i)
+			if err != nil {
+				return ""
+			}
+			// prefix of escape、bit、hex string, eg: E'\n'、B'101'
+			if n := len(tokens); c == '\'' && !gap && n > 0 && postgresStringPrefix[tokens[n-1].text] {
+				gap = tokens[n-1].gap
+				tokens = tokens[:n-1]
+			}
+			emit("?")
+			i = j
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			emit("?")
+			i = j
+		case (c >= '0' && c <= '9') || (c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9'):
+			j := i
+			for j < len(sql) && (isPostgresWordChar(sql[j]) || sql[j] == '.') {
+				if (sql[j] == 'e' || sql[j] == 'E') && j+1 < len(sql) && (sql[j+1] == '+' || sql[j+1] == '-') {
+					j++
+				}
+				j++
+			}
+			emit("?")
+			i = j
+		case isPostgresWordChar(c):
+			j := i
+			for j < len(sql) && (isPostgresWordChar(sql[j]) || sql[j] == '$') {
+				j++
+			}
+			emit(strings.ToLower(sql[i:j]))
+			i = j
+		case c == ';':
+			gap = true
+			i++
+		case strings.IndexByte(postgresOperatorChars, c) >= 0:
+			j := i
+			for j < len(sql) && strings.IndexByte(postgresOperatorChars, sql[j]) >= 0 &&
+				!strings.HasPrefix(sql[j:], "--") && !strings.HasPrefix(sql[j:], "/*") {
+				j++
+			}
+			emit(sql[i:j])
+			i = j
+		default:
+			emit(sql[i : i+1])
+			i++
+		}
+	}
+
+	var sb strings.Builder
+	for k := 0; k < len(tokens); k++ {
+		token := tokens[k]
+		if token.gap && sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(token.text)
+
+		// in (?, ?, ?) is folded into in (?)
+		if token.text == "in" && k+1 < len(tokens) && tokens[k+1].text == "(" {
+			if end := postgresValueListEnd(tokens, k+2); end > 0 {
+				if tokens[k+1].gap {
+					sb.WriteString(" ")
+				}
+				sb.WriteString("(?)")
+				k = end
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// operator characters of postgres, continuous ones are one token, eg: ::、>=、->>
+const postgresOperatorChars = "+-*/<>=~!@#%^&|`?:"
+
+// prefix of string constant
+var postgresStringPrefix = map[string]bool{"e": true, "b": true, "x": true, "n": true}
+
+// postgresValueListEnd index of ) if tokens from start are ?, ?, ... ), otherwise -1
+func postgresValueListEnd(tokens []postgresToken, start int) int {
+	for k := start; k < len(tokens); k += 2 {
+		if tokens[k].text != "?" || k+1 >= len(tokens) {
+			return -1
+		}
+		switch tokens[k+1].text {
+		case ")":
+			return k + 1
+		case ",":
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// sqlType classify statement by leading keywords
+func (st *postgresStatement) sqlType() common.SQLType {
+	return postgresWordsType(st.words)
+}
+
+// postgresWordsType
+// query locks rows or calls function with side effect is not a read, it is classified by postgresReadType
+func postgresWordsType(words []postgresWord) common.SQLType {
+	sqlType := postgresLeadingType(words)
+	if sqlType == common.StmtSelect {
+		return postgresReadType(words)
+	}
+	return sqlType
+}
+
+// postgresSideEffectFunctions
+// admin or side effect functions can be called by select, name ends with '*' is a prefix
+var postgresSideEffectFunctions = []string{
+	"pg_terminate_backend",
+	"pg_cancel_backend",
+	"pg_reload_conf",
+	"pg_rotate_logfile",
+	"pg_promote",
+	"pg_read_file",
+	"pg_read_binary_file",
+	"pg_ls_dir",
+	"pg_stat_reset*",
+	"pg_advisory_lock*",
+	"pg_advisory_xact_lock*",
+	"pg_create_*",
+	"pg_drop_replication_slot",
+	"set_config",
+	"setval",
+	"nextval",
+	"lo_*",
+	"dblink*",
+}
+
+// postgresReadType
+// select with locking clause locks rows like LOCK, it is StmtLockTables
+// select calls function in postgresSideEffectFunctions is StmtCallProc
+func postgresReadType(words []postgresWord) common.SQLType {
+	for i, w := range words {
+		if w.call && isPostgresSideEffectFunction(w.word) {
+			return common.StmtCallProc
+		}
+		if w.word == "for" && i+1 < len(words) {
+			switch words[i+1].word {
+			case "update", "share", "no", "key":
+				return common.StmtLockTables
+			}
+		}
+	}
+	return common.StmtSelect
+}
+
+func isPostgresSideEffectFunction(name string) bool {
+	for _, fn := range postgresSideEffectFunctions {
+		if strings.HasSuffix(fn, "*") && strings.HasPrefix(name, strings.TrimSuffix(fn, "*")) {
+			return true
+		}
+		if fn == name {
+			return true
+		}
+	}
+	return false
+}
+
+// postgresLeadingType classify statement by leading keywords
+func postgresLeadingType(words []postgresWord) common.SQLType {
+	if len(words) == 0 {
+		return common.StmtUnknown
+	}
+
+	switch words[0].word {
+	case "select":
+		// select ... into new_table create table
+		for _, w := range words {
+			if w.depth == 0 && w.word == "into" {
+				return common.StmtDDL
+			}
+		}
+		return common.StmtSelect
+	case "table", "values":
+		return common.StmtSelect
+	case "with":
+		// data-modifying statement in common table expression runs even if the main statement is select
+		for _, w := range words[1:] {
+			if w.depth == 0 {
+				continue
+			}
+			switch w.word {
+			case "insert":
+				return common.StmtInsert
+			case "update", "merge":
+				return common.StmtUpdate
+			case "delete":
+				return common.StmtDelete
+			}
+		}
+
+		// the main statement of common table expression
+		for _, w := range words[1:] {
+			if w.depth != 0 {
+				continue
+			}
+			switch w.word {
+			case "insert":
+				return common.StmtInsert
+			case "update":
+				return common.StmtUpdate
+			case "delete":
+				return common.StmtDelete
+			case "select":
+				return postgresWordsType([]postgresWord{w})
+			}
+		}
+		return common.StmtSelect
+	case "insert":
+		return common.StmtInsert
+	case "update":
+		return common.StmtUpdate
+	case "delete":
+		return common.StmtDelete
+	case "merge":
+		return common.StmtUpdate
+	case "create", "alter", "drop", "truncate", "comment", "reindex", "cluster", "refresh":
+		return common.StmtDDL
+	case "begin", "start":
+		return common.StmtBegin
+	case "commit", "end":
+		return common.StmtCommit
+	case "rollback", "abort":
+		if len(words) > 1 && words[1].word == "to" {
+			return common.StmtSRollback
+		}
+		return common.StmtRollback
+	case "savepoint":
+		return common.StmtSavepoint
+	case "release":
+		return common.StmtRelease
+	case "set", "reset":
+		return common.StmtSet
+	case "show":
+		return common.StmtShow
+	case "explain":
+		// explain analyze execute the statement actually
+		// treat as the type of explained statement
+		idx := 1
+		analyze := false
+		for idx < len(words) {
+			w := words[idx]
+			if w.depth == 0 && (w.word == "analyze" || w.word == "analyse") {
+				analyze = true
+			} else if w.depth > 0 {
+				if (w.word == "analyze" || w.word == "analyse") &&
+					!(idx+1 < len(words) && words[idx+1].depth > 0 && (words[idx+1].word == "false" || words[idx+1].word == "off")) {
+					analyze = true
+				}
+			} else if w.word != "verbose" {
+				break
+			}
+			idx++
+		}
+		if analyze {
+			return postgresWordsType(words[idx:])
+		}
+		return common.StmtExplain
+	case "grant", "revoke":
+		return common.StmtPriv
+	case "lock":
+		return common.StmtLockTables
+	case "call", "do":
+		return common.StmtCallProc
+	case "copy", "vacuum", "analyze", "analyse", "listen", "notify", "discard", "checkpoint", "prepare", "execute", "deallocate":
+		return common.StmtOther
+	}
+
+	return common.StmtUnknown
+}
+
+// isQuery statement return rows which can be limited
+func (st *postgresStatement) isQuery() bool {
+	switch st.words[0].word {
+	case "select", "table", "values", "with":
+		return true
+	}
+	return false
+}
+
+// hasTopLevel check keyword exists outside parentheses
+func (st *postgresStatement) hasTopLevel(keywords ...string) bool {
+	for _, w := range st.words {
+		if w.depth != 0 {
+			continue
+		}
+		for _, k := range keywords {
+			if w.word == k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lockingClause offset in body of top level FOR UPDATE、FOR NO KEY UPDATE、FOR SHARE、FOR KEY SHARE, -1 if not exist
+func (st *postgresStatement) lockingClause() int {
+	for i, w := range st.words {
+		if w.depth != 0 || w.word != "for" || i+1 >= len(st.words) {
+			continue
+		}
+		switch st.words[i+1].word {
+		case "update", "share", "no", "key":
+			return w.pos
+		}
+	}
+	return -1
+}
+
+// postgresDollarTag return dollar quote tag, eg: $$ or $body$
+func postgresDollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1]
+		}
+		if !isPostgresWordChar(s[i]) || (i == 1 && s[i] >= '0' && s[i] <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isPostgresWordChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+func isPostgresSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
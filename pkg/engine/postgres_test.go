@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestPostgresSQLType(t *testing.T) {
+	testCase := []struct {
+		sql     string
+		sqlType common.SQLType
+	}{
+		{"select * from public.mock_table", common.StmtSelect},
+		{"  SELECT 1;", common.StmtSelect},
+		{"-- comment\nselect 1 /* inner; */", common.StmtSelect},
+		{"select * into mock_bak from mock_table", common.StmtDDL},
+		{"with t as (select 1) select * from t", common.StmtSelect},
+		{"with t as (select id from a) delete from b using t where b.id = t.id", common.StmtDelete},
+		{"with x as (delete from users returning *) select * from x", common.StmtDelete},
+		{"with x as (select 1), y as (insert into t values (1) returning id) select * from y", common.StmtInsert},
+		{"with x as (update t set a = 1 returning *) select * from x", common.StmtUpdate},
+		{"table mock_table", common.StmtSelect},
+		{"select * from mock_table for key share", common.StmtLockTables},
+		{"select pg_terminate_backend(123)", common.StmtCallProc},
+		{"select set_config ('a', 'b', true)", common.StmtCallProc},
+		{"select dblink_exec('x', 'drop table t')", common.StmtCallProc},
+		{"values (lo_unlink(1))", common.StmtCallProc},
+		{"insert into mock_table(id) values(1)", common.StmtInsert},
+		{"update mock_table set name = 'a;b' where id = 1", common.StmtUpdate},
+		{"delete from mock_table", common.StmtDelete},
+		{"create table t(id int)", common.StmtDDL},
+		{"truncate mock_table", common.StmtDDL},
+		{`create function f() returns int as $$ select 1; $$ language sql`, common.StmtDDL},
+		{"show search_path", common.StmtShow},
+		{"explain select * from mock_table", common.StmtExplain},
+		{"explain (costs off) select * from mock_table", common.StmtExplain},
+		{"explain analyze select * from mock_table", common.StmtSelect},
+		{"explain analyze delete from mock_table", common.StmtDelete},
+		{"explain (analyze, buffers) update mock_table set id = 1", common.StmtUpdate},
+		{"explain (analyze false) update mock_table set id = 1", common.StmtExplain},
+		{"begin", common.StmtBegin},
+		{"rollback to savepoint a", common.StmtSRollback},
+		{"grant select on mock_table to u", common.StmtPriv},
+		{"copy mock_table to '/tmp/x'", common.StmtOther},
+		{"foo bar", common.StmtUnknown},
+	}
+
+	for _, item := range testCase {
+		sqlType, err := PostgresSQLType(item.sql)
+		require.NoError(t, err, item.sql)
+		require.Equal(t, item.sqlType, sqlType, item.sql)
+	}
+
+	invalidCase := []string{
+		"",
+		"  -- only comment",
+		"select 1; select 2",
+		"select 1;\ndrop table mock_table;",
+		"select 'not closed",
+		`select "not closed`,
+		"select $$ not closed",
+		"select /* not closed",
+	}
+
+	for _, item := range invalidCase {
+		_, err := PostgresSQLType(item)
+		require.Error(t, err, item)
+	}
+}
+
+func TestPostgresPreCheck(t *testing.T) {
+	allowSQLType := []common.SQLType{
+		common.StmtSelect,
+		common.StmtShow,
+		common.StmtExplain,
+	}
+
+	testCase := []struct {
+		sql    string
+		isPass bool
+		result string
+	}{
+		{"select * from mock_table", true, "select * from mock_table LIMIT 100"},
+		{"select * from mock_table;  ", true, "select * from mock_table LIMIT 100"},
+		{"select * from mock_table limit 10", true, "select * from mock_table limit 10"},
+		{"select * from mock_table fetch first 10 rows only", true, "select * from mock_table fetch first 10 rows only"},
+		{"select * from (select * from a limit 1) t", true, "select * from (select * from a limit 1) t LIMIT 100"},
+		{"select 'limit' from mock_table", true, "select 'limit' from mock_table LIMIT 100"},
+		{"explain select * from mock_table", true, "explain select * from mock_table"},
+		{"show all", true, "show all"},
+		{"explain analyze delete from mock_table", false, "explain analyze delete from mock_table"},
+		{"delete from mock_table", false, "delete from mock_table"},
+		{"select * into mock_bak from mock_table", false, "select * into mock_bak from mock_table"},
+		{"with x as (delete from mock_table returning *) select * from x", false, "with x as (delete from mock_table returning *) select * from x"},
+		{"select * from mock_table -- comment", true, "select * from mock_table LIMIT 100"},
+		{"select * from mock_table /* c */; -- c", true, "select * from mock_table LIMIT 100"},
+		{"select * from mock_table for update", false, "select * from mock_table for update"},
+		{"select * from mock_table where id in (select id from a for share)", false, "select * from mock_table where id in (select id from a for share)"},
+		{"select pg_terminate_backend(123)", false, "select pg_terminate_backend(123)"},
+		{"select pg_cancel_backend(pid) from pg_stat_activity", false, "select pg_cancel_backend(pid) from pg_stat_activity"},
+		{"select set_config('search_path', 'x', false)", false, "select set_config('search_path', 'x', false)"},
+		{"select lo_unlink(16401)", false, "select lo_unlink(16401)"},
+		{"select * from dblink ('dbname=x', 'delete from t') as t(a int)", false, "select * from dblink ('dbname=x', 'delete from t') as t(a int)"},
+		{"with x as (select 1) select lo_import('/etc/passwd') from x", false, "with x as (select 1) select lo_import('/etc/passwd') from x"},
+		{"select set_config, 'pg_terminate_backend(1)' from mock_table", true, "select set_config, 'pg_terminate_backend(1)' from mock_table LIMIT 100"},
+	}
+
+	for _, item := range testCase {
+		sql, isPass, err := PostgresPreCheck(item.sql, allowSQLType)
+		require.NoError(t, err, item.sql)
+		require.Equal(t, item.isPass, isPass, item.sql)
+		require.Equal(t, item.result, sql, item.sql)
+	}
+
+	// locking select is allowed by StmtLockTables, limit is added before locking clause
+	lockCase := []struct {
+		sql    string
+		result string
+	}{
+		{"select * from mock_table for update", "select * from mock_table LIMIT 100 for update"},
+		{"/* lead */ select * from mock_table where id in (select id from a for share) for no key update nowait",
+			"/* lead */ select * from mock_table where id in (select id from a for share) LIMIT 100 for no key update nowait"},
+	}
+	for _, item := range lockCase {
+		sql, isPass, err := PostgresPreCheck(item.sql, append(allowSQLType, common.StmtLockTables))
+		require.NoError(t, err, item.sql)
+		require.True(t, isPass, item.sql)
+		require.Equal(t, item.result, sql, item.sql)
+	}
+}
+
+func TestSplitPostgresScript(t *testing.T) {
+	statements, err := SplitPostgresScript("create function f() returns int as $body$ select 1; $body$ language sql;\n" +
+		"select now()::date, 'a;b', \"c;d\" from t; /* block; comment */ -- line; comment\n" +
+		"do $$ begin perform 1; end $$;;  ")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"create function f() returns int as $body$ select 1; $body$ language sql",
+		`select now()::date, 'a;b', "c;d" from t`,
+		"/* block; comment */ -- line; comment\ndo $$ begin perform 1; end $$",
+	}, statements)
+
+	_, err = SplitPostgresScript(" ; -- comment")
+	require.ErrorIs(t, err, inerr.ErrSQLEmpty)
+
+	_, err = SplitPostgresScript("select $$ not closed; select 1")
+	require.ErrorIs(t, err, inerr.ErrSQLNotClosed)
+}
+
+func TestPostgresFingerprint(t *testing.T) {
+	cases := []struct {
+		sql         string
+		fingerprint string
+	}{
+		{`SELECT "name" FROM users WHERE id = 1`, `select "name" from users where id = ?`},
+		{"select * from users where id = $1 and name = $2", "select * from users where id = ? and name = ?"},
+		{"select x::int, data->>'k' from t where a in (1, 2, 3) -- comment", "select x::int, data->>? from t where a in (?)"},
+		{"insert into t(a, b) values (E'\\n', 1.5e-3)", "insert into t(a, b) values (?, ?)"},
+		{"select $$a'b$$, $tag$x$tag$ from t;", "select ?, ? from t"},
+		{"select id from t where a in (select b from u)", "select id from t where a in (select b from u)"},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.fingerprint, PostgresFingerprint(c.sql), c.sql)
+	}
+
+	// quoted identifiers are not folded into ?
+	require.NotEqual(t,
+		PostgresFingerprint(`select "name" from users where id=1`),
+		PostgresFingerprint(`select "email" from users where id=2`),
+	)
+	require.Equal(t,
+		PostgresFingerprint("select * from users where id = $1"),
+		PostgresFingerprint("select * from users where id = 10"),
+	)
+	require.Equal(t, "", PostgresFingerprint("select 'x"))
+}
+
+func TestPostgresTableFromSelectSQL(t *testing.T) {
+	tables := []struct {
+		sql   string
+		table string
+	}{
+		{"select * from users", "users"},
+		{"SELECT id, name FROM Public.Users u WHERE id > 1 ORDER BY id LIMIT 10", "public.users"},
+		{`select * from "public"."User" as "u" for update`, "public.User"},
+		{"select id from users where id in (select user_id from orders)", "users"},
+	}
+	for _, c := range tables {
+		table, err := PostgresTableFromSelectSQL(c.sql)
+		require.NoError(t, err, c.sql)
+		require.Equal(t, c.table, table, c.sql)
+	}
+
+	complexSQL := []string{
+		"select 1",
+		"select * from users u join orders o on u.id = o.user_id",
+		"select * from users, orders",
+		"select * from (select * from users) t",
+		"select * from users union select * from admins",
+		"with t as (select * from users) select * from t",
+		"select * from only users",
+		"select * from generate_series(1, 10) g",
+		"select * from users tablesample bernoulli (10)",
+		"update users set name = 'a'",
+	}
+	for _, sql := range complexSQL {
+		_, err := PostgresTableFromSelectSQL(sql)
+		require.Equal(t, inerr.ErrExportNotSingleTable, err, sql)
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/ylh990835774/ay-go-components/pkg/inerr"
 )
 
+// RedisTablePageSize default count of keys in one page of Table
+const RedisTablePageSize = 1000
+
 type RedisEngine struct {
 	driver redis.UniversalClient
 	*common.EngineBase
@@ -31,8 +34,24 @@ func (r *RedisEngine) Close() error {
 	return r.driver.Close()
 }
 
-func (r *RedisEngine) Schema() ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// Schema
+// schema list is fetched with hooks of fetchSchema action
+func (r *RedisEngine) Schema(ctx context.Context) ([]string, error) {
+	return schemaWithHooks(ctx, r.EngineBase, common.RedisEngine, func() ([]string, error) {
+		return r.fetchSchema(ctx)
+	})
+}
+
+// Table
+// table list is fetched with hooks of fetchTable action
+func (r *RedisEngine) Table(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	return tableWithHooks(ctx, r.EngineBase, common.RedisEngine, schema, func() (*common.TableSet, error) {
+		return r.fetchTable(ctx, schema, opt)
+	})
+}
+
+func (r *RedisEngine) fetchSchema(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
 	res, err := r.driver.ConfigGet(ctx, "databases").Result()
@@ -57,32 +76,53 @@ func (r *RedisEngine) Schema() ([]string, error) {
 	return schemaList, nil
 }
 
-func (r *RedisEngine) Table(schema string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// fetchTable
+// page through keyspace by SCAN, the cursor of SCAN is returned as NextCursor
+// at least PageSize keys are collected in one page, RedisTablePageSize as default
+// page number is not supported by SCAN, only cursor
+// request without PageSize or Cursor gets the first page too, whole keyspace is scanned only if ScanAllKeys is set
+func (r *RedisEngine) fetchTable(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
 	var cursor uint64
-	keyList := make([]string, 0)
+	var err error
+	if opt.Cursor != "" {
+		cursor, err = strconv.ParseUint(opt.Cursor, 10, 64)
+		if err != nil {
+			return nil, inerr.ErrCursorInvalid
+		}
+	}
 
+	pageSize := RedisTablePageSize
+	if opt.PageSize > 0 {
+		pageSize = opt.PageSize
+	}
+
+	keyList := make([]string, 0)
 	for {
 		var keys []string
-		var err error
 
-		keys, cursor, err = r.driver.Scan(ctx, cursor, "*", 100).Result()
+		keys, cursor, err = r.driver.Scan(ctx, cursor, "*", int64(pageSize)).Result()
 		if err != nil {
 			return nil, err
 		}
 
 		keyList = append(keyList, keys...)
-		if cursor == 0 {
+		if cursor == 0 || (!opt.ScanAllKeys && len(keyList) >= pageSize) {
 			break
 		}
 	}
 
-	return keyList, nil
+	tableSet := &common.TableSet{Tables: keyList}
+	if cursor != 0 {
+		tableSet.NextCursor = strconv.FormatUint(cursor, 10)
+	}
+
+	return tableSet, nil
 }
 
-func (r *RedisEngine) Query(schema string, table string, sql string, timeout int64) *common.QuerySet {
+func (r *RedisEngine) Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet {
 	queryRes := &common.QuerySet{
 		EngineType: common.RedisEngine,
 		Action:     common.ActionSQLQuery,
@@ -92,34 +132,43 @@ func (r *RedisEngine) Query(schema string, table string, sql string, timeout int
 
 	// execute query prev hook
 	// query prev hook failed and stop query
-	if r.QueryPrev != nil {
-		err := r.QueryPrev(&common.PrevHookArgs{
-			EngineType: common.RedisEngine,
-			Action:     common.ActionSQLQuery,
-			Schema:     schema,
-			SQL:        sql,
-		})
-		if err != nil {
-			queryRes.Err = err
-			return queryRes
-		}
+	prevArgs := &common.PrevHookArgs{
+		EngineType:  common.RedisEngine,
+		Action:      common.ActionSQLQuery,
+		Caller:      common.CallerFromContext(ctx),
+		Schema:      schema,
+		SQL:         sql,
+		OriginalSQL: sql,
+	}
+	err := r.RunPrevHooks(prevArgs)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// statement may be rewritten by prev hooks
+	originalSQL := sql
+	sql, err = rewrittenSQL(prevArgs, opt)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
 	}
 
 	// registry query post hook
 	defer func() {
-		if r.QueryPost != nil {
-			r.QueryPost(&common.PostHookArgs{
-				EngineType:    common.RedisEngine,
-				Action:        common.ActionSQLQuery,
-				IsExecute:     queryRes.IsExecute,
-				ExecuteAt:     queryRes.ExecuteAt,
-				QueryDuration: queryRes.QueryDuration,
-				Err:           queryRes.Err,
-				Schema:        schema,
-				SQL:           sql,
-				AffectedRows:  queryRes.AffectedRows,
-			})
-		}
+		r.RunPostHooks(&common.PostHookArgs{
+			EngineType:    common.RedisEngine,
+			Action:        common.ActionSQLQuery,
+			Caller:        common.CallerFromContext(ctx),
+			IsExecute:     queryRes.IsExecute,
+			ExecuteAt:     queryRes.ExecuteAt,
+			QueryDuration: queryRes.QueryDuration,
+			Err:           queryRes.Err,
+			Schema:        schema,
+			SQL:           sql,
+			OriginalSQL:   originalSQL,
+			AffectedRows:  queryRes.AffectedRows,
+		})
 	}()
 
 	if schema == "" {
@@ -132,6 +181,12 @@ func (r *RedisEngine) Query(schema string, table string, sql string, timeout int
 		return queryRes
 	}
 
+	// bind params are only supported by MySQL and SQLite
+	if len(opt.Params) > 0 {
+		queryRes.Err = inerr.ErrParamUnsupported
+		return queryRes
+	}
+
 	// query main
 	redisCMD := make([]interface{}, 0)
 	redisCMDSlice, err := shlex.Split(sql, true)
@@ -149,9 +204,18 @@ func (r *RedisEngine) Query(schema string, table string, sql string, timeout int
 		redisKey = redisCMDSlice[1]
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opt.Timeout)*time.Second)
 	defer cancel()
 
+	// dry run report keys of write command, command is not executed
+	if opt.DryRun && !isRedisReadCMD(redisCMDSlice[0]) {
+		queryRes.ExecuteAt = time.Now()
+		queryRes.DryRun, queryRes.Err = r.dryRun(ctx, redisCMDSlice)
+		queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+		queryRes.SQL = sql
+		return queryRes
+	}
+
 	// try acquire key type
 	var keyType string
 	if redisKey != "" {
@@ -173,6 +237,9 @@ func (r *RedisEngine) Query(schema string, table string, sql string, timeout int
 		return queryRes
 	}
 
+	// mask sensitive value before result reach caller
+	res = maskRedisResult(res, redisCMDSlice, opt.MaskRules)
+
 	queryRes.SQL = sql
 	queryRes.IsExecute = true
 	queryRes.Err = nil
@@ -190,6 +257,55 @@ func (r *RedisEngine) Query(schema string, table string, sql string, timeout int
 	return queryRes
 }
 
+// dryRun existence and type of keys in command, fetched in one pipeline
+func (r *RedisEngine) dryRun(ctx context.Context, cmd []string) (*common.DryRunReport, error) {
+	keys := redisCommandKeys(cmd)
+
+	pipe := r.driver.Pipeline()
+	typeCMDs := make([]*redis.StatusCmd, 0, len(keys))
+	for _, key := range keys {
+		typeCMDs = append(typeCMDs, pipe.Type(ctx, key))
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, errors.Wrap(err, "fetch type of keys failed")
+		}
+	}
+
+	report := &common.DryRunReport{
+		Keys: make([]common.RedisKeyImpact, 0, len(keys)),
+	}
+	for i, key := range keys {
+		keyType := typeCMDs[i].Val()
+		exists := keyType != common.RedisKeyTypeNone
+		if exists {
+			report.EstimatedRows++
+		}
+
+		report.Keys = append(report.Keys, common.RedisKeyImpact{
+			Key:    key,
+			Exists: exists,
+			Type:   keyType,
+		})
+	}
+	return report, nil
+}
+
+// isRedisReadCMD command in DefaultRedisWhiteCMD
+func isRedisReadCMD(name string) bool {
+	sqlType, ok := common.RedisCMDTOSQLType[strings.ToLower(name)]
+	if !ok {
+		return false
+	}
+
+	for _, t := range common.DefaultRedisWhiteCMD {
+		if t == sqlType {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *RedisEngine) InitialDriver(conn common.ConnConfig, schema string) error {
 	var dbIndex int
 	var err error
@@ -217,7 +333,12 @@ func (r *RedisEngine) InitialDriver(conn common.ConnConfig, schema string) error
 
 	r.ConnConfig = conn
 
-	return r.Ping()
+	if err = r.Ping(); err != nil {
+		r.driver.Close()
+		return err
+	}
+
+	return nil
 }
 
 func (r *RedisEngine) Reset() {
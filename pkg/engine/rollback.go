@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// rollbackPlan
+// snapshot statement of rows affected by UPDATE、DELETE, and columns needed by reverse statement
+type rollbackPlan struct {
+	table    vsqlparser.TableName
+	snapshot string
+	isDelete bool
+	assigned []string // columns assigned by UPDATE
+}
+
+// rollbackSnapshot rows affected by statement, scanned before statement executed
+type rollbackSnapshot struct {
+	columns []string
+	dbTypes []string
+	rows    [][]interface{}
+}
+
+// mysqlRollbackPlan
+// nil is returned for statement other than UPDATE、DELETE, which has nothing to rollback or can not be rollback
+// multiple table statement is rejected by ErrRollbackUnsupported
+func mysqlRollbackPlan(sql string) (*rollbackPlan, error) {
+	st, err := vsqlparser.Parse(sql)
+	if err != nil {
+		return nil, nil
+	}
+
+	var from vsqlparser.TableExprs
+	plan := &rollbackPlan{}
+	snapshot := &vsqlparser.Select{
+		SelectExprs: vsqlparser.SelectExprs{&vsqlparser.StarExpr{}},
+		Lock:        vsqlparser.ForUpdateLock,
+	}
+
+	switch n := st.(type) {
+	case *vsqlparser.Update:
+		from = n.TableExprs
+		snapshot.Where, snapshot.OrderBy, snapshot.Limit = n.Where, n.OrderBy, n.Limit
+		for _, expr := range n.Exprs {
+			plan.assigned = append(plan.assigned, expr.Name.Name.String())
+		}
+	case *vsqlparser.Delete:
+		if len(n.Targets) > 0 {
+			return nil, errors.Wrap(inerr.ErrRollbackUnsupported, "multiple table delete")
+		}
+		from = n.TableExprs
+		snapshot.Where, snapshot.OrderBy, snapshot.Limit = n.Where, n.OrderBy, n.Limit
+		plan.isDelete = true
+	default:
+		return nil, nil
+	}
+
+	if len(from) != 1 {
+		return nil, errors.Wrap(inerr.ErrRollbackUnsupported, "multiple table statement")
+	}
+	aliased, ok := from[0].(*vsqlparser.AliasedTableExpr)
+	if !ok {
+		return nil, errors.Wrap(inerr.ErrRollbackUnsupported, "multiple table statement")
+	}
+	tb, ok := aliased.Expr.(vsqlparser.TableName)
+	if !ok {
+		return nil, errors.Wrap(inerr.ErrRollbackUnsupported, "statement on derived table")
+	}
+
+	// same table expression keep alias used by where clause
+	snapshot.From = from
+	plan.table = tb
+	plan.snapshot = vsqlparser.String(snapshot)
+
+	return plan, nil
+}
+
+// statements
+// reverse INSERT for DELETE, reverse UPDATE by primary key for UPDATE
+// UPDATE assign primary key can not be located after executed, it is rejected
+// value of column with mask rule in masks is written as masked string, masks is indexed by snapshot column
+func (p *rollbackPlan) statements(snapshot *rollbackSnapshot, primaryKeys []string, masks []*common.MaskRule) ([]string, error) {
+	index := map[string]int{}
+	for i, col := range snapshot.columns {
+		index[strings.ToLower(col)] = i
+	}
+
+	cols := make(vsqlparser.Columns, 0, len(snapshot.columns))
+	for _, col := range snapshot.columns {
+		cols = append(cols, vsqlparser.NewColIdent(col))
+	}
+
+	if !p.isDelete {
+		if len(primaryKeys) == 0 {
+			return nil, errors.Wrapf(inerr.ErrRollbackUnsupported, "table %s has no primary key", vsqlparser.String(p.table))
+		}
+
+		for _, pk := range primaryKeys {
+			if _, ok := index[strings.ToLower(pk)]; !ok {
+				return nil, errors.Wrapf(inerr.ErrRollbackUnsupported, "primary key %s not found", pk)
+			}
+			for _, col := range p.assigned {
+				if strings.EqualFold(col, pk) {
+					return nil, errors.Wrapf(inerr.ErrRollbackUnsupported, "primary key %s is updated", pk)
+				}
+			}
+		}
+		for _, col := range p.assigned {
+			if _, ok := index[strings.ToLower(col)]; !ok {
+				return nil, errors.Wrapf(inerr.ErrRollbackUnsupported, "column %s not found", col)
+			}
+		}
+	}
+
+	statements := make([]string, 0, len(snapshot.rows))
+	for _, row := range snapshot.rows {
+		values := make(vsqlparser.ValTuple, 0, len(row))
+		for i, v := range row {
+			if v != nil && i < len(masks) && masks[i] != nil {
+				if b, ok := v.([]byte); ok {
+					v = string(b)
+				}
+				values = append(values, vsqlparser.NewStrLiteral(fmt.Sprint(maskValue(v, masks[i]))))
+				continue
+			}
+			values = append(values, mysqlRollbackLiteral(v, snapshot.dbTypes[i]))
+		}
+
+		if p.isDelete {
+			statements = append(statements, vsqlparser.String(&vsqlparser.Insert{
+				Action:  vsqlparser.InsertAct,
+				Table:   p.table,
+				Columns: cols,
+				Rows:    vsqlparser.Values{values},
+			}))
+			continue
+		}
+
+		update := &vsqlparser.Update{
+			TableExprs: vsqlparser.TableExprs{&vsqlparser.AliasedTableExpr{Expr: p.table}},
+		}
+		for _, col := range p.assigned {
+			update.Exprs = append(update.Exprs, &vsqlparser.UpdateExpr{
+				Name: &vsqlparser.ColName{Name: vsqlparser.NewColIdent(col)},
+				Expr: values[index[strings.ToLower(col)]],
+			})
+		}
+
+		conds := make([]vsqlparser.Expr, 0, len(primaryKeys))
+		for _, pk := range primaryKeys {
+			conds = append(conds, &vsqlparser.ComparisonExpr{
+				Operator: vsqlparser.EqualOp,
+				Left:     &vsqlparser.ColName{Name: vsqlparser.NewColIdent(pk)},
+				Right:    values[index[strings.ToLower(pk)]],
+			})
+		}
+		update.Where = vsqlparser.NewWhere(vsqlparser.WhereClause, andExprs(conds))
+
+		statements = append(statements, vsqlparser.String(update))
+	}
+
+	return statements, nil
+}
+
+// masks mask rules of snapshot columns, nil if no column of table is masked
+func (p *rollbackPlan) masks(snapshot *rollbackSnapshot, rules []common.MaskRule, schema string) []*common.MaskRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	tbSchema := schema
+	if !p.table.Qualifier.IsEmpty() {
+		tbSchema = p.table.Qualifier.String()
+	}
+	src := &maskSource{schema: schema, tables: []maskTable{{schema: tbSchema, name: p.table.Name.String()}}}
+
+	var masks []*common.MaskRule
+	for i, col := range snapshot.columns {
+		if rule := src.columnRule(rules, i, col); rule != nil {
+			if masks == nil {
+				masks = make([]*common.MaskRule, len(snapshot.columns))
+			}
+			masks[i] = rule
+		}
+	}
+	return masks
+}
+
+// mysqlRollbackLiteral
+// value scanned by text protocol is []byte, number is kept unquoted by database type of column
+// binary column or invalid utf8 content is written as hex literal
+func mysqlRollbackLiteral(v interface{}, dbType string) vsqlparser.Expr {
+	switch r := v.(type) {
+	case nil:
+		return &vsqlparser.NullVal{}
+	case time.Time:
+		return vsqlparser.NewStrLiteral(r.Format("2006-01-02 15:04:05.999999"))
+	case []byte:
+		switch strings.ToUpper(dbType) {
+		case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT",
+			"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT", "YEAR":
+			return vsqlparser.NewIntLiteral(string(r))
+		case "DECIMAL", "FLOAT", "DOUBLE":
+			return vsqlparser.NewFloatLiteral(string(r))
+		case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+			return vsqlparser.NewHexLiteral(hex.EncodeToString(r))
+		}
+
+		if !utf8.Valid(r) {
+			return vsqlparser.NewHexLiteral(hex.EncodeToString(r))
+		}
+		return vsqlparser.NewStrLiteral(string(r))
+	}
+
+	lit, err := rowPolicyLiteral(v)
+	if err != nil {
+		return vsqlparser.NewStrLiteral(fmt.Sprint(v))
+	}
+	return lit
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func TestMySQLRollback(t *testing.T) {
+	t.Run("plan", func(t *testing.T) {
+		cases := []struct {
+			sql      string
+			snapshot string
+		}{
+			{
+				"update orders set amount = amount + 1, note = 'a' where id > 10 order by id limit 5",
+				"select * from orders where id > 10 order by id asc limit 5 for update",
+			},
+			{
+				"delete from crm.orders where uid = 1",
+				"select * from crm.orders where uid = 1 for update",
+			},
+			{
+				"delete from orders",
+				"select * from orders for update",
+			},
+		}
+
+		for _, c := range cases {
+			plan, err := mysqlRollbackPlan(c.sql)
+			require.NoError(t, err, c.sql)
+			require.Equal(t, c.snapshot, plan.snapshot, c.sql)
+		}
+
+		plan, err := mysqlRollbackPlan("insert into orders(id) values (1)")
+		require.NoError(t, err)
+		require.Nil(t, plan)
+
+		for _, sql := range []string{
+			"update orders o join users u on o.uid = u.id set o.amount = 0",
+			"delete o from orders o join users u on o.uid = u.id",
+		} {
+			_, err = mysqlRollbackPlan(sql)
+			require.ErrorIs(t, err, inerr.ErrRollbackUnsupported, sql)
+		}
+	})
+
+	snapshot := &rollbackSnapshot{
+		columns: []string{"id", "amount", "note", "data", "created_at"},
+		dbTypes: []string{"BIGINT", "DECIMAL", "VARCHAR", "BLOB", "DATETIME"},
+		rows: [][]interface{}{
+			{[]byte("1"), []byte("10.50"), []byte("it's"), []byte{0xff, 0x00}, time.Date(2023, 12, 15, 8, 0, 0, 0, time.Local)},
+			{[]byte("2"), nil, nil, nil, nil},
+		},
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		plan, err := mysqlRollbackPlan("delete from crm.orders where id < 3")
+		require.NoError(t, err)
+
+		statements, err := plan.statements(snapshot, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"insert into crm.orders(id, amount, note, `data`, created_at) values (1, 10.50, 'it\\'s', X'ff00', '2023-12-15 08:00:00')",
+			"insert into crm.orders(id, amount, note, `data`, created_at) values (2, null, null, null, null)",
+		}, statements)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		plan, err := mysqlRollbackPlan("update orders o set o.amount = 0, note = 'x' where o.id < 3")
+		require.NoError(t, err)
+
+		statements, err := plan.statements(snapshot, []string{"id"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"update orders set amount = 10.50, note = 'it\\'s' where id = 1",
+			"update orders set amount = null, note = null where id = 2",
+		}, statements)
+
+		// primary key is required
+		_, err = plan.statements(snapshot, nil, nil)
+		require.ErrorIs(t, err, inerr.ErrRollbackUnsupported)
+
+		// updated primary key can not be located
+		plan, err = mysqlRollbackPlan("update orders set id = id + 100")
+		require.NoError(t, err)
+		_, err = plan.statements(snapshot, []string{"id"}, nil)
+		require.ErrorIs(t, err, inerr.ErrRollbackUnsupported)
+	})
+
+	t.Run("mask", func(t *testing.T) {
+		plan, err := mysqlRollbackPlan("update crm.orders set note = 'x' where id < 3")
+		require.NoError(t, err)
+
+		rules := []common.MaskRule{
+			{Schema: "crm", Table: "orders", Column: "note", Strategy: common.MaskStrategyRedact},
+			{Table: "users", Column: "amount", Strategy: common.MaskStrategyRedact},
+		}
+		masks := plan.masks(snapshot, rules, "test")
+		require.Equal(t, &rules[0], masks[2])
+		require.Nil(t, masks[1])
+		require.Nil(t, plan.masks(snapshot, rules[1:], "test"))
+
+		statements, err := plan.statements(snapshot, []string{"id"}, masks)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"update crm.orders set note = '" + common.MaskRedacted + "' where id = 1",
+			"update crm.orders set note = null where id = 2",
+		}, statements)
+	})
+
+	t.Run("max rows", func(t *testing.T) {
+		eg, err := ForkSQLiteEngine(common.ConnConfig{FilePath: filepath.Join(t.TempDir(), "rollback.db")})
+		require.NoError(t, err)
+		defer eg.Close()
+
+		ctx := context.Background()
+		for _, sql := range []string{
+			"create table orders (id integer primary key, amount real)",
+			"insert into orders(id, amount) values (1, 1.5), (2, 2.5), (3, 3.5)",
+		} {
+			require.NoError(t, eg.Query(ctx, "main", "", sql, common.QueryOptions{Timeout: 5}).Err, sql)
+		}
+
+		snapshot, err := scanRollbackSnapshot(ctx, eg.driver, "select * from orders where id < ?", []common.QueryParam{
+			{Type: common.ParamTypeInt, Value: float64(3)},
+		}, 2)
+		require.NoError(t, err)
+		require.Len(t, snapshot.rows, 2)
+
+		// snapshot is refused instead of loading all affected rows
+		_, err = scanRollbackSnapshot(ctx, eg.driver, "select * from orders", nil, 2)
+		require.ErrorIs(t, err, inerr.ErrRollbackTooManyRows)
+
+		require.Equal(t, int64(common.DefaultRollbackMaxRows), common.QueryOptions{}.RollbackRowLimit())
+		require.Equal(t, int64(2), common.QueryOptions{RollbackMaxRows: 2}.RollbackRowLimit())
+	})
+}
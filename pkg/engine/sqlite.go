@@ -0,0 +1,355 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteDefaultSchema the main database of sqlite connection
+const SQLiteDefaultSchema = "main"
+
+type SQLiteEngine struct {
+	driver *gorm.DB
+	*common.EngineBase
+}
+
+func (s *SQLiteEngine) RegistryQueryPrev(hook common.PreHook) {
+	s.BindPrevHook(hook)
+}
+
+func (s *SQLiteEngine) RegistryQueryPost(hook common.PostHook) {
+	s.BindPostHook(hook)
+}
+
+func (s *SQLiteEngine) Close() error {
+	orm, err := s.driver.DB()
+	if err != nil {
+		return err
+	}
+	return orm.Close()
+}
+
+// Schema
+// schema list is fetched with hooks of fetchSchema action
+func (s *SQLiteEngine) Schema(ctx context.Context) ([]string, error) {
+	return schemaWithHooks(ctx, s.EngineBase, common.SQLiteEngine, func() ([]string, error) {
+		return s.fetchSchema(ctx)
+	})
+}
+
+// Table
+// table list is fetched with hooks of fetchTable action
+func (s *SQLiteEngine) Table(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	return tableWithHooks(ctx, s.EngineBase, common.SQLiteEngine, schema, func() (*common.TableSet, error) {
+		return s.fetchTable(ctx, schema, opt)
+	})
+}
+
+// fetchSchema
+// list attached databases, eg: main、temp
+func (s *SQLiteEngine) fetchSchema(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	rows, err := s.driver.WithContext(ctx).Raw("PRAGMA database_list;").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemas := make([]string, 0)
+	for rows.Next() {
+		var seq int
+		var name string
+		var file string
+		if err = rows.Scan(&seq, &name, &file); err != nil {
+			return nil, err
+		}
+
+		schemas = append(schemas, name)
+	}
+	return schemas, rows.Err()
+}
+
+// fetchTable
+// list tables and views of attached database from sqlite_master
+func (s *SQLiteEngine) fetchTable(ctx context.Context, schema string, opt common.QueryOptions) (*common.TableSet, error) {
+	if schema == "" {
+		return nil, inerr.ErrSchemaEmpty
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	rows, err := s.driver.WithContext(ctx).Raw(fmt.Sprintf(
+		"SELECT name FROM %s.sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%%' ORDER BY name;",
+		SQLiteQuoteIdent(schema))).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pageTables(tables, opt)
+}
+
+// Query
+// statement is classified by vitess parser as same as mysql
+func (s *SQLiteEngine) Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet {
+	queryRes := &common.QuerySet{
+		EngineType: common.SQLiteEngine,
+		Action:     common.ActionSQLQuery,
+		IsExecute:  false,
+		Err:        nil,
+	}
+
+	// execute query prev hook
+	// query prev hook failed, stop query
+	prevArgs := &common.PrevHookArgs{
+		EngineType:  common.SQLiteEngine,
+		Action:      common.ActionSQLQuery,
+		Caller:      common.CallerFromContext(ctx),
+		Schema:      schema,
+		SQL:         sql,
+		OriginalSQL: sql,
+	}
+	err := s.RunPrevHooks(prevArgs)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// statement may be rewritten by prev hooks
+	originalSQL := sql
+	sql, err = rewrittenSQL(prevArgs, opt)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// registry query post hook
+	// statement rejected by row policies or lint rules is also passed to post hooks
+	defer func() {
+		s.RunPostHooks(&common.PostHookArgs{
+			EngineType:    common.SQLiteEngine,
+			Action:        common.ActionSQLQuery,
+			Caller:        common.CallerFromContext(ctx),
+			IsExecute:     queryRes.IsExecute,
+			ExecuteAt:     queryRes.ExecuteAt,
+			QueryDuration: queryRes.QueryDuration,
+			Err:           queryRes.Err,
+			Schema:        schema,
+			SQL:           sql,
+			OriginalSQL:   originalSQL,
+			Fingerprint:   SQLiteFingerprint(sql),
+			AffectedRows:  queryRes.AffectedRows,
+		})
+	}()
+
+	// lint rules run on the statement to execute, statistics of tables are fetched within query timeout
+	lintCtx, lintCancel := context.WithTimeout(ctx, time.Duration(opt.Timeout)*time.Second)
+	queryRes.Warnings, err = lintStatement(sql, opt.LintRules, newLintContext(lintCtx, schema, s.tableStats))
+	lintCancel()
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	if schema == "" {
+		queryRes.Err = inerr.ErrSchemaEmpty
+		return queryRes
+	}
+
+	if sql == "" {
+		queryRes.Err = inerr.ErrSQLEmpty
+		return queryRes
+	}
+
+	// dry run is only supported by MySQL and Redis, statement is never executed
+	if opt.DryRun {
+		queryRes.Err = inerr.ErrDryRunUnsupported
+		return queryRes
+	}
+
+	// fetch sql type
+	sqlType, err := MySQLSQLType(sql)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	// query main
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opt.Timeout)*time.Second)
+	defer cancel()
+
+	// start query
+	queryRes.ExecuteAt = time.Now()
+
+	switch sqlType {
+	// not query statement
+	// use Exec()
+	case common.StmtInsert, common.StmtReplace, common.StmtUpdate, common.StmtDelete, common.StmtDDL:
+		queryRes.AffectedRows, queryRes.Err = execWithParams(ctx, s.driver, sql, opt.Params)
+
+		// query finished
+		queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+		queryRes.SQL = sql
+		queryRes.IsExecute = true
+
+		return queryRes
+	}
+
+	// common quey statement
+	rows, err := queryWithParams(ctx, s.driver, sql, opt.Params)
+
+	// query finished
+	queryRes.QueryDuration = time.Since(queryRes.ExecuteAt).Milliseconds()
+
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	defer rows.Close()
+
+	// executed statement is known by row stream before rows
+	queryRes.SQL = sql
+	err = scanQueryRows(rows, opt, queryRes, sql, schema)
+	if err != nil {
+		queryRes.Err = err
+		return queryRes
+	}
+
+	queryRes.IsExecute = true
+	queryRes.Err = nil
+
+	return queryRes
+}
+
+// tableStats
+// columns of table and rows estimated by sqlite_stat1, table is not scanned,
+// rows is 0 if table is not analyzed
+func (s *SQLiteEngine) tableStats(ctx context.Context, schema string, table string) (*common.TableStats, error) {
+	stats := &common.TableStats{}
+
+	err := s.driver.WithContext(ctx).Raw(
+		"select count(*) from pragma_table_info(?, ?)", table, schema).Row().Scan(&stats.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite_stat1 is created by ANALYZE
+	var analyzed int
+	err = s.driver.WithContext(ctx).Raw(fmt.Sprintf(
+		"select count(*) from %s.sqlite_master where type = 'table' and name = 'sqlite_stat1'",
+		SQLiteQuoteIdent(schema))).Row().Scan(&analyzed)
+	if err != nil || analyzed == 0 {
+		return stats, err
+	}
+
+	// the first integer of stat is the estimated rows of table
+	stat := make([]string, 0)
+	err = s.driver.WithContext(ctx).Raw(fmt.Sprintf(
+		"select stat from %s.sqlite_stat1 where tbl = ? order by idx is not null limit 1",
+		SQLiteQuoteIdent(schema)), table).Scan(&stat).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(stat) > 0 {
+		if fields := strings.Fields(stat[0]); len(fields) > 0 {
+			stats.Rows, _ = strconv.ParseInt(fields[0], 10, 64)
+		}
+	}
+
+	return stats, nil
+}
+
+// InitialDriver
+// open database file of conn.FilePath, schema is the attached database name
+func (s *SQLiteEngine) InitialDriver(conn common.ConnConfig, schema string) error {
+	cli, err := newSQLiteClient(conn.FilePath)
+	if err != nil {
+		return err
+	}
+
+	s.driver = cli
+	s.ConnConfig = conn
+	return nil
+}
+
+func (s *SQLiteEngine) Reset() {
+	err := s.Close()
+	if err != nil {
+		fmt.Printf("sqlite engine close failed:%s\n", err)
+	}
+	s.driver = nil
+	s.EngineBase = &common.EngineBase{}
+}
+
+func (s *SQLiteEngine) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orm, err := s.driver.DB()
+	if err != nil {
+		return err
+	}
+	return orm.PingContext(ctx)
+}
+
+func NewSQLiteEngine() *SQLiteEngine {
+	return &SQLiteEngine{
+		nil,
+		&common.EngineBase{},
+	}
+}
+
+func ForkSQLiteEngine(conn common.ConnConfig) (*SQLiteEngine, error) {
+	cli, err := newSQLiteClient(conn.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteEngine{
+		cli,
+		common.NewEngineBase(conn),
+	}, nil
+}
+
+func newSQLiteClient(filePath string) (*gorm.DB, error) {
+	if filePath == "" {
+		return nil, inerr.ErrSQLiteFileEmpty
+	}
+
+	db, err := gorm.Open(sqlite.Open(filePath), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// SQLiteQuoteIdent quote identifier by backtick which both sqlite and vitess parser accepted
+func SQLiteQuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"gorm.io/gorm"
+	vsqlparser "vitess.io/vitess/go/vt/sqlparser"
+)
+
+// TxEngine engine supports interactive transaction spanning multiple requests
+type TxEngine interface {
+	CacheableEngine
+
+	BeginTx(ctx context.Context) (Tx, error)
+}
+
+// Tx
+// engine bound to a dedicated connection with an open transaction, Query runs in the transaction
+// Close rollback the transaction if it is not finished, connection of engine is not closed
+type Tx interface {
+	Engine
+
+	UnbindHook()
+	Commit() error
+	Rollback() error
+}
+
+// statements end or restart transaction implicitly, or change state of connection which is returned to pool after transaction,
+// transaction is finished by Commit、Rollback only
+var txForbiddenSQLType = []common.SQLType{
+	common.StmtBegin,
+	common.StmtCommit,
+	common.StmtRollback,
+	common.StmtSavepoint,
+	common.StmtSRollback,
+	common.StmtRelease,
+	common.StmtLockTables,
+	common.StmtUnlockTables,
+	common.StmtSet,
+	common.SQLType(vsqlparser.StmtUse),
+}
+
+// sqlTx transaction of gorm driver
+type sqlTx struct {
+	CacheableEngine
+
+	mu        sync.Mutex
+	tx        *gorm.DB
+	sqlType   func(sql string) (common.SQLType, error)
+	forbidden []common.SQLType
+	finished  bool
+}
+
+// newSQLTx
+// transaction is began without request context, it is not rolled back when the request of begin finished,
+// statements are classified by sqlType of engine
+func newSQLTx(driver *gorm.DB, sqlType func(sql string) (common.SQLType, error), forbidden []common.SQLType,
+	newEngine func(tx *gorm.DB) CacheableEngine) (*sqlTx, error) {
+	tx := driver.WithContext(context.Background()).Begin()
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "begin transaction failed")
+	}
+
+	return &sqlTx{
+		CacheableEngine: newEngine(tx),
+		tx:              tx,
+		sqlType:         sqlType,
+		forbidden:       forbidden,
+	}, nil
+}
+
+// Query
+// statement can not be classified is rejected even if system intercept is ignored,
+// it may end the transaction without session knowing, empty sql is the default query of table
+func (t *sqlTx) Query(ctx context.Context, schema string, table string, sql string, opt common.QueryOptions) *common.QuerySet {
+	if strings.TrimSpace(sql) != "" {
+		sqlType, err := t.sqlType(sql)
+		if err != nil {
+			return &common.QuerySet{
+				Action: common.ActionSQLQuery,
+				Err:    errors.Wrapf(inerr.ErrTxStatementForbidden, "%s: %s", err, sql),
+			}
+		}
+
+		forbidden := sqlType == common.StmtUnknown
+		for _, forbiddenType := range t.forbidden {
+			if sqlType == forbiddenType {
+				forbidden = true
+				break
+			}
+		}
+		if forbidden {
+			return &common.QuerySet{
+				Action: common.ActionSQLQuery,
+				Err:    errors.Wrap(inerr.ErrTxStatementForbidden, sql),
+			}
+		}
+	}
+
+	return t.CacheableEngine.Query(ctx, schema, table, sql, opt)
+}
+
+func (t *sqlTx) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished {
+		return inerr.ErrTxFinished
+	}
+	t.finished = true
+	return t.tx.Commit().Error
+}
+
+func (t *sqlTx) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished {
+		return inerr.ErrTxFinished
+	}
+	t.finished = true
+	return t.tx.Rollback().Error
+}
+
+// Close rollback unfinished transaction, driver of engine is shared and not closed
+func (t *sqlTx) Close() error {
+	err := t.Rollback()
+	if errors.Is(err, inerr.ErrTxFinished) {
+		return nil
+	}
+	return err
+}
+
+// BeginTx
+// DDL commit transaction implicitly in MySQL, it is not allowed in transaction
+func (m *MySQLEngine) BeginTx(ctx context.Context) (Tx, error) {
+	forbidden := append([]common.SQLType{common.StmtDDL}, txForbiddenSQLType...)
+	return newSQLTx(m.driver, MySQLSQLType, forbidden, func(tx *gorm.DB) CacheableEngine {
+		return &MySQLEngine{tx, &common.EngineBase{ConnConfig: m.ConnConfig}}
+	})
+}
+
+// BeginTx DDL of PostgreSQL is transactional, locks taken by SELECT ... FOR UPDATE or LOCK TABLE are released
+// when transaction finished, so they are allowed in transaction
+func (p *PostgresEngine) BeginTx(ctx context.Context) (Tx, error) {
+	forbidden := make([]common.SQLType, 0, len(txForbiddenSQLType))
+	for _, sqlType := range txForbiddenSQLType {
+		if sqlType != common.StmtLockTables {
+			forbidden = append(forbidden, sqlType)
+		}
+	}
+	return newSQLTx(p.driver, PostgresSQLType, forbidden, func(tx *gorm.DB) CacheableEngine {
+		return &PostgresEngine{tx, &common.EngineBase{ConnConfig: p.ConnConfig}}
+	})
+}
+
+// BeginTx database file is locked by write statement until transaction finished
+func (s *SQLiteEngine) BeginTx(ctx context.Context) (Tx, error) {
+	return newSQLTx(s.driver, MySQLSQLType, txForbiddenSQLType, func(tx *gorm.DB) CacheableEngine {
+		return &SQLiteEngine{tx, &common.EngineBase{ConnConfig: s.ConnConfig}}
+	})
+}
@@ -0,0 +1,303 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+var contentTypes = map[string]string{
+	common.ExportFormatCSV:    "text/csv; charset=utf-8",
+	common.ExportFormatNDJSON: "application/x-ndjson; charset=utf-8",
+	common.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	common.ExportFormatSQL:    "application/sql; charset=utf-8",
+}
+
+var invalidFileNameChar = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Valid check export format is supported
+func Valid(format string) error {
+	if _, ok := contentTypes[format]; !ok {
+		return errors.Wrap(inerr.ErrExportFormatUnsupported, format)
+	}
+	return nil
+}
+
+// ValidEngine check export format is supported by engine
+// INSERT statement is only supported by sql engine
+func ValidEngine(format string, engineType string) error {
+	if err := Valid(format); err != nil {
+		return err
+	}
+
+	if format == common.ExportFormatSQL && sqlQuoteChar(engineType) == "" {
+		return errors.Wrapf(inerr.ErrExportFormatUnsupported, "%s of %s", format, engineType)
+	}
+	return nil
+}
+
+// ContentType content type of export file
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// FileName name of export file, eg: user_20231215100000.csv
+func FileName(table string, format string) string {
+	name := invalidFileNameChar.ReplaceAllString(table, "_")
+	if strings.Trim(name, "_.") == "" {
+		name = "query"
+	}
+
+	return fmt.Sprintf("%s_%s.%s", name, time.Now().Format("20060102150405"), format)
+}
+
+// RowWriter write rows of query result to export file one by one, file is completed by Close
+type RowWriter interface {
+	WriteRow(row common.Row) error
+	Close() error
+}
+
+// NewRowWriter
+// create writer of format with columns of query set, header of file is written, eg: column names of csv
+// table is the table name of INSERT statement
+func NewRowWriter(w io.Writer, format string, table string, qs *common.QuerySet) (RowWriter, error) {
+	switch format {
+	case common.ExportFormatCSV:
+		return newCSVWriter(w, qs)
+	case common.ExportFormatNDJSON:
+		return &ndjsonWriter{bw: bufio.NewWriter(w), cols: qs.Columns}, nil
+	case common.ExportFormatXLSX:
+		return newXLSXWriter(w, qs)
+	case common.ExportFormatSQL:
+		return newSQLWriter(w, table, qs)
+	}
+
+	return nil, errors.Wrap(inerr.ErrExportFormatUnsupported, format)
+}
+
+// Write write rows of query set to w by format row by row
+// table is the table name of INSERT statement
+func Write(w io.Writer, format string, table string, qs *common.QuerySet) error {
+	rw, err := NewRowWriter(w, format, table, qs)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range qs.Rows {
+		if err = rw.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return rw.Close()
+}
+
+type csvWriter struct {
+	cw     *csv.Writer
+	cols   []string
+	record []string
+}
+
+func newCSVWriter(w io.Writer, qs *common.QuerySet) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(qs.Columns); err != nil {
+		return nil, err
+	}
+
+	return &csvWriter{cw: cw, cols: qs.Columns, record: make([]string, len(qs.Columns))}, nil
+}
+
+func (c *csvWriter) WriteRow(row common.Row) error {
+	for i, col := range c.cols {
+		c.record[i] = cellString(row[col])
+	}
+	return c.cw.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// ndjsonWriter one json object per line, keys keep the order of columns
+type ndjsonWriter struct {
+	bw   *bufio.Writer
+	cols []string
+}
+
+func (n *ndjsonWriter) WriteRow(row common.Row) error {
+	n.bw.WriteByte('{')
+	for i, col := range n.cols {
+		if i > 0 {
+			n.bw.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(col)
+		val, err := json.Marshal(row[col])
+		if err != nil {
+			return err
+		}
+
+		n.bw.Write(key)
+		n.bw.WriteByte(':')
+		n.bw.Write(val)
+	}
+	_, err := n.bw.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.bw.Flush()
+}
+
+type sqlWriter struct {
+	bw         *bufio.Writer
+	prefix     string
+	cols       []string
+	binary     []bool
+	engineType string
+}
+
+func newSQLWriter(w io.Writer, table string, qs *common.QuerySet) (*sqlWriter, error) {
+	quote := sqlQuoteChar(qs.EngineType)
+	if quote == "" {
+		return nil, errors.Wrap(inerr.ErrExportFormatUnsupported, common.ExportFormatSQL)
+	}
+
+	if table == "" {
+		return nil, inerr.ErrTableEmpty
+	}
+
+	cols := make([]string, 0, len(qs.Columns))
+	for _, col := range qs.Columns {
+		cols = append(cols, quoteIdent(col, quote))
+	}
+
+	// schema qualified table, eg: public.user
+	parts := strings.Split(table, ".")
+	for i := range parts {
+		parts[i] = quoteIdent(parts[i], quote)
+	}
+
+	// binary columns are known by column types, masked value is written as string
+	binary := make([]bool, len(qs.Columns))
+	for i, ct := range qs.ColumnTypes {
+		if i < len(binary) {
+			binary[i] = ct.Kind == common.ColumnKindBytes && !ct.Masked
+		}
+	}
+
+	return &sqlWriter{
+		bw:         bufio.NewWriter(w),
+		prefix:     fmt.Sprintf("INSERT INTO %s (%s) VALUES (", strings.Join(parts, "."), strings.Join(cols, ", ")),
+		cols:       qs.Columns,
+		binary:     binary,
+		engineType: qs.EngineType,
+	}, nil
+}
+
+func (s *sqlWriter) WriteRow(row common.Row) error {
+	s.bw.WriteString(s.prefix)
+	for i, col := range s.cols {
+		if i > 0 {
+			s.bw.WriteString(", ")
+		}
+		if literal, ok := binaryLiteral(row[col], s.engineType); s.binary[i] && ok {
+			s.bw.WriteString(literal)
+			continue
+		}
+		s.bw.WriteString(sqlLiteral(row[col], s.engineType))
+	}
+	_, err := s.bw.WriteString(");\n")
+	return err
+}
+
+func (s *sqlWriter) Close() error {
+	return s.bw.Flush()
+}
+
+func sqlQuoteChar(engineType string) string {
+	switch engineType {
+	case common.MySQLEngine, common.SQLiteEngine:
+		return "`"
+	case common.PostgresEngine:
+		return `"`
+	}
+	return ""
+}
+
+func quoteIdent(name string, quote string) string {
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+func sqlLiteral(v interface{}, engineType string) string {
+	switch r := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if r {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(r)
+	}
+
+	s := cellString(v)
+	// mysql treat backslash as escape character in string literal
+	if engineType == common.MySQLEngine {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// binaryLiteral
+// binary value which is not utf8 is rendered as hex string start with 0x, it is written as hex literal to keep the bytes,
+// X'..' for MySQL、SQLite and '\x..' of bytea for PostgreSQL
+func binaryLiteral(v interface{}, engineType string) (string, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, "0x") {
+		return "", false
+	}
+	if _, err := hex.DecodeString(s[2:]); err != nil {
+		return "", false
+	}
+
+	if engineType == common.PostgresEngine {
+		return `'\x` + s[2:] + "'", true
+	}
+	return "X'" + s[2:] + "'", true
+}
+
+// cellString display string of value in csv and xlsx, NULL is empty
+func cellString(v interface{}) string {
+	switch r := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return r
+	case []byte:
+		return string(r)
+	case bool:
+		return strconv.FormatBool(r)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(r)
+	}
+
+	// document and array of mongodb, result of redis command
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
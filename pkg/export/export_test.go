@@ -0,0 +1,137 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+func mockQuerySet(engineType string) *common.QuerySet {
+	return &common.QuerySet{
+		EngineType: engineType,
+		Columns:    []string{"id", "name", "enabled", "remark"},
+		Rows: []common.Row{
+			{"id": int64(1), "name": "li", "enabled": true, "remark": `it's "ok"`},
+			{"id": int64(2), "name": "wang", "enabled": false, "remark": nil},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, Write(buf, common.ExportFormatCSV, "", mockQuerySet(common.MySQLEngine)))
+		require.Equal(t, "id,name,enabled,remark\n1,li,true,\"it's \"\"ok\"\"\"\n2,wang,false,\n", buf.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, Write(buf, common.ExportFormatNDJSON, "", mockQuerySet(common.MySQLEngine)))
+		require.Equal(t, `{"id":1,"name":"li","enabled":true,"remark":"it's \"ok\""}`+"\n"+
+			`{"id":2,"name":"wang","enabled":false,"remark":null}`+"\n", buf.String())
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, Write(buf, common.ExportFormatSQL, "user", mockQuerySet(common.MySQLEngine)))
+		require.Equal(t, "INSERT INTO `user` (`id`, `name`, `enabled`, `remark`) VALUES (1, 'li', TRUE, 'it''s \"ok\"');\n"+
+			"INSERT INTO `user` (`id`, `name`, `enabled`, `remark`) VALUES (2, 'wang', FALSE, NULL);\n", buf.String())
+
+		buf.Reset()
+		require.NoError(t, Write(buf, common.ExportFormatSQL, "public.user", mockQuerySet(common.PostgresEngine)))
+		require.True(t, strings.HasPrefix(buf.String(), `INSERT INTO "public"."user" ("id", "name", "enabled", "remark")`), buf.String())
+
+		require.Equal(t, inerr.ErrTableEmpty, Write(buf, common.ExportFormatSQL, "", mockQuerySet(common.MySQLEngine)))
+
+		// binary value not utf8 is written as hex literal, masked or utf8 value is string
+		qs := &common.QuerySet{
+			EngineType: common.MySQLEngine,
+			Columns:    []string{"data", "text", "secret"},
+			ColumnTypes: []common.ColumnMeta{
+				{Name: "data", Kind: common.ColumnKindBytes},
+				{Name: "text", Kind: common.ColumnKindBytes},
+				{Name: "secret", Kind: common.ColumnKindBytes, Masked: true},
+			},
+			Rows: []common.Row{{"data": "0xff00", "text": "abc", "secret": "0x****"}},
+		}
+		buf.Reset()
+		require.NoError(t, Write(buf, common.ExportFormatSQL, "t", qs))
+		require.Equal(t, "INSERT INTO `t` (`data`, `text`, `secret`) VALUES (X'ff00', 'abc', '0x****');\n", buf.String())
+
+		qs.EngineType = common.PostgresEngine
+		buf.Reset()
+		require.NoError(t, Write(buf, common.ExportFormatSQL, "t", qs))
+		require.Equal(t, `INSERT INTO "t" ("data", "text", "secret") VALUES ('\xff00', 'abc', '0x****');`+"\n", buf.String())
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, Write(buf, common.ExportFormatXLSX, "", mockQuerySet(common.MySQLEngine)))
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		parts := make(map[string]string)
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			content, err := ioutil.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+
+			parts[f.Name] = string(content)
+		}
+
+		require.Contains(t, parts, "[Content_Types].xml")
+		require.Contains(t, parts, "xl/workbook.xml")
+
+		sheet := parts["xl/worksheets/sheet1.xml"]
+		require.Contains(t, sheet, `<c r="A2"><v>1</v></c>`)
+		require.Contains(t, sheet, `<c r="C2" t="b"><v>1</v></c>`)
+		require.Contains(t, sheet, `<c r="D2" t="inlineStr"><is><t xml:space="preserve">it&#39;s &#34;ok&#34;</t></is></c>`)
+		require.NotContains(t, sheet, `r="D3"`)
+	})
+}
+
+func TestNewRowWriter(t *testing.T) {
+	// header is written before any row, rows are written one by one
+	qs := mockQuerySet(common.MySQLEngine)
+	buf := &bytes.Buffer{}
+	rw, err := NewRowWriter(buf, common.ExportFormatCSV, "", qs)
+	require.NoError(t, err)
+	require.NoError(t, rw.Close())
+	require.Equal(t, "id,name,enabled,remark\n", buf.String())
+
+	buf.Reset()
+	rw, err = NewRowWriter(buf, common.ExportFormatSQL, "user", qs)
+	require.NoError(t, err)
+	for _, row := range qs.Rows {
+		require.NoError(t, rw.WriteRow(row))
+	}
+	require.NoError(t, rw.Close())
+	require.Equal(t, 2, strings.Count(buf.String(), "INSERT INTO `user`"))
+
+	_, err = NewRowWriter(buf, common.ExportFormatSQL, "", qs)
+	require.Equal(t, inerr.ErrTableEmpty, err)
+}
+
+func TestValidEngine(t *testing.T) {
+	require.NoError(t, ValidEngine(common.ExportFormatCSV, common.RedisEngine))
+	require.NoError(t, ValidEngine(common.ExportFormatSQL, common.SQLiteEngine))
+	require.Error(t, ValidEngine(common.ExportFormatSQL, common.MongoDBEngine))
+	require.Error(t, ValidEngine("pdf", common.MySQLEngine))
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	require.Equal(t, "A", xlsxColumnName(0))
+	require.Equal(t, "Z", xlsxColumnName(25))
+	require.Equal(t, "AA", xlsxColumnName(26))
+	require.Equal(t, "AZ", xlsxColumnName(51))
+	require.Equal(t, "BA", xlsxColumnName(52))
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+)
+
+// minimal parts of a workbook with one sheet
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// xlsxWriter
+// write a workbook with one sheet, first row is column names
+// number and bool are written as typed cell, others as inline string
+type xlsxWriter struct {
+	zw     *zip.Writer
+	bw     *bufio.Writer
+	cols   []string
+	rowNum int
+}
+
+func newXLSXWriter(w io.Writer, qs *common.QuerySet) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+
+	for _, part := range xlsxStaticParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriter(f)
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	bw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make(common.Row, len(qs.Columns))
+	for _, col := range qs.Columns {
+		header[col] = col
+	}
+	if err = writeXLSXRow(bw, 1, qs.Columns, header); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{zw: zw, bw: bw, cols: qs.Columns, rowNum: 1}, nil
+}
+
+func (x *xlsxWriter) WriteRow(row common.Row) error {
+	x.rowNum++
+	return writeXLSXRow(x.bw, x.rowNum, x.cols, row)
+}
+
+func (x *xlsxWriter) Close() error {
+	x.bw.WriteString(`</sheetData></worksheet>`)
+	if err := x.bw.Flush(); err != nil {
+		return err
+	}
+
+	return x.zw.Close()
+}
+
+// writeXLSXRow error of bufio writer is kept, so it is returned by the last write
+func writeXLSXRow(bw *bufio.Writer, rowNum int, cols []string, row common.Row) error {
+	fmt.Fprintf(bw, `<row r="%d">`, rowNum)
+	for i, col := range cols {
+		ref := xlsxColumnName(i) + strconv.Itoa(rowNum)
+
+		switch v := row[col].(type) {
+		case nil:
+			// empty cell for NULL
+			continue
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(bw, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fmt.Fprintf(bw, `<c r="%s"><v>%v</v></c>`, ref, v)
+		default:
+			fmt.Fprintf(bw, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(bw, []byte(cellString(v)))
+			bw.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := bw.WriteString(`</row>`)
+	return err
+}
+
+// xlsxColumnName 0 -> A, 25 -> Z, 26 -> AA
+func xlsxColumnName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
@@ -7,12 +7,20 @@ var ErrEngineTypeUnknown = errors.New("engine type unknown")
 var ErrUnsupportedMediaType = errors.New("http server not support media type")
 var ErrUnsupportedOperation = errors.New("console component not support operation type")
 
+var ErrEngineCacheFull = errors.New("engine cache reach max open limit")
+var ErrEngineCacheClosed = errors.New("engine cache closed")
+
+var ErrHookPanic = errors.New("hook panic")
+
 var ErrFieldEmpty = errors.New("field is empty")
+var ErrCursorInvalid = errors.New("page cursor invalid")
 
 var ErrSchemaEmpty = errors.New("schema should be provided")
 var ErrTableEmpty = errors.New("table should be provided")
 var ErrSQLEmpty = errors.New("SQL statement should be provided")
 var ErrSQLForbidden = errors.New("SQL statement forbidden")
+var ErrSQLMultiStatement = errors.New("multi SQL statement not supported")
+var ErrSQLNotClosed = errors.New("SQL statement quote or comment not closed")
 
 var ErrRedisCMDUnknown = errors.New("redis cmd unknown")
 var ErrRedisCMDUnSupported = errors.New("redis command unsupported now")
@@ -23,4 +31,50 @@ var ErrRedisKeyEmpty = errors.New("key should be provided")
 var ErrRedisParseKey = errors.New("parse key from redis command failed")
 var ErrRedisSchemaFetchFailed = errors.New("redis schema fetch failed")
 
+var ErrSQLiteFileEmpty = errors.New("sqlite database file path should be provided")
+
+var ErrMongoCMDEmpty = errors.New("mongodb command should be provided")
+var ErrMongoCMDParse = errors.New("parse mongodb command failed")
+var ErrMongoCMDUnSupported = errors.New("mongodb command unsupported now")
+var ErrMongoCMDForbidden = errors.New("mongodb command forbidden")
+
+var ErrExportFormatUnsupported = errors.New("export format unsupported")
+var ErrExportNotSingleTable = errors.New("INSERT statement is only exported from select over a single table")
+
+var ErrTicketStoreNotSet = errors.New("ticket store is not set")
+var ErrTicketNotFound = errors.New("ticket not found")
+var ErrTicketStatus = errors.New("ticket status not allowed")
+var ErrTicketPrincipalEmpty = errors.New("caller principal is required by ticket")
+var ErrTicketSelfApprove = errors.New("ticket can not be approved or rejected by submitter")
+var ErrTicketMismatch = errors.New("ticket belongs to other console or instance")
+var ErrTicketUnsupported = errors.New("ticket is not supported by console")
+var ErrTicketNotRequired = errors.New("statement is allowed by AllowSQLType, ticket is not required")
+
+var ErrRollbackUnsupported = errors.New("statement is not supported by rollback generation")
+var ErrRollbackTooManyRows = errors.New("affected rows exceed max rows of rollback snapshot")
+
+var ErrDryRunUnsupported = errors.New("dry run is not supported by statement")
+
+var ErrTxUnsupported = errors.New("transaction is not supported by console")
+var ErrTxFinished = errors.New("transaction is already committed or rolled back")
+var ErrTxStatementForbidden = errors.New("statement is not allowed in transaction, use commitTx or rollbackTx action to finish it")
+var ErrTxSessionNotFound = errors.New("transaction session not found or expired")
+var ErrTxSessionMismatch = errors.New("transaction session belongs to other caller, console or schema")
+
+var ErrScriptUnsupported = errors.New("script is not supported by console")
+
+var ErrDescribeUnsupported = errors.New("table metadata is not supported by console")
+var ErrCompletionUnsupported = errors.New("completion is not supported by console")
+var ErrFormatUnsupported = errors.New("format is not supported by console or statement")
+var ErrTableNotFound = errors.New("table not found")
+
+var ErrParamInvalid = errors.New("bind param is invalid")
+var ErrParamMissing = errors.New("bind param is missing")
+var ErrParamUnsupported = errors.New("bind param is not supported by engine")
+
+var ErrRowPolicyInvalid = errors.New("row policy invalid")
+var ErrSQLLintRejected = errors.New("SQL statement rejected by lint rules")
+
+var ErrRowPolicyUnsupported = errors.New("statement on protected table is not supported by row policy")
+
 var ErrConsolePathNotSupport = errors.New("console router path can not container '*' or ':' when console serving a static folder in console internal")
@@ -0,0 +1,103 @@
+package ticket
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+)
+
+// memoryStore tickets are lost after restart, used by test or single instance
+type memoryStore struct {
+	mu      sync.RWMutex
+	tickets map[string]*common.Ticket
+}
+
+// NewMemoryStore
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		tickets: map[string]*common.Ticket{},
+	}
+}
+
+func (m *memoryStore) Create(ctx context.Context, ticket *common.Ticket) error {
+	if err := prepare(ticket); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// copy is stored, modification of caller is not visible
+	stored := *ticket
+	m.tickets[ticket.ID] = &stored
+	return nil
+}
+
+func (m *memoryStore) Get(ctx context.Context, id string) (*common.Ticket, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.tickets[id]
+	if !ok {
+		return nil, inerr.ErrTicketNotFound
+	}
+
+	ticket := *stored
+	return &ticket, nil
+}
+
+// List order by create time desc
+func (m *memoryStore) List(ctx context.Context, filter common.TicketFilter) ([]*common.Ticket, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tickets := make([]*common.Ticket, 0)
+	for _, stored := range m.tickets {
+		if !match(stored, filter) {
+			continue
+		}
+
+		ticket := *stored
+		tickets = append(tickets, &ticket)
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		if tickets[i].CreatedAt.Equal(tickets[j].CreatedAt) {
+			return tickets[i].ID > tickets[j].ID
+		}
+		return tickets[i].CreatedAt.After(tickets[j].CreatedAt)
+	})
+	return tickets, nil
+}
+
+func (m *memoryStore) Update(ctx context.Context, ticket *common.Ticket, fromStatus string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.tickets[ticket.ID]
+	if !ok {
+		return inerr.ErrTicketNotFound
+	}
+
+	if stored.Status != fromStatus {
+		return inerr.ErrTicketStatus
+	}
+
+	// only status and review、execution result are changed, same as sqlStore
+	updated := *stored
+	updated.Status = ticket.Status
+	updated.Reviewer = ticket.Reviewer
+	updated.ReviewComment = ticket.ReviewComment
+	updated.Executor = ticket.Executor
+	updated.AffectedRows = ticket.AffectedRows
+	updated.Error = ticket.Error
+	updated.UpdatedAt = time.Now()
+	m.tickets[ticket.ID] = &updated
+
+	ticket.UpdatedAt = updated.UpdatedAt
+	return nil
+}
@@ -0,0 +1,181 @@
+package ticket
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"gorm.io/gorm"
+)
+
+// DefaultTableName table of tickets if not set
+const DefaultTableName = "console_ticket"
+
+// ticketRow row of ticket table, time is stored as unix milliseconds for all dialects
+type ticketRow struct {
+	ID            string `gorm:"column:id;primaryKey;size:32"`
+	ConsoleType   string `gorm:"column:console_type;size:32;index"`
+	Instance      string `gorm:"column:instance;size:255"`
+	Schema        string `gorm:"column:schema_name;size:255"`
+	Table         string `gorm:"column:table_name;size:255"`
+	SQL           string `gorm:"column:sql_text;type:text"`
+	Comment       string `gorm:"column:comment;type:text"`
+	Status        string `gorm:"column:status;size:16;index"`
+	Submitter     string `gorm:"column:submitter;size:255"`
+	Reviewer      string `gorm:"column:reviewer;size:255"`
+	ReviewComment string `gorm:"column:review_comment;type:text"`
+	Executor      string `gorm:"column:executor;size:255"`
+	AffectedRows  int64  `gorm:"column:affected_rows"`
+	Error         string `gorm:"column:error;type:text"`
+	CreatedAt     int64  `gorm:"column:created_at;autoCreateTime:false;index"`
+	UpdatedAt     int64  `gorm:"column:updated_at;autoUpdateTime:false"`
+}
+
+func toRow(ticket *common.Ticket) *ticketRow {
+	return &ticketRow{
+		ID:            ticket.ID,
+		ConsoleType:   ticket.ConsoleType,
+		Instance:      ticket.Instance,
+		Schema:        ticket.Schema,
+		Table:         ticket.Table,
+		SQL:           ticket.SQL,
+		Comment:       ticket.Comment,
+		Status:        ticket.Status,
+		Submitter:     ticket.Submitter,
+		Reviewer:      ticket.Reviewer,
+		ReviewComment: ticket.ReviewComment,
+		Executor:      ticket.Executor,
+		AffectedRows:  ticket.AffectedRows,
+		Error:         ticket.Error,
+		CreatedAt:     ticket.CreatedAt.UnixNano() / int64(time.Millisecond),
+		UpdatedAt:     ticket.UpdatedAt.UnixNano() / int64(time.Millisecond),
+	}
+}
+
+func (r *ticketRow) toTicket() *common.Ticket {
+	return &common.Ticket{
+		ID:            r.ID,
+		ConsoleType:   r.ConsoleType,
+		Instance:      r.Instance,
+		Schema:        r.Schema,
+		Table:         r.Table,
+		SQL:           r.SQL,
+		Comment:       r.Comment,
+		Status:        r.Status,
+		Submitter:     r.Submitter,
+		Reviewer:      r.Reviewer,
+		ReviewComment: r.ReviewComment,
+		Executor:      r.Executor,
+		AffectedRows:  r.AffectedRows,
+		Error:         r.Error,
+		CreatedAt:     time.Unix(0, r.CreatedAt*int64(time.Millisecond)),
+		UpdatedAt:     time.Unix(0, r.UpdatedAt*int64(time.Millisecond)),
+	}
+}
+
+// sqlStore tickets stored in table of mysql、postgres、sqlite, shared by multiple instances
+type sqlStore struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewSQLStore
+// db is opened by caller, table is created if not exists
+// DefaultTableName is used if table is empty
+func NewSQLStore(db *gorm.DB, table string) (*sqlStore, error) {
+	if table == "" {
+		table = DefaultTableName
+	}
+
+	s := &sqlStore{
+		db:    db,
+		table: table,
+	}
+
+	if err := s.db.Table(s.table).AutoMigrate(&ticketRow{}); err != nil {
+		return nil, errors.Wrap(err, "migrate ticket table failed")
+	}
+	return s, nil
+}
+
+func (s *sqlStore) Create(ctx context.Context, ticket *common.Ticket) error {
+	if err := prepare(ticket); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Table(s.table).Create(toRow(ticket)).Error; err != nil {
+		return errors.Wrap(err, "create ticket failed")
+	}
+	return nil
+}
+
+func (s *sqlStore) Get(ctx context.Context, id string) (*common.Ticket, error) {
+	rows := make([]*ticketRow, 0)
+	err := s.db.WithContext(ctx).Table(s.table).Where("id = ?", id).Limit(1).Find(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "get ticket failed")
+	}
+
+	if len(rows) == 0 {
+		return nil, inerr.ErrTicketNotFound
+	}
+	return rows[0].toTicket(), nil
+}
+
+// List order by create time desc
+func (s *sqlStore) List(ctx context.Context, filter common.TicketFilter) ([]*common.Ticket, error) {
+	db := s.db.WithContext(ctx).Table(s.table)
+	if filter.ConsoleType != "" {
+		db = db.Where("console_type = ?", filter.ConsoleType)
+	}
+	if filter.Instance != "" {
+		db = db.Where("instance = ?", filter.Instance)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	rows := make([]*ticketRow, 0)
+	if err := db.Order("created_at desc, id desc").Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "list ticket failed")
+	}
+
+	tickets := make([]*common.Ticket, 0, len(rows))
+	for _, row := range rows {
+		tickets = append(tickets, row.toTicket())
+	}
+	return tickets, nil
+}
+
+// Update
+// status is checked in where clause, so concurrent update of same ticket succeed only once
+func (s *sqlStore) Update(ctx context.Context, ticket *common.Ticket, fromStatus string) error {
+	ticket.UpdatedAt = time.Now()
+	row := toRow(ticket)
+
+	res := s.db.WithContext(ctx).Table(s.table).
+		Where("id = ? and status = ?", ticket.ID, fromStatus).
+		Updates(map[string]interface{}{
+			"status":         row.Status,
+			"reviewer":       row.Reviewer,
+			"review_comment": row.ReviewComment,
+			"executor":       row.Executor,
+			"affected_rows":  row.AffectedRows,
+			"error":          row.Error,
+			"updated_at":     row.UpdatedAt,
+		})
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "update ticket failed")
+	}
+
+	if res.RowsAffected == 0 {
+		// ticket not exists or status is changed by others
+		if _, err := s.Get(ctx, ticket.ID); err != nil {
+			return err
+		}
+		return inerr.ErrTicketStatus
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package ticket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+)
+
+// NewID random id of ticket
+func NewID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "generate ticket id failed")
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// prepare fill id and time of new ticket
+func prepare(ticket *common.Ticket) error {
+	if ticket.ID == "" {
+		id, err := NewID()
+		if err != nil {
+			return err
+		}
+		ticket.ID = id
+	}
+
+	now := time.Now()
+	if ticket.CreatedAt.IsZero() {
+		ticket.CreatedAt = now
+	}
+	ticket.UpdatedAt = now
+
+	if ticket.Status == "" {
+		ticket.Status = common.TicketPending
+	}
+	return nil
+}
+
+// match ticket match filter or not
+func match(ticket *common.Ticket, filter common.TicketFilter) bool {
+	return (filter.ConsoleType == "" || filter.ConsoleType == ticket.ConsoleType) &&
+		(filter.Instance == "" || filter.Instance == ticket.Instance) &&
+		(filter.Status == "" || filter.Status == ticket.Status)
+}
@@ -0,0 +1,84 @@
+package ticket
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestSQLStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "ticket.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	store, err := NewSQLStore(db, "")
+	require.NoError(t, err)
+	testStore(t, store)
+
+	// table exists
+	_, err = NewSQLStore(db, "")
+	require.NoError(t, err)
+}
+
+func testStore(t *testing.T, store common.TicketStore) {
+	ctx := context.Background()
+
+	first := &common.Ticket{ConsoleType: common.SQLiteConsole, Instance: "a.db", SQL: "delete from t where id = 1", Submitter: "alice"}
+	require.NoError(t, store.Create(ctx, first))
+	require.NotEmpty(t, first.ID)
+	require.Equal(t, common.TicketPending, first.Status)
+
+	second := &common.Ticket{ConsoleType: common.MySQLConsole, Instance: "127.0.0.1:3306", SQL: "drop table t", Submitter: "bob",
+		CreatedAt: first.CreatedAt.Add(time.Second)}
+	require.NoError(t, store.Create(ctx, second))
+
+	got, err := store.Get(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first.SQL, got.SQL)
+	require.Equal(t, first.CreatedAt.UnixNano()/int64(time.Millisecond), got.CreatedAt.UnixNano()/int64(time.Millisecond))
+
+	_, err = store.Get(ctx, "not-exists")
+	require.ErrorIs(t, err, inerr.ErrTicketNotFound)
+
+	// newest first
+	tickets, err := store.List(ctx, common.TicketFilter{})
+	require.NoError(t, err)
+	require.Len(t, tickets, 2)
+	require.Equal(t, second.ID, tickets[0].ID)
+
+	tickets, err = store.List(ctx, common.TicketFilter{ConsoleType: common.SQLiteConsole, Status: common.TicketPending})
+	require.NoError(t, err)
+	require.Len(t, tickets, 1)
+	require.Equal(t, first.ID, tickets[0].ID)
+
+	// compare and set by status
+	got.Status = common.TicketApproved
+	got.Reviewer = "bob"
+	got.SQL = "delete from t"
+	require.NoError(t, store.Update(ctx, got, common.TicketPending))
+	require.ErrorIs(t, store.Update(ctx, got, common.TicketPending), inerr.ErrTicketStatus)
+
+	got, err = store.Get(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, common.TicketApproved, got.Status)
+	require.Equal(t, "bob", got.Reviewer)
+	// statement of ticket can not be changed by update
+	require.Equal(t, first.SQL, got.SQL)
+
+	tickets, err = store.List(ctx, common.TicketFilter{Status: common.TicketPending})
+	require.NoError(t, err)
+	require.Len(t, tickets, 1)
+	require.Equal(t, second.ID, tickets[0].ID)
+
+	require.ErrorIs(t, store.Update(ctx, &common.Ticket{ID: "not-exists"}, common.TicketPending), inerr.ErrTicketNotFound)
+}
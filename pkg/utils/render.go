@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"mime"
 	"net/http"
 
 	"github.com/ylh990835774/ay-go-components/pkg/common"
@@ -39,6 +40,19 @@ func RenderErr(w http.ResponseWriter, err error) {
 	writeJSON(w, resp)
 }
 
+// RenderErrWithData render error with code and structured result, eg: warnings of rejected statement
+func RenderErrWithData(w http.ResponseWriter, code int, err error, data interface{}) {
+	w.WriteHeader(http.StatusOK)
+
+	resp := &common.Resp{
+		Code:    code,
+		Message: err.Error(),
+		Result:  data,
+	}
+
+	writeJSON(w, resp)
+}
+
 func RenderData(w http.ResponseWriter, msg string, data interface{}) {
 	w.WriteHeader(http.StatusOK)
 
@@ -50,3 +64,11 @@ func RenderData(w http.ResponseWriter, msg string, data interface{}) {
 
 	writeJSON(w, resp)
 }
+
+// RenderAttachment write header of downloadable file, body is written by caller
+func RenderAttachment(w http.ResponseWriter, fileName string, contentType string) {
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/console"
+)
+
+func TestMongoDBConsoleWithDefaultOpt(t *testing.T) {
+	mongoConsole := console.NewMongoDBConsole()
+
+	opt := &common.HandlerOptions{
+		Conn: common.ConnConfig{
+			IP:   "172.168.2.24",
+			Port: 27017,
+		},
+	}
+
+	t.Run("fetch schema", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchSchema,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		mockHTTPReq(t, mongoConsole, opt, reqBody, "/console/mongodb")
+	})
+
+	t.Run("fetch table", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchTable,
+			Schema: "alarm_server_local",
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		mockHTTPReq(t, mongoConsole, opt, reqBody, "/console/mongodb")
+	})
+
+	t.Run("mongodb query safe command", func(t *testing.T) {
+		SQLList := []string{
+			`db.alert.find({})`,
+			`db.alert.find({severity: {$gte: 2}}, {name: 1}).sort({_id: -1}).limit(10)`,
+			`db.alert.findOne({_id: ObjectId("5f1d7f1b2a3c4d5e6f708192")})`,
+			`db.alert.aggregate([{$group: {_id: "$severity", n: {$sum: 1}}}])`,
+			`db.alert.countDocuments({})`,
+			`db.alert.distinct("severity")`,
+		}
+
+		for _, sql := range SQLList {
+			fakeQueryMeta := &common.QueryMeta{
+				Action: common.ActionSQLQuery,
+				Schema: "alarm_server_local",
+				Table:  "alert",
+				SQL:    SQLBase64(sql),
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			mockHTTPReq(t, mongoConsole, opt, reqBody, "/console/mongodb")
+		}
+	})
+
+	t.Run("mongodb query forbidden command", func(t *testing.T) {
+		SQLList := []string{
+			`db.alert.insertOne({name: "li"})`,
+			`db.alert.updateMany({}, {$set: {severity: 1}})`,
+			`db.alert.deleteMany({})`,
+			`db.alert.aggregate([{$out: "alert_bak"}])`,
+		}
+
+		for _, sql := range SQLList {
+			fakeQueryMeta := &common.QueryMeta{
+				Action: common.ActionSQLQuery,
+				Schema: "alarm_server_local",
+				Table:  "alert",
+				SQL:    SQLBase64(sql),
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			mockHTTPReq(t, mongoConsole, opt, reqBody, "/console/mongodb")
+		}
+	})
+}
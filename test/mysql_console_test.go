@@ -21,7 +21,7 @@ func SQLBase64(sql string) string {
 	return base64.StdEncoding.EncodeToString([]byte(sql))
 }
 
-func mockHTTPReq(t *testing.T, cle console.Console, opt *common.HandlerOptions, req []byte, url string) {
+func mockHTTPReq(t *testing.T, cle console.Console, opt *common.HandlerOptions, req []byte, url string) *common.Resp {
 	fakeReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(req))
 	require.NoError(t, err)
 
@@ -38,6 +38,11 @@ func mockHTTPReq(t *testing.T, cle console.Console, opt *common.HandlerOptions,
 	require.NoError(t, err)
 
 	fmt.Println(string(respBodyByte))
+
+	resp := &common.Resp{}
+	require.NoError(t, json.Unmarshal(respBodyByte, resp))
+
+	return resp
 }
 
 func TestMySQLConsoleWithDefaultOpt(t *testing.T) {
@@ -71,6 +76,18 @@ func TestMySQLConsoleWithDefaultOpt(t *testing.T) {
 		mockHTTPReq(t, mysqlConsole, opt, reqBody, "/console/mysql")
 	})
 
+	t.Run("fetch objects", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchObjects,
+			Schema: "alarm_server_local",
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, mysqlConsole, opt, reqBody, "/console/mysql")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Contains(t, resp.Result, "tableTypes")
+	})
+
 	t.Run("sql query with forbidden SQL", func(t *testing.T) {
 		SQList := []string{
 			`delete from alarm_server_local where id=1`,
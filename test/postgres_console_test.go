@@ -0,0 +1,83 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/console"
+)
+
+func TestPostgresConsoleWithDefaultOpt(t *testing.T) {
+	postgresConsole := console.NewPostgresConsole()
+
+	opt := &common.HandlerOptions{
+		Conn: common.ConnConfig{
+			IP:       "172.168.1.53",
+			Port:     15432,
+			UserName: "postgres",
+			Password: "aykj83752661",
+		},
+	}
+
+	t.Run("fetch schema", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchSchema,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		mockHTTPReq(t, postgresConsole, opt, reqBody, "/console/postgres")
+	})
+
+	t.Run("fetch table", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchTable,
+			Schema: "alarm_server_local",
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		mockHTTPReq(t, postgresConsole, opt, reqBody, "/console/postgres")
+	})
+
+	t.Run("sql query with forbidden SQL", func(t *testing.T) {
+		SQList := []string{
+			`delete from public.console_test where id=1`,
+			`drop table public.console_test`,
+			`explain analyze delete from public.console_test`,
+			`select 1; drop table public.console_test`,
+		}
+
+		for _, sql := range SQList {
+			fakeQueryMeta := &common.QueryMeta{
+				Action: common.ActionSQLQuery,
+				Schema: "alarm_server_local",
+				Table:  "public.console_test",
+				SQL:    SQLBase64(sql),
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			mockHTTPReq(t, postgresConsole, opt, reqBody, "/console/postgres")
+		}
+	})
+
+	t.Run("sql query with valid SQL", func(t *testing.T) {
+		SQLList := []string{
+			``,
+			`explain select * from public.console_test`,
+			`select * from public.console_test`,
+			`show search_path`,
+		}
+
+		for _, sql := range SQLList {
+			fakeQueryMeta := &common.QueryMeta{
+				Action: common.ActionSQLQuery,
+				Schema: "alarm_server_local",
+				Table:  "public.console_test",
+				SQL:    SQLBase64(sql),
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			mockHTTPReq(t, postgresConsole, opt, reqBody, "/console/postgres")
+		}
+	})
+}
@@ -48,6 +48,26 @@ func TestRedisConsoleWithDefaultOpt(t *testing.T) {
 		mockHTTPReq(t, redisConsole, opt, reqBody, "/console/redis")
 	})
 
+	t.Run("complete command and key", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action:   common.ActionCompleteSQL,
+			Schema:   "db1",
+			SQL:      SQLBase64("hg"),
+			Position: 2,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, redisConsole, opt, reqBody, "/console/redis")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.NotEmpty(t, resp.Result.(map[string]interface{})["candidates"])
+
+		fakeQueryMeta.SQL = SQLBase64("get ga")
+		fakeQueryMeta.Position = 6
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		mockHTTPReq(t, redisConsole, opt, reqBody, "/console/redis")
+	})
+
 	t.Run("redis query safe command", func(t *testing.T) {
 		SQLList := []string{
 			`ttl game`,
@@ -0,0 +1,896 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ylh990835774/ay-go-components/pkg/common"
+	"github.com/ylh990835774/ay-go-components/pkg/console"
+	"github.com/ylh990835774/ay-go-components/pkg/engine"
+	"github.com/ylh990835774/ay-go-components/pkg/inerr"
+	"github.com/ylh990835774/ay-go-components/pkg/ticket"
+)
+
+// sqlite console run fully offline with a temporary database file
+func TestSQLiteConsole(t *testing.T) {
+	sqliteConsole := console.NewSQLiteConsole()
+
+	conn := common.ConnConfig{
+		FilePath: filepath.Join(t.TempDir(), "console_test.db"),
+	}
+
+	// prepare data by turn off system intercept
+	prepareOpt := &common.HandlerOptions{
+		Conn:                    conn,
+		IsIgnoreSystemIntercept: true,
+	}
+
+	for _, sql := range []string{
+		`create table console_test (id integer primary key, name varchar(32), money decimal(10, 2))`,
+		`insert into console_test(id, name, money) values (1, 'li', 10.5), (2, 'wang', null)`,
+	} {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionSQLQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(sql),
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, prepareOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+	}
+
+	opt := &common.HandlerOptions{
+		Conn: conn,
+	}
+
+	t.Run("fetch schema", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchSchema,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Contains(t, resp.Result, "main")
+	})
+
+	t.Run("fetch table", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchTable,
+			Schema: "main",
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Equal(t, []interface{}{"console_test"}, resp.Result)
+	})
+
+	t.Run("sql query with valid SQL", func(t *testing.T) {
+		SQLList := []string{
+			``,
+			`select * from console_test`,
+			`select name, count(*) from console_test group by name`,
+		}
+
+		for _, sql := range SQLList {
+			fakeQueryMeta := &common.QueryMeta{
+				Action: common.ActionSQLQuery,
+				Schema: "main",
+				Table:  "console_test",
+				SQL:    SQLBase64(sql),
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+			require.Equal(t, 200, resp.Code, sql)
+		}
+	})
+
+	t.Run("sql query with pagination", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action:   common.ActionSQLQuery,
+			Schema:   "main",
+			Table:    "console_test",
+			SQL:      SQLBase64(`select * from console_test order by id`),
+			PageSize: 1,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		result := resp.Result.(map[string]interface{})
+		require.Len(t, result["rows"], 1)
+		require.NotEmpty(t, result["nextCursor"])
+
+		// fetch next page by cursor
+		fakeQueryMeta.Cursor = result["nextCursor"].(string)
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		result = resp.Result.(map[string]interface{})
+		require.Len(t, result["rows"], 1)
+		require.Equal(t, "wang", result["rows"].([]interface{})[0].(map[string]interface{})["name"])
+		require.Nil(t, result["nextCursor"])
+
+		// invalid cursor
+		fakeQueryMeta.Cursor = "invalid"
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+	})
+
+	t.Run("fetch table with pagination", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action:   common.ActionFetchTable,
+			Schema:   "main",
+			PageSize: 10,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Equal(t, map[string]interface{}{"tables": []interface{}{"console_test"}}, resp.Result)
+	})
+
+	t.Run("export query", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionExportQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(`select id, name from console_test order by id`),
+			Format: common.ExportFormatCSV,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		fakeReq := httptest.NewRequest(http.MethodPost, "/console/sqlite", bytes.NewReader(reqBody))
+		fakeReq.Header.Set("Content-Type", "application/json")
+		fakeResp := httptest.NewRecorder()
+
+		console.Handler(fakeResp, fakeReq, "/console/sqlite", sqliteConsole, opt)
+
+		require.Equal(t, "text/csv; charset=utf-8", fakeResp.Header().Get("Content-Type"))
+		require.Contains(t, fakeResp.Header().Get("Content-Disposition"), `attachment; filename=console_test_`)
+		require.Equal(t, "id,name\n1,li\n2,wang\n", fakeResp.Body.String())
+
+		// intercept rules are same as sqlQuery
+		fakeQueryMeta.SQL = SQLBase64(`delete from console_test`)
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+
+		// table of INSERT statement is the table of select, not the chosen table
+		fakeQueryMeta.Table = "other"
+		fakeQueryMeta.Format = common.ExportFormatSQL
+		fakeQueryMeta.SQL = SQLBase64(`select id, name from console_test t where id = 1`)
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		fakeReq = httptest.NewRequest(http.MethodPost, "/console/sqlite", bytes.NewReader(reqBody))
+		fakeReq.Header.Set("Content-Type", "application/json")
+		fakeResp = httptest.NewRecorder()
+
+		console.Handler(fakeResp, fakeReq, "/console/sqlite", sqliteConsole, opt)
+		require.Equal(t, "INSERT INTO `console_test` (`id`, `name`) VALUES (1, 'li');\n", fakeResp.Body.String())
+
+		// select not over a single table is rejected by sql format
+		for _, sql := range []string{
+			`select a.id, b.name from console_test a join console_test b on a.id = b.id`,
+			`select id, name from (select id, name from console_test) t`,
+			`select id, name from console_test union select id, name from console_test`,
+		} {
+			fakeQueryMeta.SQL = SQLBase64(sql)
+			reqBody, _ = json.Marshal(fakeQueryMeta)
+
+			resp = mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+			require.Equal(t, 500, resp.Code, sql)
+			require.Contains(t, resp.Message, inerr.ErrExportNotSingleTable.Error(), sql)
+		}
+	})
+
+	t.Run("fetch schema and table with hooks", func(t *testing.T) {
+		actions := make([]string, 0)
+		hookOpt := &common.HandlerOptions{
+			Conn: conn,
+			QueryBeforeHooks: []common.PreHook{func(args *common.PrevHookArgs) error {
+				actions = append(actions, args.Action)
+				if args.Action == common.ActionFetchTable && args.Schema == "temp" {
+					return errors.New("schema temp is forbidden")
+				}
+				return nil
+			}},
+			QueryAfterHooks: []common.PostHook{func(args *common.PostHookArgs) {
+				// hide tables start with console_
+				names := make([]string, 0)
+				for _, name := range args.Names {
+					if !strings.HasPrefix(name, "console_") {
+						names = append(names, name)
+					}
+				}
+				args.Names = names
+			}},
+		}
+
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFetchSchema,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, hookOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Contains(t, resp.Result, "main")
+
+		fakeQueryMeta = &common.QueryMeta{
+			Action: common.ActionFetchTable,
+			Schema: "main",
+		}
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, hookOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Equal(t, []interface{}{}, resp.Result)
+
+		fakeQueryMeta.Schema = "temp"
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, hookOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, "schema temp is forbidden")
+
+		require.Equal(t, []string{common.ActionFetchSchema, common.ActionFetchTable, common.ActionFetchTable}, actions)
+	})
+
+	t.Run("sql query with caller", func(t *testing.T) {
+		var prevCaller, postCaller *common.Caller
+		callerOpt := &common.HandlerOptions{
+			Conn: conn,
+			CallerExtractor: func(req *http.Request) (*common.Caller, error) {
+				user := req.Header.Get("X-User")
+				if user == "" {
+					return nil, errors.New("unauthorized")
+				}
+				return &common.Caller{
+					Principal: user,
+					Metadata:  map[string]interface{}{"role": "dba"},
+				}, nil
+			},
+			QueryBeforeHook: func(args *common.PrevHookArgs) error {
+				prevCaller = args.Caller
+				return nil
+			},
+			QueryAfterHook: func(args *common.PostHookArgs) {
+				postCaller = args.Caller
+			},
+		}
+
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionSQLQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(`select * from console_test`),
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		fakeReq := httptest.NewRequest(http.MethodPost, "/console/sqlite", bytes.NewReader(reqBody))
+		fakeReq.Header.Set("Content-Type", "application/json")
+		fakeReq.Header.Set("X-User", "li")
+		fakeReq.Header.Set("X-Request-Id", "req-1")
+		fakeResp := httptest.NewRecorder()
+
+		console.Handler(fakeResp, fakeReq, "/console/sqlite", sqliteConsole, callerOpt)
+		require.Equal(t, http.StatusOK, fakeResp.Code)
+
+		require.NotNil(t, prevCaller)
+		require.Equal(t, "li", prevCaller.Principal)
+		require.Equal(t, "192.0.2.1", prevCaller.RemoteIP)
+		require.Equal(t, "req-1", prevCaller.RequestID)
+		require.Equal(t, "li", prevCaller.Headers.Get("X-User"))
+		require.Equal(t, "dba", prevCaller.Metadata["role"])
+		require.Equal(t, prevCaller, postCaller)
+
+		// request is rejected when extractor failed
+		resp := mockHTTPReq(t, sqliteConsole, callerOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, "unauthorized")
+	})
+
+	t.Run("sql query rewritten by hooks", func(t *testing.T) {
+		var postArgs *common.PostHookArgs
+		rewriteOpt := &common.HandlerOptions{
+			Conn: conn,
+			QueryBeforeHooks: []common.PreHook{
+				func(args *common.PrevHookArgs) error {
+					args.SQL = strings.Replace(args.SQL, "select *", "select id", 1)
+					return nil
+				},
+				func(args *common.PrevHookArgs) error {
+					if strings.Contains(args.OriginalSQL, "wang") {
+						args.SQL = `delete from console_test`
+					}
+					return nil
+				},
+			},
+			QueryAfterHook: func(args *common.PostHookArgs) {
+				postArgs = args
+			},
+		}
+
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionSQLQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(`select * from console_test where id = 1`),
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, rewriteOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		result := resp.Result.(map[string]interface{})
+		require.Equal(t, []interface{}{"id"}, result["columns"])
+
+		require.NotNil(t, postArgs)
+		require.Contains(t, postArgs.OriginalSQL, "select * from console_test where id = 1")
+		require.Contains(t, postArgs.SQL, "select id from console_test where id = 1")
+
+		// rewritten statement is checked again by intercept rules
+		fakeQueryMeta.SQL = SQLBase64(`select * from console_test where name = 'wang'`)
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, rewriteOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrSQLForbidden.Error())
+	})
+
+	t.Run("sql query with mask rules", func(t *testing.T) {
+		maskOpt := &common.HandlerOptions{
+			Conn: conn,
+			MaskRules: []common.MaskRule{
+				{Table: "console_*", Column: "name", Strategy: common.MaskStrategyRedact},
+			},
+		}
+
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionSQLQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(`select id, name as n from console_test order by id`),
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, maskOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		result := resp.Result.(map[string]interface{})
+		rows := result["rows"].([]interface{})
+		require.Equal(t, common.MaskRedacted, rows[0].(map[string]interface{})["n"])
+		require.Equal(t, float64(1), rows[0].(map[string]interface{})["id"])
+		require.Equal(t, true, result["columnTypes"].([]interface{})[1].(map[string]interface{})["masked"])
+
+		// export is masked too
+		fakeQueryMeta.Action = common.ActionExportQuery
+		fakeQueryMeta.Format = common.ExportFormatCSV
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		fakeReq := httptest.NewRequest(http.MethodPost, "/console/sqlite", bytes.NewReader(reqBody))
+		fakeReq.Header.Set("Content-Type", "application/json")
+		fakeResp := httptest.NewRecorder()
+
+		console.Handler(fakeResp, fakeReq, "/console/sqlite", sqliteConsole, maskOpt)
+		require.Equal(t, "id,n\n1,******\n2,******\n", fakeResp.Body.String())
+	})
+
+	t.Run("sql query with lint rules", func(t *testing.T) {
+		var postArgs *common.PostHookArgs
+		lintOpt := &common.HandlerOptions{
+			Conn:      conn,
+			LintRules: engine.DefaultLintRules(),
+			QueryAfterHook: func(args *common.PostHookArgs) {
+				postArgs = args
+			},
+		}
+
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionSQLQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(`select id from console_test where name like '%a'`),
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		// warning is returned with result
+		resp := mockHTTPReq(t, sqliteConsole, lintOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		warnings := resp.Result.(map[string]interface{})["warnings"].([]interface{})
+		require.Len(t, warnings, 1)
+		require.Equal(t, engine.LintRuleLeadingWildcard, warnings[0].(map[string]interface{})["rule"])
+
+		// statement is rejected with structured warnings
+		fakeQueryMeta.SQL = SQLBase64(`select sleep(1) from console_test`)
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, lintOpt, reqBody, "/console/sqlite")
+		require.Equal(t, common.RespCodeLintRejected, resp.Code, resp.Message)
+
+		warnings = resp.Result.(map[string]interface{})["warnings"].([]interface{})
+		require.Len(t, warnings, 1)
+		require.Equal(t, engine.LintRuleDangerousFunction, warnings[0].(map[string]interface{})["rule"])
+		require.Equal(t, common.LintLevelError, warnings[0].(map[string]interface{})["level"])
+
+		// rejected statement is passed to post hooks for audit
+		require.NotNil(t, postArgs)
+		require.False(t, postArgs.IsExecute)
+		require.ErrorIs(t, postArgs.Err, inerr.ErrSQLLintRejected)
+		require.Contains(t, postArgs.SQL, "sleep(1)")
+
+		// columns of table are counted by engine
+		lintOpt.LintRules = []common.LintRule{engine.LintSelectStar(2)}
+		fakeQueryMeta.SQL = SQLBase64(`select * from console_test`)
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, lintOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		warnings = resp.Result.(map[string]interface{})["warnings"].([]interface{})
+		require.Len(t, warnings, 1)
+		require.Contains(t, warnings[0].(map[string]interface{})["message"], "with 3 columns")
+	})
+
+	t.Run("approval ticket", func(t *testing.T) {
+		var executedSQL string
+		ticketOpt := &common.HandlerOptions{
+			Conn:        conn,
+			TicketStore: ticket.NewMemoryStore(),
+			CallerExtractor: func(req *http.Request) (*common.Caller, error) {
+				return &common.Caller{Principal: req.Header.Get("X-User")}, nil
+			},
+			QueryAfterHook: func(args *common.PostHookArgs) {
+				executedSQL = args.SQL
+			},
+		}
+
+		ticketReq := func(user string, queryMeta *common.QueryMeta) *common.Resp {
+			reqBody, _ := json.Marshal(queryMeta)
+			fakeReq := httptest.NewRequest(http.MethodPost, "/console/sqlite", bytes.NewReader(reqBody))
+			fakeReq.Header.Set("Content-Type", "application/json")
+			fakeReq.Header.Set("X-User", user)
+			fakeResp := httptest.NewRecorder()
+
+			console.Handler(fakeResp, fakeReq, "/console/sqlite", sqliteConsole, ticketOpt)
+			resp := &common.Resp{}
+			require.NoError(t, json.Unmarshal(fakeResp.Body.Bytes(), resp))
+			return resp
+		}
+
+		// principal is required
+		resp := ticketReq("", &common.QueryMeta{
+			Action: common.ActionSubmitTicket,
+			Schema: "main",
+			SQL:    SQLBase64(`insert into console_test(id, name) values (3, 'zhao')`),
+		})
+		require.Equal(t, 500, resp.Code, resp.Message)
+
+		// statement is checked when submitted
+		for sql, message := range map[string]string{
+			`not a statement`: "ticket preCheck failed",
+			`delete from console_test; drop table console_test`: "ticket preCheck failed",
+			`select * from console_test`:                        inerr.ErrTicketNotRequired.Error(),
+		} {
+			resp = ticketReq("li", &common.QueryMeta{
+				Action: common.ActionSubmitTicket,
+				Schema: "main",
+				SQL:    SQLBase64(sql),
+			})
+			require.Equal(t, 500, resp.Code, sql)
+			require.Contains(t, resp.Message, message, sql)
+		}
+
+		resp = ticketReq("li", &common.QueryMeta{
+			Action:  common.ActionSubmitTicket,
+			Schema:  "main",
+			Table:   "console_test",
+			SQL:     SQLBase64(`insert into console_test(id, name) values (3, 'zhao')`),
+			Comment: "add user",
+		})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		ticketID := resp.Result.(map[string]interface{})["id"].(string)
+		require.Equal(t, common.TicketPending, resp.Result.(map[string]interface{})["status"])
+
+		// pending ticket can not be executed
+		resp = ticketReq("li", &common.QueryMeta{Action: common.ActionExecuteTicket, TicketID: ticketID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrTicketStatus.Error())
+
+		// submitter can not approve own ticket
+		resp = ticketReq("li", &common.QueryMeta{Action: common.ActionApproveTicket, TicketID: ticketID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrTicketSelfApprove.Error())
+
+		resp = ticketReq("wang", &common.QueryMeta{Action: common.ActionApproveTicket, TicketID: ticketID, Comment: "ok"})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Equal(t, "wang", resp.Result.(map[string]interface{})["reviewer"])
+
+		resp = ticketReq("li", &common.QueryMeta{Action: common.ActionListTickets, Status: common.TicketApproved})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Len(t, resp.Result.([]interface{}), 1)
+
+		// statement outside AllowSQLType is executed with hooks
+		resp = ticketReq("li", &common.QueryMeta{Action: common.ActionExecuteTicket, TicketID: ticketID})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		executed := resp.Result.(map[string]interface{})["ticket"].(map[string]interface{})
+		require.Equal(t, common.TicketExecuted, executed["status"])
+		require.Equal(t, float64(1), executed["affectedRows"])
+		require.Contains(t, executedSQL, "insert into console_test")
+
+		// ticket is executed only once
+		resp = ticketReq("li", &common.QueryMeta{Action: common.ActionExecuteTicket, TicketID: ticketID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+
+		// rejected ticket
+		resp = ticketReq("li", &common.QueryMeta{
+			Action: common.ActionSubmitTicket,
+			Schema: "main",
+			SQL:    SQLBase64(`drop table console_test`),
+		})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		ticketID = resp.Result.(map[string]interface{})["id"].(string)
+
+		resp = ticketReq("wang", &common.QueryMeta{Action: common.ActionRejectTicket, TicketID: ticketID})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Equal(t, common.TicketRejected, resp.Result.(map[string]interface{})["status"])
+
+		resp = ticketReq("li", &common.QueryMeta{Action: common.ActionExecuteTicket, TicketID: ticketID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+
+		// clean up inserted row
+		result := sqliteConsole.QueryHandler(context.Background(), "main", "console_test", `delete from console_test where id = 3`, prepareOpt)
+		require.NoError(t, result.Err)
+	})
+
+	t.Run("sql query with dry run", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionSQLQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(`delete from console_test`),
+			DryRun: true,
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		// dry run is not supported by sqlite, statement is not executed
+		resp := mockHTTPReq(t, sqliteConsole, prepareOpt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrDryRunUnsupported.Error())
+
+		result := sqliteConsole.QueryHandler(context.Background(), "main", "console_test", `select count(*) as total from console_test`, opt)
+		require.NoError(t, result.Err)
+		require.EqualValues(t, 2, result.Rows[0]["total"])
+	})
+
+	t.Run("transaction session", func(t *testing.T) {
+		txOpt := &common.HandlerOptions{
+			Conn:          conn,
+			AllowSQLType:  []common.SQLType{common.StmtSelect, common.StmtInsert, common.StmtCommit},
+			TxIdleTimeout: 1,
+			CallerExtractor: func(req *http.Request) (*common.Caller, error) {
+				return &common.Caller{Principal: req.Header.Get("X-User")}, nil
+			},
+		}
+
+		txReq := func(user string, queryMeta *common.QueryMeta) *common.Resp {
+			queryMeta.Schema = "main"
+			reqBody, _ := json.Marshal(queryMeta)
+			fakeReq := httptest.NewRequest(http.MethodPost, "/console/sqlite", bytes.NewReader(reqBody))
+			fakeReq.Header.Set("Content-Type", "application/json")
+			fakeReq.Header.Set("X-User", user)
+			fakeResp := httptest.NewRecorder()
+
+			console.Handler(fakeResp, fakeReq, "/console/sqlite", sqliteConsole, txOpt)
+			resp := &common.Resp{}
+			require.NoError(t, json.Unmarshal(fakeResp.Body.Bytes(), resp))
+			return resp
+		}
+
+		count := func(sessionID string) interface{} {
+			resp := txReq("li", &common.QueryMeta{
+				Action:    common.ActionSQLQuery,
+				SQL:       SQLBase64(`select count(*) as total from console_test`),
+				SessionID: sessionID,
+			})
+			require.Equal(t, 200, resp.Code, resp.Message)
+			return resp.Result.(map[string]interface{})["rows"].([]interface{})[0].(map[string]interface{})["total"]
+		}
+
+		begin := func() string {
+			resp := txReq("li", &common.QueryMeta{Action: common.ActionBeginTx})
+			require.Equal(t, 200, resp.Code, resp.Message)
+			return resp.Result.(map[string]interface{})["sessionId"].(string)
+		}
+
+		insert := func(sessionID string) {
+			resp := txReq("li", &common.QueryMeta{
+				Action:    common.ActionSQLQuery,
+				SQL:       SQLBase64(`insert into console_test(id, name) values (3, 'zhao')`),
+				SessionID: sessionID,
+			})
+			require.Equal(t, 200, resp.Code, resp.Message)
+		}
+
+		// statement in session is visible in the session only before commit
+		sessionID := begin()
+		insert(sessionID)
+		require.EqualValues(t, 3, count(sessionID))
+		require.EqualValues(t, 2, count(""))
+
+		// transaction is finished by action only
+		resp := txReq("li", &common.QueryMeta{Action: common.ActionSQLQuery, SQL: SQLBase64(`commit`), SessionID: sessionID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrTxStatementForbidden.Error())
+
+		// statement can not be classified is rejected even if system intercept is ignored
+		txOpt.IsIgnoreSystemIntercept = true
+		resp = txReq("li", &common.QueryMeta{Action: common.ActionSQLQuery, SQL: SQLBase64(`end transaction`), SessionID: sessionID})
+		txOpt.IsIgnoreSystemIntercept = false
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrTxStatementForbidden.Error())
+		require.EqualValues(t, 3, count(sessionID))
+
+		// session belongs to caller who began it
+		resp = txReq("wang", &common.QueryMeta{Action: common.ActionRollbackTx, SessionID: sessionID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrTxSessionMismatch.Error())
+
+		resp = txReq("li", &common.QueryMeta{Action: common.ActionRollbackTx, SessionID: sessionID})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.EqualValues(t, 2, count(""))
+
+		resp = txReq("li", &common.QueryMeta{Action: common.ActionCommitTx, SessionID: sessionID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrTxSessionNotFound.Error())
+
+		// idle transaction is rolled back
+		sessionID = begin()
+		insert(sessionID)
+		time.Sleep(1500 * time.Millisecond)
+
+		resp = txReq("li", &common.QueryMeta{Action: common.ActionCommitTx, SessionID: sessionID})
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.EqualValues(t, 2, count(""))
+
+		// committed
+		sessionID = begin()
+		insert(sessionID)
+		resp = txReq("li", &common.QueryMeta{Action: common.ActionCommitTx, SessionID: sessionID})
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.EqualValues(t, 3, count(""))
+
+		result := sqliteConsole.QueryHandler(context.Background(), "main", "console_test", `delete from console_test where id = 3`, prepareOpt)
+		require.NoError(t, result.Err)
+	})
+
+	t.Run("execute script", func(t *testing.T) {
+		scriptOpt := &common.HandlerOptions{
+			Conn:         conn,
+			AllowSQLType: []common.SQLType{common.StmtSelect, common.StmtInsert, common.StmtDelete},
+		}
+
+		script := func(sql string, continueOnError bool) *common.Resp {
+			fakeQueryMeta := &common.QueryMeta{
+				Action:          common.ActionExecuteScript,
+				Schema:          "main",
+				SQL:             SQLBase64(sql),
+				ContinueOnError: continueOnError,
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+			return mockHTTPReq(t, sqliteConsole, scriptOpt, reqBody, "/console/sqlite")
+		}
+
+		statuses := func(resp *common.Resp) []interface{} {
+			statuses := make([]interface{}, 0)
+			for _, stmt := range resp.Result.(map[string]interface{})["statements"].([]interface{}) {
+				statuses = append(statuses, stmt.(map[string]interface{})["status"])
+			}
+			return statuses
+		}
+
+		// script is rejected before execution if any statement is forbidden
+		resp := script(`insert into console_test(id, name) values (3, 'a;b'); drop table console_test`, false)
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, "statement 2")
+
+		// stop on error
+		resp = script(`insert into console_test(id, name) values (3, 'a;b');
+			insert into console_test(id, name) values (1, 'dup');
+			select * from console_test`, false)
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Equal(t, []interface{}{common.ScriptStmtSucceed, common.ScriptStmtFailed, common.ScriptStmtSkipped}, statuses(resp))
+
+		first := resp.Result.(map[string]interface{})["statements"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, float64(1), first["affectedRows"])
+
+		// continue on error
+		resp = script(`insert into console_test(id, name) values (1, 'dup');
+			delete from console_test where id = 3;
+			select count(*) as total from console_test`, true)
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Equal(t, []interface{}{common.ScriptStmtFailed, common.ScriptStmtSucceed, common.ScriptStmtSucceed}, statuses(resp))
+
+		last := resp.Result.(map[string]interface{})["statements"].([]interface{})[2].(map[string]interface{})
+		require.Equal(t, float64(2), last["result"].(map[string]interface{})["rows"].([]interface{})[0].(map[string]interface{})["total"])
+	})
+
+	t.Run("sql query with bind params", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionSQLQuery,
+			Schema: "main",
+			Table:  "console_test",
+			SQL:    SQLBase64(`select id, name from console_test where id = ? and name = :name`),
+			Params: []common.QueryParam{
+				{Type: common.ParamTypeInt, Value: 1},
+				{Name: "name", Type: common.ParamTypeString, Value: "li' or '1' = '1"},
+			},
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		// value is passed as placeholder, not concatenated into statement
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Empty(t, resp.Result.(map[string]interface{})["rows"])
+
+		fakeQueryMeta.Params[1].Value = "li"
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Len(t, resp.Result.(map[string]interface{})["rows"], 1)
+
+		// named param is missing
+		fakeQueryMeta.Params = fakeQueryMeta.Params[:1]
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrParamMissing.Error())
+	})
+
+	t.Run("describe table", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionDescribeTable,
+			Schema: "main",
+			Table:  "console_test",
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		detail := resp.Result.(map[string]interface{})
+		require.Equal(t, "BASE TABLE", detail["type"])
+		columns := detail["columns"].([]interface{})
+		require.Len(t, columns, 3)
+		require.Equal(t, "decimal(10, 2)", columns[2].(map[string]interface{})["databaseType"])
+		require.Equal(t, "PRIMARY", detail["indexes"].([]interface{})[0].(map[string]interface{})["name"])
+
+		fakeQueryMeta.Action = common.ActionShowCreateTable
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+		require.Contains(t, resp.Result.(map[string]interface{})["ddl"], "CREATE TABLE console_test")
+
+		fakeQueryMeta.Table = "not_exist"
+		reqBody, _ = json.Marshal(fakeQueryMeta)
+
+		resp = mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 500, resp.Code, resp.Message)
+		require.Contains(t, resp.Message, inerr.ErrTableNotFound.Error())
+	})
+
+	t.Run("complete SQL", func(t *testing.T) {
+		complete := func(sql string, position int) []interface{} {
+			fakeQueryMeta := &common.QueryMeta{
+				Action:   common.ActionCompleteSQL,
+				Schema:   "main",
+				SQL:      SQLBase64(sql),
+				Position: position,
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+			require.Equal(t, 200, resp.Code, resp.Message)
+
+			labels := make([]interface{}, 0)
+			for _, c := range resp.Result.(map[string]interface{})["candidates"].([]interface{}) {
+				labels = append(labels, c.(map[string]interface{})["label"])
+			}
+			return labels
+		}
+
+		// columns of table in FROM clause, functions and keywords
+		require.Equal(t, []interface{}{"name", "NULLIF", "NOW", "NOT", "NULL"}, complete("select n from console_test", 8))
+		require.Equal(t, []interface{}{"money"}, complete("select c.m from console_test c", 10))
+		require.Equal(t, []interface{}{"console_test"}, complete("select * from con", 17))
+		require.Empty(t, complete("select * from console_test where name = 'con", 44))
+	})
+
+	t.Run("format SQL", func(t *testing.T) {
+		fakeQueryMeta := &common.QueryMeta{
+			Action: common.ActionFormatSQL,
+			Schema: "main",
+			SQL:    SQLBase64(`select id, name from console_test where id in (1, 2) order by id`),
+		}
+		reqBody, _ := json.Marshal(fakeQueryMeta)
+
+		resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+		require.Equal(t, 200, resp.Code, resp.Message)
+
+		result := resp.Result.(map[string]interface{})
+		require.Equal(t, "SELECT id, `name`\nFROM console_test\nWHERE id IN (1, 2)\nORDER BY id ASC", result["sql"])
+		statement := result["statements"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "select id, `name` from console_test where id in (?) order by id asc", statement["fingerprint"])
+
+		// executions of the same shape share fingerprint in post hook
+		fingerprints := make([]string, 0)
+		hookOpt := &common.HandlerOptions{
+			Conn: conn,
+			QueryAfterHook: func(args *common.PostHookArgs) {
+				fingerprints = append(fingerprints, args.Fingerprint)
+			},
+		}
+		for _, sql := range []string{
+			`select * from console_test where id = 1`,
+			`SELECT * FROM console_test WHERE id = 2`,
+		} {
+			fakeQueryMeta := &common.QueryMeta{
+				Action: common.ActionSQLQuery,
+				Schema: "main",
+				Table:  "console_test",
+				SQL:    SQLBase64(sql),
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			resp := mockHTTPReq(t, sqliteConsole, hookOpt, reqBody, "/console/sqlite")
+			require.Equal(t, 200, resp.Code, resp.Message)
+		}
+		// fingerprint is of the executed statement, limit is added by system intercept
+		require.Equal(t, []string{"select * from console_test where id = ? limit ?", "select * from console_test where id = ? limit ?"}, fingerprints)
+	})
+
+	t.Run("sql query with forbidden SQL", func(t *testing.T) {
+		SQLList := []string{
+			`delete from console_test where id=1`,
+			`update console_test set name = 'a'`,
+			`drop table console_test`,
+			`select * from console_test; drop table console_test`,
+		}
+
+		for _, sql := range SQLList {
+			fakeQueryMeta := &common.QueryMeta{
+				Action: common.ActionSQLQuery,
+				Schema: "main",
+				Table:  "console_test",
+				SQL:    SQLBase64(sql),
+			}
+			reqBody, _ := json.Marshal(fakeQueryMeta)
+
+			resp := mockHTTPReq(t, sqliteConsole, opt, reqBody, "/console/sqlite")
+			require.Equal(t, 500, resp.Code, sql)
+		}
+	})
+}